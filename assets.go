@@ -0,0 +1,99 @@
+package main
+
+import (
+	"compress/gzip"
+	"embed"
+	"io"
+	"io/fs"
+	"log"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// assetPrefix is the URL path of the embedded client-side assets
+const assetPrefix = "/__godown/assets/"
+
+// assetFS holds the client-side scripts and the third-party libraries
+// vendored under assets/vendor (stored gzipped when large)
+//
+//go:embed assets
+var assetFS embed.FS
+
+// vendoredLibraries are the third-party libraries of assets/vendor, by the
+// file loaded by godown.js
+var vendoredLibraries = []struct{ name, file string }{
+	{"Mermaid", "assets/vendor/mermaid.min.js.gz"},
+	{"KaTeX", "assets/vendor/katex/katex.min.js.gz"},
+	{"KaTeX stylesheet", "assets/vendor/katex/katex.min.css.gz"},
+	{"KaTeX fonts", "assets/vendor/katex/fonts"},
+	{"highlight.js", "assets/vendor/highlight/highlight.min.js.gz"},
+	{"highlight.js light theme", "assets/vendor/highlight/github.min.css.gz"},
+	{"highlight.js dark theme", "assets/vendor/highlight/github-dark.min.css.gz"},
+}
+
+// missingLibraries returns the names of the vendored libraries absent from
+// the filesystem
+func missingLibraries(fsys fs.FS) []string {
+	var missing []string
+	for _, library := range vendoredLibraries {
+		if _, err := fs.Stat(fsys, library.file); err != nil {
+			missing = append(missing, library.name)
+		}
+	}
+	return missing
+}
+
+// serveAsset serves an embedded asset
+func serveAsset(w http.ResponseWriter, r *http.Request) {
+	serveAssetFS(w, r, assetFS)
+}
+
+// serveAssetFS serves an asset of the filesystem. Gzipped assets are sent
+// as is to clients accepting gzip, else decompressed.
+func serveAssetFS(w http.ResponseWriter, r *http.Request, fsys fs.FS) {
+	name := path.Clean("assets/" + strings.TrimPrefix(r.URL.Path, assetPrefix))
+	if !strings.HasPrefix(name, "assets/") {
+		http.NotFound(w, r)
+		return
+	}
+
+	contentType := mime.TypeByExtension(path.Ext(name))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	content, err := fs.ReadFile(fsys, name)
+	if err == nil {
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Cache-Control", "public, max-age=86400")
+		w.Write(content)
+		return
+	}
+
+	file, err := fsys.Open(name + ".gz")
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer file.Close()
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+	w.Header().Set("Vary", "Accept-Encoding")
+	if strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+		w.Header().Set("Content-Encoding", "gzip")
+		io.Copy(w, file)
+		return
+	}
+
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		log.Printf("Error reading asset %s: %v", name, err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	defer reader.Close()
+	io.Copy(w, reader)
+}
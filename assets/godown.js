@@ -0,0 +1,308 @@
+// Client-side rendering of godown pages: loads the embedded libraries only
+// when the page needs them.
+(function () {
+  "use strict";
+
+  var assets = "/__godown/assets/";
+  var darkMode = window.matchMedia("(prefers-color-scheme: dark)");
+
+  function loadScript(src, onload) {
+    var script = document.createElement("script");
+    script.src = assets + src;
+    script.onload = onload;
+    script.onerror = function () {
+      console.warn("godown: cannot load " + script.src);
+    };
+    document.head.appendChild(script);
+  }
+
+  function loadStylesheet(href, media) {
+    var link = document.createElement("link");
+    link.rel = "stylesheet";
+    link.href = assets + href;
+    if (media) {
+      link.media = media;
+    }
+    document.head.appendChild(link);
+  }
+
+  // Math formulas typeset by KaTeX, invalid ones shown in error color
+  function renderFormulas(formulas) {
+    formulas.forEach(function (formula) {
+      window.katex.render(formula.textContent, formula, {
+        displayMode: formula.classList.contains("godown-math-display"),
+        throwOnError: false,
+      });
+    });
+  }
+
+  // Mermaid diagrams, drawn again with the matching theme when the color
+  // scheme changes
+  function renderDiagrams(diagrams) {
+    window.mermaid.initialize({
+      startOnLoad: false,
+      theme: darkMode.matches ? "dark" : "default",
+    });
+    diagrams.forEach(function (diagram) {
+      diagram.removeAttribute("data-processed");
+      diagram.textContent = diagram.dataset.source;
+    });
+    window.mermaid.run({ nodes: diagrams });
+  }
+
+  // Fenced code blocks of a known language, colored with the theme of the
+  // color scheme
+  function highlightCode(blocks) {
+    window.hljs.configure({ ignoreUnescapedHTML: true });
+    blocks.forEach(function (block) {
+      window.hljs.highlightElement(block);
+    });
+  }
+
+  // Data tables sorted by clicking a column header, filtered by the rows
+  // containing a text and paginated
+  function enhanceTable(container) {
+    var table = container.querySelector("table");
+    var tbody = table.tBodies[0];
+    var rows = Array.prototype.slice.call(tbody.rows);
+    var pageSize = parseInt(container.dataset.pageSize, 10) || rows.length;
+    var matching = rows;
+    var page = 0;
+
+    var controls = document.createElement("div");
+    controls.className = "godown-table-controls";
+    var filter = document.createElement("input");
+    filter.type = "search";
+    filter.placeholder = "Filter rows";
+    var previous = document.createElement("button");
+    previous.type = "button";
+    previous.textContent = "Previous";
+    var next = document.createElement("button");
+    next.type = "button";
+    next.textContent = "Next";
+    var status = document.createElement("span");
+    controls.append(filter, previous, status, next);
+    container.insertBefore(controls, table);
+
+    function show() {
+      var pages = Math.max(1, Math.ceil(matching.length / pageSize));
+      page = Math.min(page, pages - 1);
+      var start = page * pageSize;
+      var fragment = document.createDocumentFragment();
+      matching.slice(start, start + pageSize).forEach(function (row) {
+        fragment.appendChild(row);
+      });
+      tbody.replaceChildren(fragment);
+      status.textContent =
+        matching.length === 0
+          ? "No matching row"
+          : "Rows " +
+            (start + 1) +
+            "–" +
+            Math.min(start + pageSize, matching.length) +
+            " of " +
+            matching.length;
+      previous.disabled = page === 0;
+      next.disabled = page >= pages - 1;
+    }
+
+    filter.addEventListener("input", function () {
+      var text = filter.value.toLowerCase();
+      matching = rows.filter(function (row) {
+        return row.textContent.toLowerCase().indexOf(text) !== -1;
+      });
+      page = 0;
+      show();
+    });
+    previous.addEventListener("click", function () {
+      page--;
+      show();
+    });
+    next.addEventListener("click", function () {
+      page++;
+      show();
+    });
+
+    var headers = table.tHead.rows[0].cells;
+    Array.prototype.forEach.call(headers, function (th, column) {
+      th.addEventListener("click", function () {
+        var ascending = th.getAttribute("aria-sort") !== "ascending";
+        Array.prototype.forEach.call(headers, function (cell) {
+          cell.removeAttribute("aria-sort");
+        });
+        th.setAttribute("aria-sort", ascending ? "ascending" : "descending");
+        var compare = function (a, b) {
+          var x = a.cells[column].textContent;
+          var y = b.cells[column].textContent;
+          var order =
+            x.trim() !== "" && y.trim() !== "" && !isNaN(x) && !isNaN(y)
+              ? x - y
+              : x.localeCompare(y, undefined, { numeric: true });
+          return ascending ? order : -order;
+        };
+        rows.sort(compare);
+        matching.sort(compare);
+        show();
+      });
+    });
+
+    show();
+  }
+
+  // JSON Pointer of a value of a data tree copied to the clipboard, without
+  // collapsing the value
+  function copyPointer(event) {
+    var button = event.target.closest(".godown-pointer");
+    if (!button || !navigator.clipboard) {
+      return;
+    }
+    event.preventDefault();
+    navigator.clipboard.writeText(button.dataset.pointer).then(function () {
+      button.classList.add("copied");
+      setTimeout(function () {
+        button.classList.remove("copied");
+      }, 1000);
+    });
+  }
+
+  // Images of a gallery shown one at a time over the page, browsed with the
+  // arrow keys and closed with Escape
+  function enhanceGallery(gallery) {
+    var items = Array.prototype.slice.call(
+      gallery.querySelectorAll(".godown-gallery-item"),
+    );
+    var current = 0;
+
+    var lightbox = document.createElement("div");
+    lightbox.className = "godown-lightbox";
+    lightbox.hidden = true;
+    var image = document.createElement("img");
+    var caption = document.createElement("div");
+    caption.className = "godown-lightbox-caption";
+    function button(label, text, onclick) {
+      var element = document.createElement("button");
+      element.type = "button";
+      element.className = "godown-lightbox-" + label.toLowerCase();
+      element.setAttribute("aria-label", label);
+      element.textContent = text;
+      element.addEventListener("click", onclick);
+      return element;
+    }
+    lightbox.append(
+      image,
+      caption,
+      button("Previous", "‹", function () {
+        show(current - 1);
+      }),
+      button("Next", "›", function () {
+        show(current + 1);
+      }),
+      button("Close", "×", hide),
+    );
+    document.body.appendChild(lightbox);
+
+    function show(index) {
+      current = (index + items.length) % items.length;
+      var item = items[current];
+      image.src = item.href;
+      image.alt = item.title;
+      caption.textContent =
+        item.title + " (" + (current + 1) + "/" + items.length + ")";
+      lightbox.hidden = false;
+    }
+
+    function hide() {
+      lightbox.hidden = true;
+      image.removeAttribute("src");
+    }
+
+    items.forEach(function (item, index) {
+      item.addEventListener("click", function (event) {
+        // Modified clicks open the image in a new tab or window
+        if (
+          event.button !== 0 ||
+          event.ctrlKey ||
+          event.metaKey ||
+          event.shiftKey
+        ) {
+          return;
+        }
+        event.preventDefault();
+        show(index);
+      });
+    });
+    lightbox.addEventListener("click", function (event) {
+      if (event.target === lightbox) {
+        hide();
+      }
+    });
+    document.addEventListener("keydown", function (event) {
+      if (lightbox.hidden) {
+        return;
+      }
+      switch (event.key) {
+        case "Escape":
+          hide();
+          break;
+        case "ArrowLeft":
+          show(current - 1);
+          break;
+        case "ArrowRight":
+          show(current + 1);
+          break;
+        default:
+          return;
+      }
+      event.preventDefault();
+    });
+  }
+
+  document.addEventListener("click", copyPointer);
+
+  document.addEventListener("DOMContentLoaded", function () {
+    var diagrams = Array.prototype.slice.call(
+      document.querySelectorAll("pre.mermaid"),
+    );
+    if (diagrams.length > 0) {
+      diagrams.forEach(function (diagram) {
+        diagram.dataset.source = diagram.textContent;
+      });
+      loadScript("vendor/mermaid.min.js", function () {
+        renderDiagrams(diagrams);
+        darkMode.addEventListener("change", function () {
+          renderDiagrams(diagrams);
+        });
+      });
+    }
+
+    var formulas = Array.prototype.slice.call(
+      document.querySelectorAll(".godown-math"),
+    );
+    if (formulas.length > 0) {
+      loadStylesheet("vendor/katex/katex.min.css");
+      loadScript("vendor/katex/katex.min.js", function () {
+        renderFormulas(formulas);
+      });
+    }
+
+    var blocks = Array.prototype.slice.call(
+      document.querySelectorAll('pre > code[class*="language-"]'),
+    );
+    if (blocks.length > 0) {
+      loadStylesheet(
+        "vendor/highlight/github.min.css",
+        "(prefers-color-scheme: light)",
+      );
+      loadStylesheet(
+        "vendor/highlight/github-dark.min.css",
+        "(prefers-color-scheme: dark)",
+      );
+      loadScript("vendor/highlight/highlight.min.js", function () {
+        highlightCode(blocks);
+      });
+    }
+
+    document.querySelectorAll(".godown-table").forEach(enhanceTable);
+    document.querySelectorAll(".godown-gallery").forEach(enhanceGallery);
+  });
+})();
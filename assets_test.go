@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// Test the embedded client-side script
+func TestServeAsset(t *testing.T) {
+	req := httptest.NewRequest("GET", "/__godown/assets/godown.js", nil)
+	w := httptest.NewRecorder()
+	serveAsset(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if got := w.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/javascript") {
+		t.Errorf("Content-Type = %q, want text/javascript", got)
+	}
+	if !strings.Contains(w.Body.String(), "pre.mermaid") {
+		t.Errorf("godown.js should load Mermaid for diagrams")
+	}
+}
+
+// Test gzipped assets and missing files
+func TestServeAssetFS(t *testing.T) {
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	gz.Write([]byte("console.log('bundle')"))
+	gz.Close()
+
+	fsys := fstest.MapFS{
+		"assets/vendor/lib.js.gz": {Data: compressed.Bytes()},
+		"secret.txt":              {Data: []byte("secret")},
+	}
+
+	tests := []struct {
+		name     string
+		url      string
+		encoding string
+		status   int
+		body     string
+	}{
+		{"gzip client", "/__godown/assets/vendor/lib.js", "gzip, deflate", http.StatusOK, compressed.String()},
+		{"plain client", "/__godown/assets/vendor/lib.js", "", http.StatusOK, "console.log('bundle')"},
+		{"missing", "/__godown/assets/vendor/none.js", "", http.StatusNotFound, ""},
+		{"outside", "/__godown/assets/../secret.txt", "", http.StatusNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.URL.Path = tt.url
+			req.Header.Set("Accept-Encoding", tt.encoding)
+			w := httptest.NewRecorder()
+			serveAssetFS(w, req, fsys)
+
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d", w.Code, tt.status)
+			}
+			if tt.body != "" && w.Body.String() != tt.body {
+				t.Errorf("body = %q, want %q", w.Body.String(), tt.body)
+			}
+		})
+	}
+}
+
+// Test the detection of the libraries missing from a build
+func TestMissingLibraries(t *testing.T) {
+	fsys := fstest.MapFS{}
+	if missing := missingLibraries(fsys); len(missing) != len(vendoredLibraries) {
+		t.Errorf("missingLibraries() = %v, want every library", missing)
+	}
+	for _, library := range vendoredLibraries {
+		fsys[library.file] = &fstest.MapFile{}
+	}
+	if missing := missingLibraries(fsys); len(missing) != 0 {
+		t.Errorf("missingLibraries() = %v, want none", missing)
+	}
+}
@@ -0,0 +1,497 @@
+package main
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"debug/elf"
+	"debug/macho"
+	"debug/pe"
+	"encoding/binary"
+	"fmt"
+	"html/template"
+	"image"
+	"image/color"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxFormatRows bounds the number of rows of the detail table (sections,
+// chunks, archive entries...)
+const maxFormatRows = 200
+
+// formatInfo is the summary of a file format recognized by its magic bytes
+type formatInfo struct {
+	Name   string
+	Fields [][2]string // Parsed header fields (name, value)
+	Table  *formatTable
+}
+
+// formatTable is a detail list of the file structure
+type formatTable struct {
+	Title     string
+	Columns   []string
+	Rows      [][]string
+	Truncated int // Number of rows not listed
+}
+
+// addRow appends a row, counting the rows beyond maxFormatRows
+func (t *formatTable) addRow(row ...string) {
+	if len(t.Rows) >= maxFormatRows {
+		t.Truncated++
+		return
+	}
+	t.Rows = append(t.Rows, row)
+}
+
+// binaryFormats maps magic bytes (at offset 0) to their parser, in detection order
+var binaryFormats = []struct {
+	magic []byte
+	parse func(ra io.ReaderAt, size int64) (*formatInfo, error)
+}{
+	{[]byte("\x7fELF"), parseELF},
+	{[]byte("\xfe\xed\xfa\xce"), parseMachO},
+	{[]byte("\xfe\xed\xfa\xcf"), parseMachO},
+	{[]byte("\xce\xfa\xed\xfe"), parseMachO},
+	{[]byte("\xcf\xfa\xed\xfe"), parseMachO},
+	{[]byte("\xca\xfe\xba\xbe"), parseMachOFat},
+	{[]byte("MZ"), parsePE},
+	{[]byte("\x89PNG\r\n\x1a\n"), parsePNG},
+	{[]byte("\xff\xd8\xff"), parseJPEG},
+	{[]byte("PK\x03\x04"), parseZip},
+	{[]byte("PK\x05\x06"), parseZip},
+	{[]byte("\x1f\x8b"), parseGzip},
+	{[]byte("%PDF-"), parsePDF},
+	{[]byte("SQLite format 3\x00"), parseSQLite},
+	{[]byte("\x00asm"), parseWasm},
+}
+
+// identifyFormat detects the format of a file from its magic bytes and parses
+// its header, returning nil for unknown formats
+func identifyFormat(ra io.ReaderAt, size int64) (info *formatInfo) {
+	header := make([]byte, 16)
+	n, _ := ra.ReadAt(header, 0)
+	header = header[:n]
+
+	// Parsers of malformed files must not break the hex view
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Error parsing binary format: %v", r)
+			info = nil
+		}
+	}()
+
+	for _, format := range binaryFormats {
+		if !bytes.HasPrefix(header, format.magic) {
+			continue
+		}
+		info, err := format.parse(ra, size)
+		if err != nil {
+			log.Printf("Error parsing binary format: %v", err)
+			continue
+		}
+		return info
+	}
+	return nil
+}
+
+// parseELF summarizes an ELF binary and lists its sections
+func parseELF(ra io.ReaderAt, size int64) (*formatInfo, error) {
+	f, err := elf.NewFile(ra)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &formatInfo{
+		Name: "ELF " + strings.ToLower(strings.TrimPrefix(f.Type.String(), "ET_")),
+		Fields: [][2]string{
+			{"Class", strings.TrimPrefix(f.Class.String(), "ELFCLASS")},
+			{"Byte order", f.ByteOrder.String()},
+			{"OS/ABI", strings.TrimPrefix(f.OSABI.String(), "ELFOSABI_")},
+			{"Type", f.Type.String()},
+			{"Machine", strings.TrimPrefix(f.Machine.String(), "EM_")},
+			{"Entry point", fmt.Sprintf("0x%x", f.Entry)},
+			{"Sections", strconv.Itoa(len(f.Sections))},
+			{"Program headers", strconv.Itoa(len(f.Progs))},
+		},
+	}
+
+	table := &formatTable{Title: "Sections", Columns: []string{"Name", "Type", "Address", "Offset", "Size"}}
+	for _, section := range f.Sections {
+		table.addRow(section.Name, strings.TrimPrefix(section.Type.String(), "SHT_"),
+			fmt.Sprintf("0x%x", section.Addr), fmt.Sprintf("0x%x", section.Offset), formatBytes(int64(section.Size)))
+	}
+	info.Table = table
+	return info, nil
+}
+
+// parsePE summarizes a Windows PE binary and lists its sections
+func parsePE(ra io.ReaderAt, size int64) (*formatInfo, error) {
+	f, err := pe.NewFile(ra)
+	if err != nil {
+		return nil, err
+	}
+
+	machines := map[uint16]string{
+		pe.IMAGE_FILE_MACHINE_I386:  "x86",
+		pe.IMAGE_FILE_MACHINE_AMD64: "x86-64",
+		pe.IMAGE_FILE_MACHINE_ARM:   "ARM",
+		pe.IMAGE_FILE_MACHINE_ARM64: "ARM64",
+	}
+	machine, ok := machines[f.Machine]
+	if !ok {
+		machine = fmt.Sprintf("0x%04x", f.Machine)
+	}
+
+	kind := "executable"
+	if f.Characteristics&pe.IMAGE_FILE_DLL != 0 {
+		kind = "DLL"
+	}
+
+	info := &formatInfo{
+		Name: "PE " + kind,
+		Fields: [][2]string{
+			{"Machine", machine},
+			{"Sections", strconv.Itoa(len(f.Sections))},
+			{"Timestamp", time.Unix(int64(f.TimeDateStamp), 0).UTC().Format(time.RFC3339)},
+			{"Characteristics", fmt.Sprintf("0x%04x", f.Characteristics)},
+		},
+	}
+	switch header := f.OptionalHeader.(type) {
+	case *pe.OptionalHeader32:
+		info.Fields = append(info.Fields, [2]string{"Format", "PE32"}, [2]string{"Entry point", fmt.Sprintf("0x%x", header.AddressOfEntryPoint)}, [2]string{"Subsystem", strconv.Itoa(int(header.Subsystem))})
+	case *pe.OptionalHeader64:
+		info.Fields = append(info.Fields, [2]string{"Format", "PE32+"}, [2]string{"Entry point", fmt.Sprintf("0x%x", header.AddressOfEntryPoint)}, [2]string{"Subsystem", strconv.Itoa(int(header.Subsystem))})
+	}
+
+	table := &formatTable{Title: "Sections", Columns: []string{"Name", "Virtual address", "Virtual size", "Raw size"}}
+	for _, section := range f.Sections {
+		table.addRow(section.Name, fmt.Sprintf("0x%x", section.VirtualAddress), formatBytes(int64(section.VirtualSize)), formatBytes(int64(section.Size)))
+	}
+	info.Table = table
+	return info, nil
+}
+
+// parseMachO summarizes a Mach-O binary and lists its sections
+func parseMachO(ra io.ReaderAt, size int64) (*formatInfo, error) {
+	f, err := macho.NewFile(ra)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &formatInfo{
+		Name: "Mach-O " + strings.ToLower(strings.TrimPrefix(f.Type.String(), "Type")),
+		Fields: [][2]string{
+			{"CPU", strings.TrimPrefix(f.Cpu.String(), "Cpu")},
+			{"Type", f.Type.String()},
+			{"Byte order", f.ByteOrder.String()},
+			{"Load commands", strconv.Itoa(int(f.Ncmd))},
+			{"Sections", strconv.Itoa(len(f.Sections))},
+		},
+	}
+
+	table := &formatTable{Title: "Sections", Columns: []string{"Segment", "Section", "Address", "Size"}}
+	for _, section := range f.Sections {
+		table.addRow(section.Seg, section.Name, fmt.Sprintf("0x%x", section.Addr), formatBytes(int64(section.Size)))
+	}
+	info.Table = table
+	return info, nil
+}
+
+// parseMachOFat lists the architectures of a universal Mach-O binary
+func parseMachOFat(ra io.ReaderAt, size int64) (*formatInfo, error) {
+	f, err := macho.NewFatFile(ra)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &formatInfo{
+		Name:   "Mach-O universal binary",
+		Fields: [][2]string{{"Architectures", strconv.Itoa(len(f.Arches))}},
+	}
+	table := &formatTable{Title: "Architectures", Columns: []string{"CPU", "Type", "Offset", "Size"}}
+	for _, arch := range f.Arches {
+		table.addRow(strings.TrimPrefix(arch.Cpu.String(), "Cpu"), arch.Type.String(), fmt.Sprintf("0x%x", arch.Offset), formatBytes(int64(arch.Size)))
+	}
+	info.Table = table
+	return info, nil
+}
+
+// imageFields returns the dimensions and color model of an image
+func imageFields(ra io.ReaderAt, size int64) ([][2]string, error) {
+	config, _, err := image.DecodeConfig(io.NewSectionReader(ra, 0, size))
+	if err != nil {
+		return nil, err
+	}
+	return [][2]string{
+		{"Dimensions", fmt.Sprintf("%d × %d", config.Width, config.Height)},
+		{"Color model", colorModelName(config)},
+	}, nil
+}
+
+// colorModelName returns a readable name for the color model of an image
+func colorModelName(config image.Config) string {
+	if palette, ok := config.ColorModel.(color.Palette); ok {
+		return fmt.Sprintf("Paletted (%d colors)", len(palette))
+	}
+
+	models := []struct {
+		model color.Model
+		name  string
+	}{
+		{color.RGBAModel, "RGBA"},
+		{color.RGBA64Model, "RGBA 16 bits"},
+		{color.NRGBAModel, "NRGBA"},
+		{color.NRGBA64Model, "NRGBA 16 bits"},
+		{color.GrayModel, "Gray"},
+		{color.Gray16Model, "Gray 16 bits"},
+		{color.YCbCrModel, "YCbCr"},
+		{color.CMYKModel, "CMYK"},
+	}
+	for _, m := range models {
+		if config.ColorModel == m.model {
+			return m.name
+		}
+	}
+	return "unknown"
+}
+
+// parsePNG summarizes a PNG image and lists its chunks
+func parsePNG(ra io.ReaderAt, size int64) (*formatInfo, error) {
+	fields, err := imageFields(ra, size)
+	if err != nil {
+		return nil, err
+	}
+	info := &formatInfo{Name: "PNG image", Fields: fields}
+
+	// Chunks: 4 bytes length, 4 bytes type, data, 4 bytes CRC
+	table := &formatTable{Title: "Chunks", Columns: []string{"Type", "Offset", "Length"}}
+	header := make([]byte, 8)
+	for offset := int64(8); offset+8 <= size; {
+		if _, err := ra.ReadAt(header, offset); err != nil {
+			break
+		}
+		length := int64(binary.BigEndian.Uint32(header[:4]))
+		table.addRow(string(header[4:8]), fmt.Sprintf("0x%x", offset), formatBytes(length))
+		if string(header[4:8]) == "IEND" {
+			break
+		}
+		offset += 12 + length
+	}
+	info.Table = table
+	return info, nil
+}
+
+// parseJPEG summarizes a JPEG image
+func parseJPEG(ra io.ReaderAt, size int64) (*formatInfo, error) {
+	fields, err := imageFields(ra, size)
+	if err != nil {
+		return nil, err
+	}
+	return &formatInfo{Name: "JPEG image", Fields: fields}, nil
+}
+
+// parseZip summarizes a ZIP archive and lists its entries
+func parseZip(ra io.ReaderAt, size int64) (*formatInfo, error) {
+	r, err := zip.NewReader(ra, size)
+	if err != nil {
+		return nil, err
+	}
+
+	var total uint64
+	table := &formatTable{Title: "Entries", Columns: []string{"Name", "Size", "Compressed", "Method", "Modified"}}
+	for _, file := range r.File {
+		total += file.UncompressedSize64
+		method := strconv.Itoa(int(file.Method))
+		switch file.Method {
+		case zip.Store:
+			method = "stored"
+		case zip.Deflate:
+			method = "deflate"
+		}
+		table.addRow(file.Name, formatBytes(int64(file.UncompressedSize64)), formatBytes(int64(file.CompressedSize64)), method, file.Modified.Format("2006-01-02 15:04"))
+	}
+
+	info := &formatInfo{
+		Name: "ZIP archive",
+		Fields: [][2]string{
+			{"Entries", strconv.Itoa(len(r.File))},
+			{"Uncompressed size", formatBytes(int64(total))},
+		},
+		Table: table,
+	}
+	if r.Comment != "" {
+		info.Fields = append(info.Fields, [2]string{"Comment", r.Comment})
+	}
+	return info, nil
+}
+
+// parseGzip summarizes the header of a gzip stream
+func parseGzip(ra io.ReaderAt, size int64) (*formatInfo, error) {
+	zr, err := gzip.NewReader(io.NewSectionReader(ra, 0, size))
+	if err != nil {
+		return nil, err
+	}
+
+	info := &formatInfo{Name: "gzip compressed data"}
+	if zr.Name != "" {
+		info.Fields = append(info.Fields, [2]string{"Original name", zr.Name})
+	}
+	if !zr.ModTime.IsZero() {
+		info.Fields = append(info.Fields, [2]string{"Modified", zr.ModTime.UTC().Format(time.RFC3339)})
+	}
+	if zr.Comment != "" {
+		info.Fields = append(info.Fields, [2]string{"Comment", zr.Comment})
+	}
+
+	// The trailer stores the uncompressed size modulo 2^32
+	trailer := make([]byte, 4)
+	if _, err := ra.ReadAt(trailer, size-4); err == nil {
+		info.Fields = append(info.Fields, [2]string{"Uncompressed size", formatBytes(int64(binary.LittleEndian.Uint32(trailer)))})
+	}
+	return info, nil
+}
+
+// parsePDF reads the version of a PDF document
+func parsePDF(ra io.ReaderAt, size int64) (*formatInfo, error) {
+	header := make([]byte, 16)
+	n, _ := ra.ReadAt(header, 0)
+	line, _, _ := bytes.Cut(header[:n], []byte("\n"))
+	version := strings.TrimSpace(strings.TrimPrefix(string(line), "%PDF-"))
+	return &formatInfo{Name: "PDF document", Fields: [][2]string{{"Version", version}}}, nil
+}
+
+// parseSQLite summarizes the 100 bytes header of an SQLite database
+func parseSQLite(ra io.ReaderAt, size int64) (*formatInfo, error) {
+	header := make([]byte, 100)
+	if _, err := ra.ReadAt(header, 0); err != nil {
+		return nil, err
+	}
+
+	pageSize := int(binary.BigEndian.Uint16(header[16:18]))
+	if pageSize == 1 {
+		pageSize = 65536
+	}
+	encodings := map[uint32]string{1: "UTF-8", 2: "UTF-16le", 3: "UTF-16be"}
+	encoding, ok := encodings[binary.BigEndian.Uint32(header[56:60])]
+	if !ok {
+		encoding = "unknown"
+	}
+	journal := "rollback"
+	if header[18] == 2 {
+		journal = "WAL"
+	}
+
+	return &formatInfo{
+		Name: "SQLite database",
+		Fields: [][2]string{
+			{"Page size", strconv.Itoa(pageSize)},
+			{"Pages", strconv.Itoa(int(binary.BigEndian.Uint32(header[28:32])))},
+			{"Text encoding", encoding},
+			{"Journal mode", journal},
+			{"Schema format", strconv.Itoa(int(binary.BigEndian.Uint32(header[44:48])))},
+			{"User version", strconv.Itoa(int(binary.BigEndian.Uint32(header[60:64])))},
+			{"SQLite version", strconv.Itoa(int(binary.BigEndian.Uint32(header[96:100])))},
+		},
+	}, nil
+}
+
+// wasmSections names the known WebAssembly section ids
+var wasmSections = []string{"custom", "type", "import", "function", "table", "memory", "global", "export", "start", "element", "code", "data", "data count"}
+
+// parseWasm summarizes a WebAssembly module and lists its sections
+func parseWasm(ra io.ReaderAt, size int64) (*formatInfo, error) {
+	r := bufio.NewReader(io.NewSectionReader(ra, 0, size))
+	header := make([]byte, 8)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+
+	info := &formatInfo{
+		Name:   "WebAssembly module",
+		Fields: [][2]string{{"Version", strconv.Itoa(int(binary.LittleEndian.Uint32(header[4:8])))}},
+	}
+
+	table := &formatTable{Title: "Sections", Columns: []string{"Section", "Offset", "Size"}}
+	offset := int64(8)
+	for {
+		id, err := r.ReadByte()
+		if err != nil {
+			break
+		}
+		length, err := binary.ReadUvarint(r)
+		if err != nil {
+			break
+		}
+		name := fmt.Sprintf("unknown (%d)", id)
+		if int(id) < len(wasmSections) {
+			name = wasmSections[id]
+		}
+		table.addRow(name, fmt.Sprintf("0x%x", offset), formatBytes(int64(length)))
+
+		offset += 1 + int64(uvarintLen(length)) + int64(length)
+		if _, err := r.Discard(int(length)); err != nil {
+			break
+		}
+	}
+	info.Table = table
+	return info, nil
+}
+
+// uvarintLen returns the number of bytes of a LEB128-encoded value
+func uvarintLen(value uint64) int {
+	n := 1
+	for value >= 0x80 {
+		value >>= 7
+		n++
+	}
+	return n
+}
+
+// renderFormatInfo renders the format summary panel
+func renderFormatInfo(info *formatInfo) string {
+	if info == nil {
+		return ""
+	}
+
+	var result strings.Builder
+	result.WriteString("<div class=\"godown-format\">\n")
+	fmt.Fprintf(&result, "<strong>Format:</strong> %s\n", template.HTMLEscapeString(info.Name))
+
+	if len(info.Fields) > 0 {
+		result.WriteString("<table>\n")
+		for _, field := range info.Fields {
+			fmt.Fprintf(&result, "<tr><th>%s</th><td>%s</td></tr>\n", template.HTMLEscapeString(field[0]), template.HTMLEscapeString(field[1]))
+		}
+		result.WriteString("</table>\n")
+	}
+
+	if table := info.Table; table != nil && len(table.Rows) > 0 {
+		fmt.Fprintf(&result, "<details>\n<summary>%s (%d)</summary>\n<table>\n<tr>", template.HTMLEscapeString(table.Title), len(table.Rows)+table.Truncated)
+		for _, column := range table.Columns {
+			fmt.Fprintf(&result, "<th>%s</th>", template.HTMLEscapeString(column))
+		}
+		result.WriteString("</tr>\n")
+		for _, row := range table.Rows {
+			result.WriteString("<tr>")
+			for _, cell := range row {
+				fmt.Fprintf(&result, "<td>%s</td>", template.HTMLEscapeString(cell))
+			}
+			result.WriteString("</tr>\n")
+		}
+		result.WriteString("</table>\n")
+		if table.Truncated > 0 {
+			fmt.Fprintf(&result, "<p>%d more not listed</p>\n", table.Truncated)
+		}
+		result.WriteString("</details>\n")
+	}
+
+	result.WriteString("</div>\n")
+	return result.String()
+}
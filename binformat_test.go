@@ -0,0 +1,150 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"image"
+	"image/png"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// findField returns the value of a parsed header field
+func findField(info *formatInfo, name string) string {
+	for _, field := range info.Fields {
+		if field[0] == name {
+			return field[1]
+		}
+	}
+	return ""
+}
+
+// Test format detection of generated files
+func TestIdentifyFormat(t *testing.T) {
+	var pngData bytes.Buffer
+	png.Encode(&pngData, image.NewNRGBA(image.Rect(0, 0, 3, 2)))
+
+	var zipData bytes.Buffer
+	zw := zip.NewWriter(&zipData)
+	fw, _ := zw.Create("docs/readme.txt")
+	fw.Write([]byte("hello"))
+	zw.Close()
+
+	var gzData bytes.Buffer
+	gw := gzip.NewWriter(&gzData)
+	gw.Name = "notes.txt"
+	gw.Write([]byte("hello world"))
+	gw.Close()
+
+	sqlite := make([]byte, 100)
+	copy(sqlite, "SQLite format 3\x00")
+	sqlite[16], sqlite[17] = 0x10, 0x00 // page size 4096
+	sqlite[31] = 3                      // 3 pages
+	sqlite[59] = 1                      // UTF-8
+
+	// Header, type section of 1 byte, custom section of 2 bytes
+	wasm := []byte{0x00, 'a', 's', 'm', 0x01, 0x00, 0x00, 0x00, 0x01, 0x01, 0x00, 0x00, 0x02, 0x00, 0x00}
+
+	tests := []struct {
+		name     string
+		data     []byte
+		format   string
+		field    string
+		value    string
+		rowCount int
+	}{
+		{"PNG", pngData.Bytes(), "PNG image", "Dimensions", "3 × 2", 3},
+		{"ZIP", zipData.Bytes(), "ZIP archive", "Entries", "1", 1},
+		{"gzip", gzData.Bytes(), "gzip compressed data", "Original name", "notes.txt", 0},
+		{"gzip size", gzData.Bytes(), "gzip compressed data", "Uncompressed size", "11", 0},
+		{"ZIP size", zipData.Bytes(), "ZIP archive", "Uncompressed size", "5", 1},
+		{"PDF", []byte("%PDF-1.7\n%âãÏÓ\n"), "PDF document", "Version", "1.7", 0},
+		{"SQLite", sqlite, "SQLite database", "Page size", "4096", 0},
+		{"WASM", wasm, "WebAssembly module", "Version", "1", 2},
+		{"Unknown", []byte("just some bytes"), "", "", "", 0},
+		{"Malformed ELF", []byte("\x7fELF\x02\x01\x01"), "", "", "", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := identifyFormat(bytes.NewReader(tt.data), int64(len(tt.data)))
+			if tt.format == "" {
+				if info != nil {
+					t.Errorf("identifyFormat() = %+v, want nil", info)
+				}
+				return
+			}
+			if info == nil {
+				t.Fatalf("identifyFormat() = nil, want %s", tt.format)
+			}
+			if info.Name != tt.format {
+				t.Errorf("identifyFormat() name = %q, want %q", info.Name, tt.format)
+			}
+			if value := findField(info, tt.field); value != tt.value {
+				t.Errorf("field %s = %q, want %q", tt.field, value, tt.value)
+			}
+			rows := 0
+			if info.Table != nil {
+				rows = len(info.Table.Rows)
+			}
+			if rows != tt.rowCount {
+				t.Errorf("table rows = %d, want %d", rows, tt.rowCount)
+			}
+		})
+	}
+}
+
+// Test format detection of the test executable
+func TestIdentifyFormatExecutable(t *testing.T) {
+	prefixes := map[string]string{"linux": "ELF", "darwin": "Mach-O", "windows": "PE"}
+	prefix, ok := prefixes[runtime.GOOS]
+	if !ok {
+		t.Skip("unsupported platform")
+	}
+
+	path, err := os.Executable()
+	if err != nil {
+		t.Skip(err)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		t.Skip(err)
+	}
+	defer file.Close()
+	stat, _ := file.Stat()
+
+	info := identifyFormat(file, stat.Size())
+	if info == nil || !strings.HasPrefix(info.Name, prefix) {
+		t.Fatalf("identifyFormat(test binary) = %+v, want %s format", info, prefix)
+	}
+	if info.Table == nil || len(info.Table.Rows) == 0 {
+		t.Errorf("identifyFormat(test binary) should list sections")
+	}
+}
+
+// Test the summary panel rendering
+func TestRenderFormatInfo(t *testing.T) {
+	if renderFormatInfo(nil) != "" {
+		t.Errorf("renderFormatInfo(nil) should be empty")
+	}
+
+	table := &formatTable{Title: "Entries", Columns: []string{"Name"}}
+	for i := 0; i < maxFormatRows+5; i++ {
+		table.addRow("<file>")
+	}
+	html := renderFormatInfo(&formatInfo{Name: "Test", Fields: [][2]string{{"Key", "a & b"}}, Table: table})
+	for _, expected := range []string{
+		"<strong>Format:</strong> Test",
+		"<tr><th>Key</th><td>a &amp; b</td></tr>",
+		"<summary>Entries (205)</summary>",
+		"<td>&lt;file&gt;</td>",
+		"5 more not listed",
+	} {
+		if !strings.Contains(html, expected) {
+			t.Errorf("renderFormatInfo() should contain %q", expected)
+		}
+	}
+}
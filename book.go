@@ -0,0 +1,182 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/gomarkdown/markdown"
+	"github.com/gomarkdown/markdown/ast"
+)
+
+// bookPath is the URL path of the single-page view of the documentation
+const bookPath = "/__godown/book"
+
+// bookChapter is a page of the book
+type bookChapter struct {
+	ID       string // Anchor of the chapter, prefixing its heading IDs
+	Title    string
+	URL      string // Unescaped URL path of the page
+	Base     string // URL path of the file, against which its links are resolved
+	FilePath string
+	Tree     *docTree
+}
+
+// bookChapters lists the pages of the trees of the mount points in reading
+// order
+func bookChapters(mounts []*mount) []*bookChapter {
+	var chapters []*bookChapter
+	ids := map[string]int{}
+	for _, m := range mounts {
+		if m.Tree == nil {
+			continue
+		}
+		m.Tree.mu.RLock()
+		pages := m.Tree.pages
+		m.Tree.mu.RUnlock()
+
+		for _, page := range pages {
+			file := strings.TrimSuffix(page.File, path.Ext(page.File))
+			id := "chapter-" + headingID(m.Prefix+"/"+file)
+			if n := ids[id]; n > 0 {
+				id = fmt.Sprintf("%s-%d", id, n)
+			}
+			ids[id]++
+			pageURL, err := url.PathUnescape(page.URL)
+			if err != nil {
+				pageURL = page.URL
+			}
+			chapters = append(chapters, &bookChapter{
+				ID:       id,
+				Title:    page.Title,
+				URL:      pageURL,
+				Base:     m.Prefix + "/" + page.File,
+				FilePath: filepath.Join(m.Root, filepath.FromSlash(page.File)),
+				Tree:     m.Tree,
+			})
+		}
+	}
+	return chapters
+}
+
+// bookAnchors maps the URL paths of the chapters, as page URL and as file
+// path, to their anchors
+func bookAnchors(chapters []*bookChapter) map[string]string {
+	anchors := map[string]string{}
+	for _, chapter := range chapters {
+		anchors[chapter.Base] = chapter.ID
+		anchors[path.Clean(chapter.URL)] = chapter.ID
+	}
+	return anchors
+}
+
+// bookTransform rewrites the links of a chapter: links to the other pages of
+// the book and to headings point to their anchors in the book, other
+// relative links and images are made absolute
+func bookTransform(doc ast.Node, chapter *bookChapter, anchors map[string]string) {
+	base, err := url.Parse(chapter.Base)
+	if err != nil {
+		return
+	}
+	rewrite := func(dest []byte, image bool) []byte {
+		u, err := url.Parse(string(dest))
+		if err != nil || u.Scheme != "" || u.Host != "" {
+			return dest
+		}
+		if u.Path == "" && u.RawQuery == "" {
+			if u.Fragment == "" {
+				return dest
+			}
+			return []byte("#" + chapter.ID + "--" + u.Fragment)
+		}
+
+		resolved := base.ResolveReference(u)
+		if !image {
+			if id, ok := anchors[path.Clean(resolved.Path)]; ok {
+				if u.Fragment != "" {
+					return []byte("#" + id + "--" + u.Fragment)
+				}
+				return []byte("#" + id)
+			}
+		}
+		return []byte(resolved.String())
+	}
+
+	rewriteDestinations(doc, rewrite)
+}
+
+// rewriteDestinations replaces the destinations of the links and images of
+// a document
+func rewriteDestinations(doc ast.Node, rewrite func(dest []byte, image bool) []byte) {
+	ast.WalkFunc(doc, func(node ast.Node, entering bool) ast.WalkStatus {
+		if !entering {
+			return ast.GoToNext
+		}
+		switch n := node.(type) {
+		case *ast.Link:
+			// Footnote references keep their note name
+			if n.NoteID == 0 {
+				n.Destination = rewrite(n.Destination, false)
+			}
+		case *ast.Image:
+			n.Destination = rewrite(n.Destination, true)
+		}
+		return ast.GoToNext
+	})
+}
+
+// renderChapter renders a page as a chapter of the book
+func renderChapter(chapter *bookChapter, anchors map[string]string) string {
+	content, err := os.ReadFile(chapter.FilePath)
+	if err != nil {
+		return ""
+	}
+	meta, body := splitFrontMatter(content)
+	body, _ = expandIncludes(body, chapter.FilePath, chapter.Tree.root)
+	opts := pageMarkdownOptions(meta)
+	doc := parseMarkdown(body, opts, chapter.Tree)
+	bookTransform(doc, chapter, anchors)
+
+	// Heading and footnote IDs are unique across chapters
+	renderer := newRenderer(opts)
+	renderer.Opts.HeadingIDPrefix = chapter.ID + "--"
+	renderer.Opts.FootnoteAnchorPrefix = chapter.ID + "--"
+	return string(markdown.Render(doc, renderer))
+}
+
+// serveBook renders every page of the documentation on a single page, in
+// reading order, for printing
+func serveBook(w http.ResponseWriter, r *http.Request) {
+	chapters := bookChapters(servedMounts())
+	anchors := bookAnchors(chapters)
+
+	var result strings.Builder
+	result.WriteString("<nav class=\"godown-book-toc\">\n<h2>Contents</h2>\n<ol>\n")
+	for _, chapter := range chapters {
+		fmt.Fprintf(&result, "<li><a href=\"#%s\">%s</a></li>\n", chapter.ID, template.HTMLEscapeString(chapter.Title))
+	}
+	result.WriteString("</ol>\n</nav>\n")
+	for _, chapter := range chapters {
+		fmt.Fprintf(&result, "<section class=\"godown-chapter\" id=\"%s\">\n%s</section>\n", chapter.ID, renderChapter(chapter, anchors))
+	}
+
+	title := "Documentation"
+	if len(mounts) == 0 && siteTree != nil {
+		siteTree.mu.RLock()
+		if siteTree.nav != nil && siteTree.nav.File != "" {
+			title = siteTree.nav.Title
+		}
+		siteTree.mu.RUnlock()
+	}
+
+	renderPage(w, PageData{
+		Title:     title,
+		Content:   template.HTML("<div class=\"godown-book\">\n" + result.String() + "</div>\n"),
+		StylePath: "/__godown_style.css",
+	})
+}
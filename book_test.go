@@ -0,0 +1,61 @@
+package main
+
+import (
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+// Test the single-page book
+func TestServeBook(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTree(t, tmpDir, map[string]string{
+		"README.md":        "# Home\n\n## Setup\n\nRead [the guide](guide/install.md#usage), [[install]] or [setup](#setup).\n\n![Logo](img/logo.png)",
+		"guide/install.md": "---\ntitle: Installation\n---\n# Install\n\n## Setup\n\n## Usage\n\nBack [home](../README.md), [other](../notes.txt) and [site](https://example.com).\n\nNote[^1]\n\n[^1]: A note.",
+		"guide/usage.md":   "# Usage\n\nText[^1]\n\n[^1]: Another note.",
+		"notes.txt":        "notes",
+	})
+
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+
+	oldTree := siteTree
+	siteTree = newDocTree(".", "", "README.md")
+	defer func() { siteTree = oldTree }()
+
+	req := httptest.NewRequest("GET", "/__godown/book", nil)
+	w := httptest.NewRecorder()
+	serveBook(w, req)
+	body := w.Body.String()
+
+	for _, expected := range []string{
+		"<title>Home</title>",
+		"<li><a href=\"#chapter-readme\">Home</a></li>\n<li><a href=\"#chapter-guide-install\">Installation</a></li>\n<li><a href=\"#chapter-guide-usage\">Usage</a></li>",
+		`<section class="godown-chapter" id="chapter-readme">`,
+		`<section class="godown-chapter" id="chapter-guide-install">`,
+		// Headings are unique across chapters
+		`<h2 id="chapter-readme--setup">Setup</h2>`,
+		`<h2 id="chapter-guide-install--setup">Setup</h2>`,
+		// Links between pages point to the chapters
+		`<a href="#chapter-guide-install--usage">the guide</a>`,
+		`<a class="godown-wikilink" href="#chapter-guide-install">install</a>`,
+		`<a href="#chapter-readme--setup">setup</a>`,
+		`<a href="#chapter-readme">home</a>`,
+		// Other links and images still work from the book URL
+		`<a href="/notes.txt">other</a>`,
+		`<a href="https://example.com">site</a>`,
+		`<img src="/img/logo.png" alt="Logo" />`,
+		// Footnotes are unique across chapters
+		`<a href="#fn:chapter-guide-install--1">1</a>`,
+		`<li id="fn:chapter-guide-usage--1">Another note.`,
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("book should contain %q, got:\n%s", expected, body)
+		}
+	}
+	if strings.Contains(body, "title: Installation") {
+		t.Errorf("book should not render the front matter")
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// runCheck implements the check command: it reports the include and snippet
+// directives of the Markdown pages which cannot be expanded
+func runCheck(args []string) error {
+	flags := flag.NewFlagSet("check", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: godown check [directory]\n\nReport the missing files, line ranges and regions of the include and snippet directives of the Markdown pages of the directory (default: current one).\n")
+	}
+	flags.Parse(args)
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return fmt.Errorf("too many arguments")
+	}
+
+	root := "."
+	if flags.NArg() == 1 {
+		root = flags.Arg(0)
+	}
+	if info, err := os.Stat(root); err != nil || !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", root)
+	}
+
+	if problems := checkTree(os.Stdout, root); problems > 0 {
+		return fmt.Errorf("%d directive(s) cannot be expanded", problems)
+	}
+	return nil
+}
+
+// checkTree writes the problems of the pages of a root, returning their
+// number. A problem of a file included by several pages is reported once.
+func checkTree(w io.Writer, root string) int {
+	seen := map[string]bool{}
+	for _, page := range scanPages(root, "", "", loadIgnorePatterns(root)) {
+		filePath := filepath.Join(root, filepath.FromSlash(page.File))
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			continue
+		}
+		_, body := splitFrontMatter(content)
+		for _, problem := range checkDirectives(body, filePath, root) {
+			if !seen[problem] {
+				seen[problem] = true
+				fmt.Fprintln(w, problem)
+			}
+		}
+	}
+	return len(seen)
+}
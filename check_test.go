@@ -0,0 +1,37 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// Test the report of the failed directives
+func TestCheckTree(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTree(t, tmpDir, map[string]string{
+		"README.md":         "# Home\n\n{{< include \"shared/intro.md\" >}}\n",
+		"guide.md":          "# Guide\n\n{{< include \"shared/intro.md\" >}}\n\n{{< snippet \"src/main.go\" region=\"setup\" >}}\n",
+		"shared/intro.md":   "Intro\n\n{{< include \"missing.md\" >}}\n",
+		"src/main.go":       "package main\n",
+		"ok.md":             "{{< snippet \"src/main.go\" lines=\"1\" >}}\n",
+		"example.md":        "```\n{{< include \"missing.md\" >}}\n```\n",
+		"drafts/ignored.md": "{{< include \"missing.md\" >}}\n",
+		".godownignore":     "drafts\n",
+	})
+
+	var out strings.Builder
+	problems := checkTree(&out, tmpDir)
+
+	expected := []string{
+		`shared/intro.md:3: cannot include "missing.md": file not found`,
+		`guide.md:5: cannot embed "src/main.go": region "setup" not found`,
+	}
+	if problems != len(expected) {
+		t.Errorf("checkTree() = %d problems, want %d:\n%s", problems, len(expected), out.String())
+	}
+	for _, s := range expected {
+		if !strings.Contains(out.String(), s) {
+			t.Errorf("checkTree() output = %q, should contain %q", out.String(), s)
+		}
+	}
+}
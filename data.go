@@ -0,0 +1,480 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// maxDataSize is the size above which data files are shown as text
+const maxDataSize = 10 << 20
+
+// dataOpenDepth is the depth up to which the tree of a data file is expanded
+const dataOpenDepth = 3
+
+// maxDataNodes bounds the number of values of a data file, against huge
+// trees and the alias bombs of YAML files
+const maxDataNodes = 100000
+
+// yamlErrorLine extracts the line of a YAML error message
+var yamlErrorLine = regexp.MustCompile(`line (\d+)`)
+
+// dataNode is a value of a JSON, YAML or TOML file
+type dataNode struct {
+	Key      string // Key in the parent object, or index in the parent array
+	Kind     string // object, array, string, number, bool, null or date
+	Value    string // Text of a scalar
+	Children []*dataNode
+}
+
+// dataError is a parse error of a data file, at a line and column starting
+// at 1 (0 when unknown)
+type dataError struct {
+	Line    int
+	Column  int
+	Message string
+}
+
+func (e *dataError) Error() string {
+	switch {
+	case e.Line > 0 && e.Column > 0:
+		return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Message)
+	case e.Line > 0:
+		return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+	}
+	return e.Message
+}
+
+// dataFormats maps the extensions of the data files to their format
+var dataFormats = map[string]string{
+	".json": "JSON",
+	".toml": "TOML",
+	".yaml": "YAML",
+	".yml":  "YAML",
+}
+
+// isDataFile checks if the file is a JSON, YAML or TOML file
+func isDataFile(filePath string) bool {
+	_, ok := dataFormats[strings.ToLower(filepath.Ext(filePath))]
+	return ok
+}
+
+// parseData parses the documents of a data file in the given format, a YAML
+// file holding several documents
+func parseData(content []byte, format string) ([]*dataNode, error) {
+	switch format {
+	case "JSON":
+		root, err := parseJSON(content)
+		if err != nil {
+			return nil, err
+		}
+		return []*dataNode{root}, nil
+	case "TOML":
+		root, err := parseTOML(content)
+		if err != nil {
+			return nil, err
+		}
+		return []*dataNode{root}, nil
+	}
+	return parseYAML(content)
+}
+
+// parseJSON parses a JSON document, keeping the order of the keys
+func parseJSON(content []byte) (*dataNode, error) {
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+	fail := func(err error) error {
+		// Offsets are after the faulty byte, the end of the input is after
+		// the last one
+		offset := decoder.InputOffset()
+		var syntaxError *json.SyntaxError
+		if errors.As(err, &syntaxError) {
+			offset = syntaxError.Offset
+		}
+		if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) || strings.HasSuffix(err.Error(), "unexpected end of JSON input") {
+			offset, err = int64(len(content))+1, errors.New("unexpected end of JSON input")
+		}
+		line, column := textPosition(content, offset)
+		return &dataError{Line: line, Column: column, Message: strings.TrimPrefix(err.Error(), "json: ")}
+	}
+
+	budget := maxDataNodes
+	var parse func(token json.Token) (*dataNode, error)
+	parse = func(token json.Token) (*dataNode, error) {
+		if budget--; budget < 0 {
+			return nil, fmt.Errorf("more than %d values", maxDataNodes)
+		}
+		switch value := token.(type) {
+		case json.Delim:
+			node := &dataNode{Kind: "object"}
+			if value == '[' {
+				node.Kind = "array"
+			}
+			for decoder.More() {
+				key := strconv.Itoa(len(node.Children))
+				if node.Kind == "object" {
+					token, err := decoder.Token()
+					if err != nil {
+						return nil, err
+					}
+					key = token.(string)
+				}
+				token, err := decoder.Token()
+				if err != nil {
+					return nil, err
+				}
+				child, err := parse(token)
+				if err != nil {
+					return nil, err
+				}
+				child.Key = key
+				node.Children = append(node.Children, child)
+			}
+			// Closing delimiter
+			if _, err := decoder.Token(); err != nil {
+				return nil, err
+			}
+			return node, nil
+		case string:
+			return &dataNode{Kind: "string", Value: value}, nil
+		case json.Number:
+			return &dataNode{Kind: "number", Value: value.String()}, nil
+		case bool:
+			return &dataNode{Kind: "bool", Value: strconv.FormatBool(value)}, nil
+		}
+		return &dataNode{Kind: "null", Value: "null"}, nil
+	}
+
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, fail(err)
+	}
+	root, err := parse(token)
+	if err != nil {
+		return nil, fail(err)
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		if err == nil {
+			err = errors.New("unexpected data after the top-level value")
+		}
+		return nil, fail(err)
+	}
+	return root, nil
+}
+
+// parseYAML parses the documents of a YAML file, keeping the order of the
+// keys
+func parseYAML(content []byte) ([]*dataNode, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	var roots []*dataNode
+	budget := maxDataNodes
+	for {
+		var document yaml.Node
+		if err := decoder.Decode(&document); err != nil {
+			if err == io.EOF {
+				break
+			}
+			message := strings.TrimPrefix(err.Error(), "yaml: ")
+			line := 0
+			if match := yamlErrorLine.FindStringSubmatch(message); match != nil {
+				line, _ = strconv.Atoi(match[1])
+				message = strings.TrimPrefix(message, match[0]+": ")
+			}
+			return nil, &dataError{Line: line, Message: message}
+		}
+		if len(document.Content) > 0 {
+			root, err := yamlNode(document.Content[0], &budget)
+			if err != nil {
+				return nil, err
+			}
+			roots = append(roots, root)
+		} else {
+			roots = append(roots, &dataNode{Kind: "null", Value: "null"})
+		}
+	}
+	if len(roots) == 0 {
+		roots = append(roots, &dataNode{Kind: "null", Value: "null"})
+	}
+	return roots, nil
+}
+
+// yamlNode converts a node of a YAML document, expanding the aliases while
+// the budget of values lasts
+func yamlNode(node *yaml.Node, budget *int) (*dataNode, error) {
+	if *budget--; *budget < 0 {
+		return nil, &dataError{
+			Line:    node.Line,
+			Column:  node.Column,
+			Message: fmt.Sprintf("more than %d values once the aliases are expanded", maxDataNodes),
+		}
+	}
+
+	switch node.Kind {
+	case yaml.AliasNode:
+		return yamlNode(node.Alias, budget)
+	case yaml.MappingNode:
+		result := &dataNode{Kind: "object"}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			child, err := yamlNode(node.Content[i+1], budget)
+			if err != nil {
+				return nil, err
+			}
+			child.Key = node.Content[i].Value
+			result.Children = append(result.Children, child)
+		}
+		return result, nil
+	case yaml.SequenceNode:
+		result := &dataNode{Kind: "array"}
+		for i, item := range node.Content {
+			child, err := yamlNode(item, budget)
+			if err != nil {
+				return nil, err
+			}
+			child.Key = strconv.Itoa(i)
+			result.Children = append(result.Children, child)
+		}
+		return result, nil
+	}
+
+	switch node.ShortTag() {
+	case "!!int", "!!float":
+		return &dataNode{Kind: "number", Value: node.Value}, nil
+	case "!!bool":
+		return &dataNode{Kind: "bool", Value: node.Value}, nil
+	case "!!null":
+		return &dataNode{Kind: "null", Value: "null"}, nil
+	case "!!timestamp":
+		return &dataNode{Kind: "date", Value: node.Value}, nil
+	}
+	return &dataNode{Kind: "string", Value: node.Value}, nil
+}
+
+// parseTOML parses a TOML document, keeping the order of the keys
+func parseTOML(content []byte) (*dataNode, error) {
+	var document map[string]any
+	meta, err := toml.Decode(string(content), &document)
+	if err != nil {
+		var parseError toml.ParseError
+		if errors.As(err, &parseError) {
+			line, column := textPosition(content, int64(parseError.Position.Start)+1)
+			return nil, &dataError{Line: line, Column: column, Message: parseError.Message}
+		}
+		return nil, &dataError{Message: err.Error()}
+	}
+
+	// Position of the keys in the file, array indexes left out
+	order := map[string]int{}
+	for i, key := range meta.Keys() {
+		if _, ok := order[key.String()]; !ok {
+			order[key.String()] = i
+		}
+	}
+	return tomlNode(document, nil, order), nil
+}
+
+// tomlNode converts a value of a TOML document at a key path
+func tomlNode(value any, path toml.Key, order map[string]int) *dataNode {
+	switch value := value.(type) {
+	case map[string]any:
+		node := &dataNode{Kind: "object"}
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		position := func(key string) int {
+			if i, ok := order[append(path[:len(path):len(path)], key).String()]; ok {
+				return i
+			}
+			return len(order)
+		}
+		sort.SliceStable(keys, func(i, j int) bool {
+			if pi, pj := position(keys[i]), position(keys[j]); pi != pj {
+				return pi < pj
+			}
+			return keys[i] < keys[j]
+		})
+		for _, key := range keys {
+			child := tomlNode(value[key], append(path[:len(path):len(path)], key), order)
+			child.Key = key
+			node.Children = append(node.Children, child)
+		}
+		return node
+	case []map[string]any:
+		items := make([]any, len(value))
+		for i, item := range value {
+			items[i] = item
+		}
+		return tomlNode(items, path, order)
+	case []any:
+		node := &dataNode{Kind: "array"}
+		for i, item := range value {
+			child := tomlNode(item, path, order)
+			child.Key = strconv.Itoa(i)
+			node.Children = append(node.Children, child)
+		}
+		return node
+	case string:
+		return &dataNode{Kind: "string", Value: value}
+	case bool:
+		return &dataNode{Kind: "bool", Value: strconv.FormatBool(value)}
+	case int64:
+		return &dataNode{Kind: "number", Value: strconv.FormatInt(value, 10)}
+	case float64:
+		return &dataNode{Kind: "number", Value: strconv.FormatFloat(value, 'g', -1, 64)}
+	case time.Time:
+		return &dataNode{Kind: "date", Value: value.Format(time.RFC3339Nano)}
+	}
+	return &dataNode{Kind: "string", Value: fmt.Sprint(value)}
+}
+
+// textPosition returns the line and the column, starting at 1, of the byte
+// before an offset of a text (the end of the text being after its last byte)
+func textPosition(content []byte, offset int64) (int, int) {
+	past := 0
+	if offset > int64(len(content)) {
+		offset, past = int64(len(content)), 1
+	}
+	before := content[:max(offset-1, 0)]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := utf8.RuneCount(before[bytes.LastIndexByte(before, '\n')+1:]) + 1
+	return line, column + past
+}
+
+// jsonPointer returns the JSON Pointer (RFC 6901) of a key of the value at a
+// pointer
+func jsonPointer(parent, key string) string {
+	return parent + "/" + strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
+
+// renderDataNode renders a value of a data file as a tree item, with the
+// button copying its JSON Pointer. The kind of the parent tells if the node
+// has a key or an index.
+func renderDataNode(result *strings.Builder, node *dataNode, pointer string, depth int, parent string) {
+	label := ""
+	switch parent {
+	case "object":
+		label = fmt.Sprintf("<span class=\"godown-data-key\">%s</span>: ", template.HTMLEscapeString(node.Key))
+	case "array":
+		label = fmt.Sprintf("<span class=\"godown-data-index\">%s</span>: ", node.Key)
+	}
+	copyButton := fmt.Sprintf("<button type=\"button\" class=\"godown-pointer\" data-pointer=\"%s\" title=\"Copy the JSON Pointer %s\">#</button>",
+		template.HTMLEscapeString(pointer), template.HTMLEscapeString(pointer))
+
+	switch node.Kind {
+	case "object", "array":
+		summary := fmt.Sprintf("{%d}", len(node.Children))
+		if node.Kind == "array" {
+			summary = fmt.Sprintf("[%d]", len(node.Children))
+		}
+		open := ""
+		if depth < dataOpenDepth {
+			open = " open"
+		}
+		fmt.Fprintf(result, "<li><details%s><summary>%s<span class=\"godown-data-size\">%s</span> %s</summary>\n<ul>\n", open, label, summary, copyButton)
+		for _, child := range node.Children {
+			renderDataNode(result, child, jsonPointer(pointer, child.Key), depth+1, node.Kind)
+		}
+		result.WriteString("</ul>\n</details></li>\n")
+	case "string":
+		var value bytes.Buffer
+		encoder := json.NewEncoder(&value)
+		encoder.SetEscapeHTML(false)
+		encoder.Encode(node.Value)
+		fmt.Fprintf(result, "<li>%s<span class=\"godown-data-string\">%s</span> %s</li>\n", label, template.HTMLEscapeString(strings.TrimSuffix(value.String(), "\n")), copyButton)
+	default:
+		fmt.Fprintf(result, "<li>%s<span class=\"godown-data-%s\">%s</span> %s</li>\n", label, node.Kind, template.HTMLEscapeString(node.Value), copyButton)
+	}
+}
+
+// renderData renders the documents of a data file as collapsible trees
+func renderData(roots []*dataNode, format string) string {
+	var result strings.Builder
+	result.WriteString("<div class=\"godown-data\">\n")
+	fmt.Fprintf(&result, "<p class=\"godown-data-info\">Valid %s", format)
+	if len(roots) > 1 {
+		fmt.Fprintf(&result, ", %d documents", len(roots))
+	}
+	result.WriteString("</p>\n")
+	for i, root := range roots {
+		if len(roots) > 1 {
+			fmt.Fprintf(&result, "<h2>Document %d</h2>\n", i+1)
+		}
+		result.WriteString("<ul class=\"godown-data-tree\">\n")
+		renderDataNode(&result, root, "", 0, "")
+		result.WriteString("</ul>\n")
+	}
+	result.WriteString("</div>\n")
+	return result.String()
+}
+
+// renderDataError renders a parse error followed by the source, with the
+// line of the error marked
+func renderDataError(content []byte, format string, err error) string {
+	var result strings.Builder
+	fmt.Fprintf(&result, "<div class=\"godown-data-error\">Invalid %s: %s</div>\n", format, template.HTMLEscapeString(err.Error()))
+
+	line := 0
+	var parseError *dataError
+	if errors.As(err, &parseError) {
+		line = parseError.Line
+	}
+	result.WriteString("<pre class=\"godown-text\">")
+	for i, text := range strings.SplitAfter(strings.ToValidUTF8(string(content), "\uFFFD"), "\n") {
+		if i+1 == line {
+			fmt.Fprintf(&result, "<mark id=\"error\">%s</mark>", template.HTMLEscapeString(text))
+		} else {
+			result.WriteString(template.HTMLEscapeString(text))
+		}
+	}
+	result.WriteString("</pre>\n")
+	return result.String()
+}
+
+// serveDataFile renders a JSON, YAML or TOML file as a tree, or its parse
+// error
+func serveDataFile(w http.ResponseWriter, r *http.Request, filePath string) {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	if info.Size() > maxDataSize {
+		serveTextFile(w, r, filePath)
+		return
+	}
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	format := dataFormats[strings.ToLower(filepath.Ext(filePath))]
+	var htmlContent string
+	if roots, err := parseData(content, format); err != nil {
+		htmlContent = renderDataError(content, format, err)
+	} else {
+		htmlContent = renderData(roots, format)
+	}
+
+	renderPage(w, PageData{
+		Title:     filepath.Base(filePath),
+		Content:   template.HTML(htmlContent),
+		StylePath: "/__godown_style.css",
+		Views:     template.HTML(viewLinks(r, filePath, viewData)),
+	})
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+// dataKeys lists the keys of the children of a node
+func dataKeys(node *dataNode) []string {
+	var keys []string
+	for _, child := range node.Children {
+		keys = append(keys, child.Key)
+	}
+	return keys
+}
+
+// Test the parsing of the data formats, keeping the order of the keys
+func TestParseData(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		content string
+		keys    []string
+		kinds   []string
+	}{
+		{
+			name:    "JSON",
+			format:  "JSON",
+			content: `{"zeta": "a", "alpha": 1.5, "list": [true, null], "nested": {}}`,
+			keys:    []string{"zeta", "alpha", "list", "nested"},
+			kinds:   []string{"string", "number", "array", "object"},
+		},
+		{
+			name:    "YAML",
+			format:  "YAML",
+			content: "zeta: a\nalpha: 2\nwhen: 2024-01-01\nbase: &base\n  x: 1\ncopy: *base\nnone: ~\n",
+			keys:    []string{"zeta", "alpha", "when", "base", "copy", "none"},
+			kinds:   []string{"string", "number", "date", "object", "object", "null"},
+		},
+		{
+			name:    "TOML",
+			format:  "TOML",
+			content: "zeta = \"a\"\nalpha = 3\nwhen = 2024-01-01T10:00:00Z\n\n[server]\nport = 8080\n\n[[products]]\nname = \"pen\"\n",
+			keys:    []string{"zeta", "alpha", "when", "server", "products"},
+			kinds:   []string{"string", "number", "date", "object", "array"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			roots, err := parseData([]byte(tt.content), tt.format)
+			if err != nil {
+				t.Fatalf("parseData() error = %v", err)
+			}
+			root := roots[0]
+			if keys := dataKeys(root); strings.Join(keys, ",") != strings.Join(tt.keys, ",") {
+				t.Errorf("parseData() keys = %v, want %v", keys, tt.keys)
+			}
+			for i, child := range root.Children {
+				if i < len(tt.kinds) && child.Kind != tt.kinds[i] {
+					t.Errorf("parseData() %s kind = %q, want %q", child.Key, child.Kind, tt.kinds[i])
+				}
+			}
+		})
+	}
+}
+
+// Test YAML files with several documents
+func TestParseYAMLDocuments(t *testing.T) {
+	roots, err := parseYAML([]byte("a: 1\n---\n- x\n- y\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(roots) != 2 || roots[1].Kind != "array" || len(roots[1].Children) != 2 {
+		t.Errorf("parseYAML() = %d documents, want an object and an array of 2 items", len(roots))
+	}
+}
+
+// Test the expansion limit of YAML aliases
+func TestParseYAMLAliasBomb(t *testing.T) {
+	content := "a: &a [x, x, x, x, x, x, x, x, x, x]\n"
+	for i, name := range []string{"b", "c", "d", "e", "f", "g", "h", "i"} {
+		previous := string(rune('a' + i))
+		content += fmt.Sprintf("%s: &%s [*%s, *%s, *%s, *%s, *%s, *%s, *%s, *%s, *%s, *%s]\n",
+			name, name, previous, previous, previous, previous, previous, previous, previous, previous, previous, previous)
+	}
+	_, err := parseYAML([]byte(content))
+	parseError, ok := err.(*dataError)
+	if !ok || !strings.Contains(parseError.Message, "aliases") {
+		t.Errorf("parseYAML() error = %v, want the expansion limit", err)
+	}
+}
+
+// Test the limit of values of JSON files
+func TestParseJSONNodes(t *testing.T) {
+	content := "[" + strings.Repeat("0,", maxDataNodes) + "0]"
+	_, err := parseJSON([]byte(content))
+	parseError, ok := err.(*dataError)
+	if !ok || !strings.Contains(parseError.Message, "values") || parseError.Line != 1 {
+		t.Errorf("parseJSON() error = %v, want the value limit", err)
+	}
+
+	content = "[" + strings.Repeat("0,", maxDataNodes-2) + "0]"
+	if _, err := parseJSON([]byte(content)); err != nil {
+		t.Errorf("parseJSON() error = %v, want %d values accepted", err, maxDataNodes)
+	}
+}
+
+// Test the position of parse errors
+func TestParseDataErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		content string
+		line    int
+		column  int
+	}{
+		{"JSON missing comma", "JSON", "{\n  \"a\": 1\n  \"b\": 2\n}", 3, 3},
+		{"JSON truncated", "JSON", "{\n  \"a\": [1, 2", 2, 13},
+		{"JSON trailing data", "JSON", "{}\n{}", 2, 1},
+		{"YAML tab indentation", "YAML", "a: 1\n\tb: 2\n", 2, 0},
+		{"TOML duplicate key", "TOML", "a = 1\na = 2\n", 2, 1},
+		{"TOML unterminated string", "TOML", "a = 1\nb = \"x\n", 2, 7},
+		{"TOML missing value", "TOML", "a = 1\nb = \n", 2, 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseData([]byte(tt.content), tt.format)
+			parseError, ok := err.(*dataError)
+			if !ok {
+				t.Fatalf("parseData() error = %v, want a dataError", err)
+			}
+			if parseError.Line != tt.line || parseError.Column != tt.column {
+				t.Errorf("parseData() error at %d:%d (%v), want %d:%d", parseError.Line, parseError.Column, err, tt.line, tt.column)
+			}
+		})
+	}
+}
+
+// Test JSON Pointer escaping
+func TestJSONPointer(t *testing.T) {
+	if result := jsonPointer("/a", "b/c~d"); result != "/a/b~1c~0d" {
+		t.Errorf("jsonPointer() = %q, want %q", result, "/a/b~1c~0d")
+	}
+}
+
+// Test the tree and error views of data files
+func TestServeDataFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+
+	writeTree(t, tmpDir, map[string]string{
+		"config.json": `{"server": {"host": "<local>", "ports": [80, 443]}, "a/b": true}`,
+		"broken.yaml": "a: 1\n\tb: 2\n",
+	})
+
+	tests := []struct {
+		url        string
+		expected   []string
+		unexpected []string
+	}{
+		{
+			url: "/config.json",
+			expected: []string{
+				"Valid JSON",
+				`<span class="godown-data-key">server</span>: <span class="godown-data-size">{2}</span>`,
+				`<span class="godown-data-string">&#34;&lt;local&gt;&#34;</span>`,
+				`<span class="godown-data-index">1</span>: <span class="godown-data-number">443</span>`,
+				`data-pointer="/server/ports/1"`,
+				`data-pointer="/a~1b"`,
+				`class="active" aria-current="page">Tree</a>`,
+			},
+		},
+		{
+			url:      "/broken.yaml",
+			expected: []string{`<div class="godown-data-error">Invalid YAML: line 2: found a tab character`, `<mark id="error">`},
+		},
+		{
+			url:        "/config.json?view=text",
+			expected:   []string{`<pre class="godown-text">`},
+			unexpected: []string{"godown-data-tree"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			serveMarkdown(w, httptest.NewRequest("GET", tt.url, nil))
+			body := w.Body.String()
+			for _, s := range tt.expected {
+				if !strings.Contains(body, s) {
+					t.Errorf("body should contain %q, got %q", s, body)
+				}
+			}
+			for _, s := range tt.unexpected {
+				if strings.Contains(body, s) {
+					t.Errorf("body should not contain %q", s)
+				}
+			}
+		})
+	}
+}
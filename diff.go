@@ -0,0 +1,225 @@
+package main
+
+import (
+	"html/template"
+	"strings"
+)
+
+// Kinds of diff operations
+const (
+	diffEqual  = '='
+	diffDelete = '-'
+	diffInsert = '+'
+)
+
+// maxDiffLines bounds the number of differing lines compared, above which
+// the old lines are all replaced by the new ones
+const maxDiffLines = 10000
+
+// diffOp is an element of an edit script
+type diffOp struct {
+	Kind byte
+	Text string
+}
+
+// diffStrings computes the shortest edit script turning a into b using the
+// linear space variant of the Myers algorithm
+func diffStrings(a, b []string) []diffOp {
+	var ops []diffOp
+	prefix, suffix := commonEnds(a, b)
+	ops = appendOps(ops, diffEqual, a[:prefix])
+
+	middleA, middleB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	if len(middleA)+len(middleB) > maxDiffLines {
+		ops = appendOps(ops, diffDelete, middleA)
+		ops = appendOps(ops, diffInsert, middleB)
+	} else {
+		ops = diffRange(ops, middleA, middleB)
+	}
+
+	return appendOps(ops, diffEqual, a[len(a)-suffix:])
+}
+
+// commonEnds returns the length of the common prefix and suffix of a and b
+func commonEnds(a, b []string) (prefix, suffix int) {
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	return prefix, suffix
+}
+
+// appendOps appends an operation of the given kind for each text
+func appendOps(ops []diffOp, kind byte, texts []string) []diffOp {
+	for _, text := range texts {
+		ops = append(ops, diffOp{Kind: kind, Text: text})
+	}
+	return ops
+}
+
+// diffRange appends the edit script of a and b, split around the middle
+// snake of their shortest path
+func diffRange(ops []diffOp, a, b []string) []diffOp {
+	prefix, suffix := commonEnds(a, b)
+	ops = appendOps(ops, diffEqual, a[:prefix])
+	tail := a[len(a)-suffix:]
+	a, b = a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+
+	switch {
+	case len(a) == 0:
+		ops = appendOps(ops, diffInsert, b)
+	case len(b) == 0:
+		ops = appendOps(ops, diffDelete, a)
+	default:
+		// Without common ends the path has at least two edits, so that both
+		// halves are shorter
+		x, y, u, v := middleSnake(a, b)
+		ops = diffRange(ops, a[:x], b[:y])
+		ops = appendOps(ops, diffEqual, a[x:u])
+		ops = diffRange(ops, a[u:], b[v:])
+	}
+	return appendOps(ops, diffEqual, tail)
+}
+
+// middleSnake returns the start (x, y) and end (u, v) of the snake in the
+// middle of a shortest edit path, searching from both ends at once
+func middleSnake(a, b []string) (x, y, u, v int) {
+	n, m := len(a), len(b)
+	max := (n + m + 1) / 2
+	delta := n - m
+	odd := delta%2 != 0
+
+	// forward[offset+k] is the furthest x reached on diagonal k from the
+	// start, backward[offset+k] the furthest distance from the end on the
+	// diagonal k of the reversed sequences
+	offset := max + 1
+	forward := make([]int, 2*max+3)
+	backward := make([]int, 2*max+3)
+
+	for d := 0; d <= max; d++ {
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && forward[offset+k-1] < forward[offset+k+1]) {
+				x = forward[offset+k+1]
+			} else {
+				x = forward[offset+k-1] + 1
+			}
+			y := x - k
+			startX, startY := x, y
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			forward[offset+k] = x
+			if reverse := delta - k; odd && reverse >= -(d-1) && reverse <= d-1 && x+backward[offset+reverse] >= n {
+				return startX, startY, x, y
+			}
+		}
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && backward[offset+k-1] < backward[offset+k+1]) {
+				x = backward[offset+k+1]
+			} else {
+				x = backward[offset+k-1] + 1
+			}
+			y := x - k
+			startX, startY := x, y
+			for x < n && y < m && a[n-1-x] == b[m-1-y] {
+				x++
+				y++
+			}
+			backward[offset+k] = x
+			if diagonal := delta - k; !odd && diagonal >= -d && diagonal <= d && x+forward[offset+diagonal] >= n {
+				return n - x, m - y, n - startX, m - startY
+			}
+		}
+	}
+	// Not reached: the paths meet after max rounds
+	return 0, 0, n, m
+}
+
+// splitLines splits text into lines, without line terminators
+func splitLines(text string) []string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// splitMarkdownBlocks splits a Markdown document into blocks separated by
+// blank lines, keeping fenced code blocks whole
+func splitMarkdownBlocks(md string) []string {
+	var blocks []string
+	var current []string
+	fence := ""
+
+	flush := func() {
+		if len(current) > 0 {
+			blocks = append(blocks, strings.Join(current, "\n"))
+			current = nil
+		}
+	}
+
+	for _, line := range splitLines(md) {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case fence != "":
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+		case strings.HasPrefix(trimmed, "```"):
+			fence = "```"
+		case strings.HasPrefix(trimmed, "~~~"):
+			fence = "~~~"
+		case trimmed == "":
+			flush()
+			continue
+		}
+		current = append(current, line)
+	}
+	flush()
+	return blocks
+}
+
+// renderLineDiff renders a line diff of two texts
+func renderLineDiff(oldText, newText string) string {
+	var result strings.Builder
+	result.WriteString("<pre class=\"godown-diff\">")
+	for _, op := range diffStrings(splitLines(oldText), splitLines(newText)) {
+		class := "same"
+		switch op.Kind {
+		case diffDelete:
+			class = "del"
+		case diffInsert:
+			class = "ins"
+		}
+		result.WriteString("<span class=\"" + class + "\">")
+		result.WriteString(string(op.Kind) + " " + template.HTMLEscapeString(op.Text))
+		result.WriteString("</span>\n")
+	}
+	result.WriteString("</pre>")
+	return result.String()
+}
+
+// renderMarkdownDiff renders two versions of a Markdown document block by
+// block, highlighting removed and added blocks
+func renderMarkdownDiff(oldMd, newMd string) string {
+	var result strings.Builder
+	for _, op := range diffStrings(splitMarkdownBlocks(oldMd), splitMarkdownBlocks(newMd)) {
+		class := "godown-diff-same"
+		switch op.Kind {
+		case diffDelete:
+			class = "godown-diff-del"
+		case diffInsert:
+			class = "godown-diff-ins"
+		}
+		result.WriteString("<div class=\"" + class + "\">\n")
+		result.Write(mdToHTML([]byte(op.Text)))
+		result.WriteString("</div>\n")
+	}
+	return result.String()
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"math/rand"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// applyDiff rebuilds both sides of an edit script
+func applyDiff(ops []diffOp) (oldLines, newLines []string) {
+	for _, op := range ops {
+		if op.Kind != diffInsert {
+			oldLines = append(oldLines, op.Text)
+		}
+		if op.Kind != diffDelete {
+			newLines = append(newLines, op.Text)
+		}
+	}
+	return oldLines, newLines
+}
+
+// Test the Myers diff algorithm
+func TestDiffStrings(t *testing.T) {
+	tests := []struct {
+		name    string
+		a, b    string
+		changes int
+	}{
+		{"Identical", "a b c", "a b c", 0},
+		{"Empty to text", "", "a b", 2},
+		{"Text to empty", "a b", "", 2},
+		{"Insertion", "a c", "a b c", 1},
+		{"Deletion", "a b c", "a c", 1},
+		{"Replacement", "a b c", "a x c", 2},
+		{"Classic example", "a b c a b b a", "c b a b a c", 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := strings.Fields(tt.a), strings.Fields(tt.b)
+			ops := diffStrings(a, b)
+
+			oldLines, newLines := applyDiff(ops)
+			if strings.Join(oldLines, " ") != tt.a || strings.Join(newLines, " ") != tt.b {
+				t.Errorf("diffStrings() script does not rebuild inputs: %v", ops)
+			}
+
+			changes := 0
+			for _, op := range ops {
+				if op.Kind != diffEqual {
+					changes++
+				}
+			}
+			if changes != tt.changes {
+				t.Errorf("diffStrings() changes = %d, want %d (%v)", changes, tt.changes, ops)
+			}
+		})
+	}
+}
+
+// Test that edit scripts are the shortest ones, against the longest common
+// subsequence of random inputs
+func TestDiffStringsShortest(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	word := func() []string {
+		words := make([]string, random.Intn(30))
+		for i := range words {
+			words[i] = string(rune('a' + random.Intn(3)))
+		}
+		return words
+	}
+
+	for i := 0; i < 500; i++ {
+		a, b := word(), word()
+		lcs := make([][]int, len(a)+1)
+		for x := range lcs {
+			lcs[x] = make([]int, len(b)+1)
+		}
+		for x := len(a) - 1; x >= 0; x-- {
+			for y := len(b) - 1; y >= 0; y-- {
+				if a[x] == b[y] {
+					lcs[x][y] = lcs[x+1][y+1] + 1
+				} else {
+					lcs[x][y] = max(lcs[x+1][y], lcs[x][y+1])
+				}
+			}
+		}
+
+		ops := diffStrings(a, b)
+		oldLines, newLines := applyDiff(ops)
+		if strings.Join(oldLines, "") != strings.Join(a, "") || strings.Join(newLines, "") != strings.Join(b, "") {
+			t.Fatalf("diffStrings(%q, %q) does not rebuild inputs: %v", a, b, ops)
+		}
+		changes := 0
+		for _, op := range ops {
+			if op.Kind != diffEqual {
+				changes++
+			}
+		}
+		if changes != len(a)+len(b)-2*lcs[0][0] {
+			t.Fatalf("diffStrings(%q, %q) changes = %d, want %d", a, b, changes, len(a)+len(b)-2*lcs[0][0])
+		}
+	}
+}
+
+// Test the replacement of inputs too different to be compared
+func TestDiffStringsLimit(t *testing.T) {
+	a := make([]string, maxDiffLines)
+	b := make([]string, maxDiffLines)
+	for i := range a {
+		a[i] = "old " + strconv.Itoa(i)
+		b[i] = "new " + strconv.Itoa(i)
+	}
+	a = append([]string{"same"}, a...)
+	b = append([]string{"same"}, b...)
+
+	ops := diffStrings(a, b)
+	if len(ops) != 2*maxDiffLines+1 || ops[0].Kind != diffEqual || ops[1].Kind != diffDelete || ops[len(ops)-1].Kind != diffInsert {
+		t.Errorf("diffStrings() should keep the common line and replace the others")
+	}
+}
+
+// Test Markdown block splitting
+func TestSplitMarkdownBlocks(t *testing.T) {
+	md := "# Title\n\nParagraph\nline 2\n\n\n```go\ncode\n\nmore code\n```\n\n- item"
+	blocks := splitMarkdownBlocks(md)
+
+	want := []string{"# Title", "Paragraph\nline 2", "```go\ncode\n\nmore code\n```", "- item"}
+	if len(blocks) != len(want) {
+		t.Fatalf("splitMarkdownBlocks() = %q, want %q", blocks, want)
+	}
+	for i := range want {
+		if blocks[i] != want[i] {
+			t.Errorf("block %d = %q, want %q", i, blocks[i], want[i])
+		}
+	}
+}
+
+// Test diff rendering
+func TestRenderDiffs(t *testing.T) {
+	html := renderMarkdownDiff("# Title\n\nOld text\n", "# Title\n\nNew **text**\n")
+	for _, expected := range []string{
+		"<div class=\"godown-diff-same\">\n<h1 id=\"title\">Title</h1>",
+		"<div class=\"godown-diff-del\">\n<p>Old text</p>",
+		"<div class=\"godown-diff-ins\">\n<p>New <strong>text</strong></p>",
+	} {
+		if !strings.Contains(html, expected) {
+			t.Errorf("renderMarkdownDiff() should contain %q, got:\n%s", expected, html)
+		}
+	}
+
+	html = renderLineDiff("a\n<b>\n", "a\nc\n")
+	for _, expected := range []string{
+		`<span class="same">= a</span>`,
+		`<span class="del">- &lt;b&gt;</span>`,
+		`<span class="ins">+ c</span>`,
+	} {
+		if !strings.Contains(html, expected) {
+			t.Errorf("renderLineDiff() should contain %q, got:\n%s", expected, html)
+		}
+	}
+}
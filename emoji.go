@@ -0,0 +1,360 @@
+package main
+
+// emojiShortcodes maps the common GitHub :shortcode: emoji to their characters
+var emojiShortcodes = map[string]string{
+	"+1":                          "👍",
+	"-1":                          "👎",
+	"100":                         "💯",
+	"1st_place_medal":             "🥇",
+	"abacus":                      "🧮",
+	"airplane":                    "✈️",
+	"alarm_clock":                 "⏰",
+	"alien":                       "👽",
+	"angry":                       "😠",
+	"ant":                         "🐜",
+	"apple":                       "🍎",
+	"arrow_down":                  "⬇️",
+	"arrow_left":                  "⬅️",
+	"arrow_right":                 "➡️",
+	"arrow_up":                    "⬆️",
+	"arrows_counterclockwise":     "🔄",
+	"art":                         "🎨",
+	"astonished":                  "😲",
+	"balloon":                     "🎈",
+	"ballot_box_with_check":       "☑️",
+	"bangbang":                    "‼️",
+	"bar_chart":                   "📊",
+	"battery":                     "🔋",
+	"bear":                        "🐻",
+	"bee":                         "🐝",
+	"beer":                        "🍺",
+	"beers":                       "🍻",
+	"beetle":                      "🪲",
+	"bell":                        "🔔",
+	"bike":                        "🚲",
+	"bird":                        "🐦",
+	"black_circle":                "⚫",
+	"blue_heart":                  "💙",
+	"blush":                       "😊",
+	"book":                        "📖",
+	"bookmark":                    "🔖",
+	"books":                       "📚",
+	"boom":                        "💥",
+	"bow":                         "🙇",
+	"brain":                       "🧠",
+	"broken_heart":                "💔",
+	"bug":                         "🐛",
+	"bulb":                        "💡",
+	"cactus":                      "🌵",
+	"cake":                        "🍰",
+	"calendar":                    "📆",
+	"camera":                      "📷",
+	"car":                         "🚗",
+	"card_file_box":               "🗃️",
+	"card_index_dividers":         "🗂️",
+	"cat":                         "🐱",
+	"cd":                          "💿",
+	"chart_with_downwards_trend":  "📉",
+	"chart_with_upwards_trend":    "📈",
+	"checkered_flag":              "🏁",
+	"cherries":                    "🍒",
+	"clap":                        "👏",
+	"clipboard":                   "📋",
+	"closed_lock_with_key":        "🔐",
+	"cloud":                       "☁️",
+	"clown_face":                  "🤡",
+	"coffee":                      "☕",
+	"cold_sweat":                  "😰",
+	"collision":                   "💥",
+	"computer":                    "💻",
+	"confetti_ball":               "🎊",
+	"confused":                    "😕",
+	"construction":                "🚧",
+	"construction_worker":         "👷",
+	"cookie":                      "🍪",
+	"cool":                        "🆒",
+	"copyright":                   "©️",
+	"cow":                         "🐮",
+	"crab":                        "🦀",
+	"credit_card":                 "💳",
+	"crescent_moon":               "🌙",
+	"crossed_fingers":             "🤞",
+	"crown":                       "👑",
+	"cry":                         "😢",
+	"dancer":                      "💃",
+	"dart":                        "🎯",
+	"dash":                        "💨",
+	"date":                        "📅",
+	"desktop_computer":            "🖥️",
+	"dizzy":                       "💫",
+	"dizzy_face":                  "😵",
+	"dna":                         "🧬",
+	"dog":                         "🐶",
+	"dollar":                      "💵",
+	"dolphin":                     "🐬",
+	"dragon":                      "🐉",
+	"droplet":                     "💧",
+	"dvd":                         "📀",
+	"earth_africa":                "🌍",
+	"earth_americas":              "🌎",
+	"electric_plug":               "🔌",
+	"email":                       "📧",
+	"envelope":                    "✉️",
+	"evergreen_tree":              "🌲",
+	"exclamation":                 "❗",
+	"exploding_head":              "🤯",
+	"expressionless":              "😑",
+	"eyeglasses":                  "👓",
+	"eyes":                        "👀",
+	"facepalm":                    "🤦",
+	"fearful":                     "😨",
+	"file_folder":                 "📁",
+	"fire":                        "🔥",
+	"fish":                        "🐟",
+	"flashlight":                  "🔦",
+	"floppy_disk":                 "💾",
+	"flushed":                     "😳",
+	"footprints":                  "👣",
+	"four_leaf_clover":            "🍀",
+	"fox_face":                    "🦊",
+	"free":                        "🆓",
+	"frog":                        "🐸",
+	"frowning_face":               "☹️",
+	"game_die":                    "🎲",
+	"gear":                        "⚙️",
+	"gem":                         "💎",
+	"ghost":                       "👻",
+	"gift":                        "🎁",
+	"globe_with_meridians":        "🌐",
+	"green_circle":                "🟢",
+	"green_heart":                 "💚",
+	"grey_exclamation":            "❕",
+	"grey_question":               "❔",
+	"grimacing":                   "😬",
+	"grin":                        "😁",
+	"grinning":                    "😀",
+	"hamburger":                   "🍔",
+	"hammer":                      "🔨",
+	"hammer_and_wrench":           "🛠️",
+	"hamster":                     "🐹",
+	"handshake":                   "🤝",
+	"hankey":                      "💩",
+	"headphones":                  "🎧",
+	"hear_no_evil":                "🙉",
+	"heart":                       "❤️",
+	"heart_eyes":                  "😍",
+	"heavy_check_mark":            "✔️",
+	"heavy_minus_sign":            "➖",
+	"heavy_multiplication_x":      "✖️",
+	"heavy_plus_sign":             "➕",
+	"honeybee":                    "🐝",
+	"hourglass":                   "⌛",
+	"house":                       "🏠",
+	"hugs":                        "🤗",
+	"inbox_tray":                  "📥",
+	"information_source":          "ℹ️",
+	"innocent":                    "😇",
+	"interrobang":                 "⁉️",
+	"iphone":                      "📱",
+	"joy":                         "😂",
+	"key":                         "🔑",
+	"keyboard":                    "⌨️",
+	"kissing_heart":               "😘",
+	"koala":                       "🐨",
+	"label":                       "🏷️",
+	"lady_beetle":                 "🐞",
+	"large_blue_circle":           "🔵",
+	"laughing":                    "😆",
+	"ledger":                      "📒",
+	"leftwards_arrow_with_hook":   "↩️",
+	"lemon":                       "🍋",
+	"link":                        "🔗",
+	"lion":                        "🦁",
+	"lipstick":                    "💄",
+	"lock":                        "🔒",
+	"lock_with_ink_pen":           "🔏",
+	"loudspeaker":                 "📢",
+	"mag":                         "🔍",
+	"mag_right":                   "🔎",
+	"mage":                        "🧙",
+	"mailbox":                     "📫",
+	"man_technologist":            "👨‍💻",
+	"mask":                        "😷",
+	"medal_sports":                "🏅",
+	"mega":                        "📣",
+	"memo":                        "📝",
+	"microphone":                  "🎤",
+	"microscope":                  "🔬",
+	"moneybag":                    "💰",
+	"monkey_face":                 "🐵",
+	"mortar_board":                "🎓",
+	"mouse":                       "🐭",
+	"mouse_three_button":          "🖱️",
+	"movie_camera":                "🎥",
+	"muscle":                      "💪",
+	"musical_note":                "🎵",
+	"negative_squared_cross_mark": "❎",
+	"nerd_face":                   "🤓",
+	"neutral_face":                "😐",
+	"new":                         "🆕",
+	"newspaper":                   "📰",
+	"ninja":                       "🥷",
+	"no_bell":                     "🔕",
+	"no_entry":                    "⛔",
+	"no_entry_sign":               "🚫",
+	"no_good":                     "🙅",
+	"no_mouth":                    "😶",
+	"notebook":                    "📓",
+	"notes":                       "🎶",
+	"nut_and_bolt":                "🔩",
+	"ocean":                       "🌊",
+	"octopus":                     "🐙",
+	"office":                      "🏢",
+	"ok":                          "🆗",
+	"ok_hand":                     "👌",
+	"ok_woman":                    "🙆",
+	"open_file_folder":            "📂",
+	"open_mouth":                  "😮",
+	"orange_heart":                "🧡",
+	"outbox_tray":                 "📤",
+	"owl":                         "🦉",
+	"package":                     "📦",
+	"page_facing_up":              "📄",
+	"panda_face":                  "🐼",
+	"paperclip":                   "📎",
+	"party_popper":                "🎉",
+	"partying_face":               "🥳",
+	"pencil":                      "📝",
+	"pencil2":                     "✏️",
+	"penguin":                     "🐧",
+	"pensive":                     "😔",
+	"phone":                       "☎️",
+	"pig":                         "🐷",
+	"pill":                        "💊",
+	"pizza":                       "🍕",
+	"pleading_face":               "🥺",
+	"point_down":                  "👇",
+	"point_left":                  "👈",
+	"point_right":                 "👉",
+	"point_up":                    "☝️",
+	"poop":                        "💩",
+	"pray":                        "🙏",
+	"printer":                     "🖨️",
+	"purple_heart":                "💜",
+	"pushpin":                     "📌",
+	"question":                    "❓",
+	"rabbit":                      "🐰",
+	"rage":                        "😡",
+	"rainbow":                     "🌈",
+	"raised_hand":                 "✋",
+	"raised_hands":                "🙌",
+	"raising_hand":                "🙋",
+	"recycle":                     "♻️",
+	"red_circle":                  "🔴",
+	"registered":                  "®️",
+	"relieved":                    "😌",
+	"ring":                        "💍",
+	"robot":                       "🤖",
+	"rocket":                      "🚀",
+	"rofl":                        "🤣",
+	"roll_eyes":                   "🙄",
+	"rose":                        "🌹",
+	"rotating_light":              "🚨",
+	"round_pushpin":               "📍",
+	"running":                     "🏃",
+	"satellite":                   "📡",
+	"scissors":                    "✂️",
+	"scream":                      "😱",
+	"scroll":                      "📜",
+	"see_no_evil":                 "🙈",
+	"seedling":                    "🌱",
+	"shield":                      "🛡️",
+	"ship":                        "🚢",
+	"shrug":                       "🤷",
+	"shushing_face":               "🤫",
+	"skull":                       "💀",
+	"sleeping":                    "😴",
+	"sleepy":                      "😪",
+	"slightly_smiling_face":       "🙂",
+	"small_blue_diamond":          "🔹",
+	"small_orange_diamond":        "🔸",
+	"small_red_triangle":          "🔺",
+	"smile":                       "😄",
+	"smiley":                      "😃",
+	"smirk":                       "😏",
+	"snail":                       "🐌",
+	"snake":                       "🐍",
+	"snowflake":                   "❄️",
+	"sob":                         "😭",
+	"soccer":                      "⚽",
+	"sos":                         "🆘",
+	"sparkles":                    "✨",
+	"sparkling_heart":             "💖",
+	"speak_no_evil":               "🙊",
+	"speech_balloon":              "💬",
+	"star":                        "⭐",
+	"star2":                       "🌟",
+	"star_struck":                 "🤩",
+	"stopwatch":                   "⏱️",
+	"stuck_out_tongue":            "😛",
+	"sunflower":                   "🌻",
+	"sunglasses":                  "😎",
+	"sunny":                       "☀️",
+	"superhero":                   "🦸",
+	"sweat":                       "😓",
+	"sweat_drops":                 "💦",
+	"sweat_smile":                 "😅",
+	"syringe":                     "💉",
+	"tada":                        "🎉",
+	"tea":                         "🍵",
+	"technologist":                "🧑‍💻",
+	"telescope":                   "🔭",
+	"test_tube":                   "🧪",
+	"thinking":                    "🤔",
+	"thought_balloon":             "💭",
+	"thumbs_up":                   "👍",
+	"thumbsdown":                  "👎",
+	"thumbsup":                    "👍",
+	"tiger":                       "🐯",
+	"tm":                          "™️",
+	"toolbox":                     "🧰",
+	"tophat":                      "🎩",
+	"triangular_flag_on_post":     "🚩",
+	"triumph":                     "😤",
+	"trophy":                      "🏆",
+	"turtle":                      "🐢",
+	"tv":                          "📺",
+	"umbrella":                    "☔",
+	"unamused":                    "😒",
+	"unicorn":                     "🦄",
+	"unlock":                      "🔓",
+	"up":                          "🆙",
+	"upside_down_face":            "🙃",
+	"v":                           "✌️",
+	"vertical_traffic_light":      "🚦",
+	"video_game":                  "🎮",
+	"walking":                     "🚶",
+	"warning":                     "⚠️",
+	"wastebasket":                 "🗑️",
+	"watch":                       "⌚",
+	"wave":                        "👋",
+	"whale":                       "🐳",
+	"white_check_mark":            "✅",
+	"white_circle":                "⚪",
+	"white_flag":                  "🏳️",
+	"wine_glass":                  "🍷",
+	"wink":                        "😉",
+	"woman_technologist":          "👩‍💻",
+	"worried":                     "😟",
+	"wrench":                      "🔧",
+	"writing_hand":                "✍️",
+	"x":                           "❌",
+	"yawning_face":                "🥱",
+	"yellow_circle":               "🟡",
+	"yellow_heart":                "💛",
+	"yum":                         "😋",
+	"zap":                         "⚡",
+	"zipper_mouth_face":           "🤐",
+	"zombie":                      "🧟",
+	"zzz":                         "💤",
+}
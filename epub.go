@@ -0,0 +1,365 @@
+package main
+
+import (
+	"archive/zip"
+	"crypto/sha1"
+	"flag"
+	"fmt"
+	"html"
+	"io"
+	"log"
+	"mime"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gomarkdown/markdown"
+)
+
+// epubContainer points to the package document of the EPUB
+const epubContainer = `<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles>
+    <rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+  </rootfiles>
+</container>
+`
+
+// xmlEntities are the entities known to XML, the other HTML ones being
+// converted to character references
+var xmlEntities = map[string]bool{"amp": true, "lt": true, "gt": true, "quot": true, "apos": true}
+
+// namedEntity matches the named character references of HTML
+var namedEntity = regexp.MustCompile(`&[a-zA-Z][a-zA-Z0-9]*;`)
+
+// xhtmlEntities replaces the HTML named entities unknown to XML, like the
+// &ldquo; of smartypants, with numeric character references
+func xhtmlEntities(content string) string {
+	return namedEntity.ReplaceAllStringFunc(content, func(entity string) string {
+		if xmlEntities[entity[1:len(entity)-1]] {
+			return entity
+		}
+		decoded := html.UnescapeString(entity)
+		if decoded == entity {
+			return "&amp;" + entity[1:]
+		}
+		var result strings.Builder
+		for _, r := range decoded {
+			fmt.Fprintf(&result, "&#%d;", r)
+		}
+		return result.String()
+	})
+}
+
+// voidElements are the HTML elements without content
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true, "img": true,
+	"input": true, "link": true, "meta": true, "source": true, "track": true, "wbr": true,
+}
+
+// startTag matches the start tags of HTML and their attributes
+var startTag = regexp.MustCompile(`<([a-zA-Z][a-zA-Z0-9-]*)((?:\s+[^\s=>/]+(?:\s*=\s*(?:"[^"]*"|'[^']*'|[^\s"'>]+))?)*)\s*/?>`)
+
+// tagAttribute matches an attribute of a start tag
+var tagAttribute = regexp.MustCompile(`\s+([^\s=>/]+)(?:\s*=\s*("[^"]*"|'[^']*'|[^\s"'>]+))?`)
+
+// xhtmlTags makes the start tags of raw HTML well-formed XML: void elements
+// are closed and attributes have quoted values
+func xhtmlTags(content string) string {
+	return startTag.ReplaceAllStringFunc(content, func(tag string) string {
+		m := startTag.FindStringSubmatch(tag)
+		var result strings.Builder
+		result.WriteString("<" + m[1])
+		for _, attr := range tagAttribute.FindAllStringSubmatch(m[2], -1) {
+			value := attr[2]
+			switch {
+			case value == "":
+				value = `"` + attr[1] + `"`
+			case value[0] != '"' && value[0] != '\'':
+				value = `"` + value + `"`
+			}
+			result.WriteString(" " + attr[1] + "=" + value)
+		}
+		if voidElements[strings.ToLower(m[1])] || strings.HasSuffix(tag, "/>") {
+			result.WriteString(" />")
+		} else {
+			result.WriteString(">")
+		}
+		return result.String()
+	})
+}
+
+// epubImage is an image embedded in the EPUB
+type epubImage struct {
+	ID        string
+	Name      string // Path in the OEBPS directory
+	FilePath  string
+	MediaType string
+}
+
+// epubWriter builds an EPUB 3 book from the pages of a tree
+type epubWriter struct {
+	root   string
+	files  map[string]string // Chapter files by page URL and file path
+	images []*epubImage
+	byPath map[string]*epubImage // Embedded images by URL path
+}
+
+// chapterFile returns the name of the file of the n-th chapter
+func chapterFile(n int) string {
+	return fmt.Sprintf("chapter-%03d.xhtml", n+1)
+}
+
+// addImage embeds the image of a URL path, returning its path in the book
+// ("" if the file is not an image of the tree)
+func (e *epubWriter) addImage(urlPath string) string {
+	if image, ok := e.byPath[urlPath]; ok {
+		return image.Name
+	}
+	rel := strings.TrimPrefix(urlPath, "/")
+	filePath := filepath.Join(e.root, filepath.FromSlash(rel))
+	mediaType := mime.TypeByExtension(strings.ToLower(path.Ext(rel)))
+	if !isMediaFile(filePath) || !strings.HasPrefix(mediaType, "image/") {
+		return ""
+	}
+	if info, err := os.Stat(filePath); err != nil || info.IsDir() {
+		return ""
+	}
+
+	image := &epubImage{
+		ID:        fmt.Sprintf("image-%d", len(e.images)+1),
+		Name:      (&url.URL{Path: "images/" + rel}).EscapedPath(),
+		FilePath:  filePath,
+		MediaType: mediaType,
+	}
+	e.images = append(e.images, image)
+	e.byPath[urlPath] = image
+	return image.Name
+}
+
+// renderChapter renders a page as a XHTML content document: links to the
+// other pages point to their chapters and the images are embedded
+func (e *epubWriter) renderChapter(chapter *bookChapter, language string) (string, error) {
+	content, err := os.ReadFile(chapter.FilePath)
+	if err != nil {
+		return "", err
+	}
+	meta, body := splitFrontMatter(content)
+	body, _ = expandIncludes(body, chapter.FilePath, chapter.Tree.root)
+	opts := pageMarkdownOptions(meta).xhtml()
+	doc := parseMarkdown(body, opts, chapter.Tree)
+
+	base, err := url.Parse(chapter.Base)
+	if err != nil {
+		return "", err
+	}
+	rewriteDestinations(doc, func(dest []byte, image bool) []byte {
+		u, err := url.Parse(string(dest))
+		if err != nil || u.Scheme != "" || u.Host != "" || (u.Path == "" && u.RawQuery == "") {
+			return dest
+		}
+		target := path.Clean(base.ResolveReference(u).Path)
+		if image {
+			if name := e.addImage(target); name != "" {
+				return []byte(name)
+			}
+			return dest
+		}
+		if file, ok := e.files[target]; ok {
+			if u.Fragment != "" {
+				file += "#" + u.Fragment
+			}
+			return []byte(file)
+		}
+		return dest
+	})
+
+	var result strings.Builder
+	result.WriteString(xhtmlHeader(chapter.Title, language))
+	result.WriteString(xhtmlEntities(xhtmlTags(string(markdown.Render(doc, newRenderer(opts))))))
+	result.WriteString("</body>\n</html>\n")
+	return result.String(), nil
+}
+
+// xhtmlHeader starts a XHTML content document
+func xhtmlHeader(title, language string) string {
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops" lang="%[2]s" xml:lang="%[2]s">
+<head>
+<meta charset="UTF-8"/>
+<title>%[1]s</title>
+<link rel="stylesheet" type="text/css" href="style.css"/>
+</head>
+<body>
+`, html.EscapeString(title), html.EscapeString(language))
+}
+
+// writeEPUB writes the pages of the tree of a mount point as an EPUB 3 book,
+// in reading order
+func writeEPUB(w io.Writer, m *mount, title, language string, modified time.Time) error {
+	chapters := bookChapters([]*mount{m})
+	if len(chapters) == 0 {
+		return fmt.Errorf("no Markdown page found in %s", m.Root)
+	}
+
+	e := &epubWriter{root: m.Root, files: map[string]string{}, byPath: map[string]*epubImage{}}
+	for i, chapter := range chapters {
+		e.files[chapter.Base] = chapterFile(i)
+		e.files[path.Clean(chapter.URL)] = chapterFile(i)
+	}
+
+	archive := zip.NewWriter(w)
+	add := func(name, content string) error {
+		file, err := archive.Create(name)
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(file, content)
+		return err
+	}
+
+	// The uncompressed mimetype comes first
+	file, err := archive.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		return err
+	}
+	io.WriteString(file, "application/epub+zip")
+	if err := add("META-INF/container.xml", epubContainer); err != nil {
+		return err
+	}
+
+	for i, chapter := range chapters {
+		content, err := e.renderChapter(chapter, language)
+		if err != nil {
+			return fmt.Errorf("%s: %w", chapter.FilePath, err)
+		}
+		if err := add("OEBPS/"+chapterFile(i), content); err != nil {
+			return err
+		}
+	}
+
+	for _, image := range e.images {
+		data, err := os.ReadFile(image.FilePath)
+		if err != nil {
+			return err
+		}
+		name, _ := url.PathUnescape(image.Name)
+		if err := add("OEBPS/"+name, string(data)); err != nil {
+			return err
+		}
+	}
+
+	if err := add("OEBPS/style.css", defaultCSS); err != nil {
+		return err
+	}
+	if err := add("OEBPS/nav.xhtml", epubNav(chapters, title, language)); err != nil {
+		return err
+	}
+	if err := add("OEBPS/content.opf", e.packageDocument(chapters, title, language, modified)); err != nil {
+		return err
+	}
+	return archive.Close()
+}
+
+// epubNav renders the navigation document, listing the chapters
+func epubNav(chapters []*bookChapter, title, language string) string {
+	var result strings.Builder
+	result.WriteString(xhtmlHeader(title, language))
+	result.WriteString("<nav epub:type=\"toc\" id=\"toc\">\n")
+	fmt.Fprintf(&result, "<h1>%s</h1>\n<ol>\n", html.EscapeString(title))
+	for i, chapter := range chapters {
+		fmt.Fprintf(&result, "<li><a href=\"%s\">%s</a></li>\n", chapterFile(i), html.EscapeString(chapter.Title))
+	}
+	result.WriteString("</ol>\n</nav>\n</body>\n</html>\n")
+	return result.String()
+}
+
+// packageDocument renders the metadata, manifest and spine of the book
+func (e *epubWriter) packageDocument(chapters []*bookChapter, title, language string, modified time.Time) string {
+	// The identifier stays the same while the chapters do
+	h := sha1.New()
+	io.WriteString(h, title)
+	for _, chapter := range chapters {
+		io.WriteString(h, "\n"+chapter.Base)
+	}
+	sum := h.Sum(nil)
+	id := fmt.Sprintf("%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+
+	var result strings.Builder
+	fmt.Fprintf(&result, `<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="book-id" xml:lang="%s">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:identifier id="book-id">urn:uuid:%s</dc:identifier>
+    <dc:title>%s</dc:title>
+    <dc:language>%s</dc:language>
+    <meta property="dcterms:modified">%s</meta>
+  </metadata>
+  <manifest>
+    <item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+    <item id="style" href="style.css" media-type="text/css"/>
+`, html.EscapeString(language), id, html.EscapeString(title), html.EscapeString(language), modified.UTC().Format("2006-01-02T15:04:05Z"))
+	for i := range chapters {
+		fmt.Fprintf(&result, "    <item id=\"chapter-%d\" href=\"%s\" media-type=\"application/xhtml+xml\"/>\n", i+1, chapterFile(i))
+	}
+	for _, image := range e.images {
+		fmt.Fprintf(&result, "    <item id=\"%s\" href=\"%s\" media-type=\"%s\"/>\n", image.ID, html.EscapeString(image.Name), image.MediaType)
+	}
+	result.WriteString("  </manifest>\n  <spine>\n")
+	for i := range chapters {
+		fmt.Fprintf(&result, "    <itemref idref=\"chapter-%d\"/>\n", i+1)
+	}
+	result.WriteString("  </spine>\n</package>\n")
+	return result.String()
+}
+
+// runEPUB exports the documentation of a directory as an EPUB book
+func runEPUB(args []string) error {
+	flags := flag.NewFlagSet("epub", flag.ExitOnError)
+	output := flags.String("o", "book.epub", "Output EPUB file")
+	title := flags.String("title", "", "Book title (default: title of the index page)")
+	language := flags.String("language", "en", "Book language")
+	index := flags.String("index", "README.md", "Index file, first chapter of the book")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: godown epub [options] [directory]\n\nExport the Markdown pages of the directory (default: current one) as an EPUB book, in the order of SUMMARY.md or of the directory.\n\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return fmt.Errorf("too many arguments")
+	}
+
+	root := "."
+	if flags.NArg() == 1 {
+		root = flags.Arg(0)
+	}
+	if info, err := os.Stat(root); err != nil || !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", root)
+	}
+
+	tree := newDocTree(root, "", *index)
+	if *title == "" {
+		*title = tree.nav.Title
+	}
+
+	file, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	m := &mount{Root: root, Index: *index, Tree: tree}
+	if err := writeEPUB(file, m, *title, *language, time.Now()); err != nil {
+		file.Close()
+		os.Remove(*output)
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	log.Printf("EPUB written to %s", *output)
+	return nil
+}
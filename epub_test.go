@@ -0,0 +1,131 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Test the conversion of HTML tags and entities for XHTML
+func TestXHTMLEntities(t *testing.T) {
+	tags := xhtmlTags(`<p>a<br>b<img src="x.png" alt='y'/><input type="checkbox" disabled checked> <details open><summary>s</summary></details><hr class=x></p>`)
+	wantTags := `<p>a<br />b<img src="x.png" alt='y' /><input type="checkbox" disabled="disabled" checked="checked" /> <details open="open"><summary>s</summary></details><hr class="x" /></p>`
+	if tags != wantTags {
+		t.Errorf("xhtmlTags() = %q, want %q", tags, wantTags)
+	}
+
+	got := xhtmlEntities("&ldquo;a&rdquo; &amp; &lt;b&gt; &nbsp; &unknown;")
+	want := "&#8220;a&#8221; &amp; &lt;b&gt; &#160; &amp;unknown;"
+	if got != want {
+		t.Errorf("xhtmlEntities() = %q, want %q", got, want)
+	}
+}
+
+// Test the EPUB export of a tree
+func TestWriteEPUB(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTree(t, tmpDir, map[string]string{
+		"README.md":        "# Home\n\n\"Quoted\" -- see [install](guide/install.md#usage) and [[Usage]].\n\n![Logo](img/logo.png) ![Missing](img/missing.png) ![Remote](https://example.com/a.png)",
+		"SUMMARY.md":       "# Summary\n\n- [Home](README.md)\n- [Usage & tips](guide/usage.md)\n- [Install](guide/install.md)\n",
+		"guide/install.md": "# Install\n\n## Usage\n\nBack [home](../README.md)<br>\n\n![Logo](../img/logo.png)",
+		"guide/usage.md":   "# Usage & tips",
+		"img/logo.png":     "png data",
+	})
+
+	m := &mount{Root: tmpDir, Index: "README.md", Tree: newDocTree(tmpDir, "", "README.md")}
+	var buf bytes.Buffer
+	if err := writeEPUB(&buf, m, "My <Book>", "fr", time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := archive.File[0]
+	if first.Name != "mimetype" || first.Method != zip.Store {
+		t.Errorf("first entry = %s (method %d), want the stored mimetype", first.Name, first.Method)
+	}
+
+	files := map[string]string{}
+	var names []string
+	for _, f := range archive.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(r)
+		r.Close()
+		files[f.Name] = string(data)
+		names = append(names, f.Name)
+
+		// Every XML document is well-formed
+		if strings.HasSuffix(f.Name, ".xhtml") || strings.HasSuffix(f.Name, ".opf") || strings.HasSuffix(f.Name, ".xml") {
+			decoder := xml.NewDecoder(bytes.NewReader(data))
+			for {
+				if _, err := decoder.Token(); err == io.EOF {
+					break
+				} else if err != nil {
+					t.Errorf("%s is not well-formed: %v\n%s", f.Name, err, data)
+					break
+				}
+			}
+		}
+	}
+
+	wantNames := "mimetype,META-INF/container.xml,OEBPS/chapter-001.xhtml,OEBPS/chapter-002.xhtml,OEBPS/chapter-003.xhtml,OEBPS/images/img/logo.png,OEBPS/style.css,OEBPS/nav.xhtml,OEBPS/content.opf"
+	if got := strings.Join(names, ","); got != wantNames {
+		t.Errorf("entries = %s, want %s", got, wantNames)
+	}
+
+	tests := []struct {
+		file     string
+		contains []string
+	}{
+		{"mimetype", []string{"application/epub+zip"}},
+		{"OEBPS/chapter-001.xhtml", []string{
+			`<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops" lang="fr" xml:lang="fr">`,
+			"<title>Home</title>",
+			"&#8220;Quoted&#8221; &#8211;",
+			`<a href="chapter-003.xhtml#usage">install</a>`,
+			`<a class="godown-wikilink" href="chapter-002.xhtml">Usage</a>`,
+			`<img src="images/img/logo.png" alt="Logo" />`,
+			`<img src="img/missing.png" alt="Missing" />`,
+			`<img src="https://example.com/a.png" alt="Remote" />`,
+		}},
+		{"OEBPS/chapter-003.xhtml", []string{`<a href="chapter-001.xhtml">home</a>`, `<img src="images/img/logo.png" alt="Logo" />`}},
+		{"OEBPS/nav.xhtml", []string{
+			`<nav epub:type="toc" id="toc">`,
+			"<h1>My &lt;Book&gt;</h1>",
+			`<li><a href="chapter-002.xhtml">Usage &amp; tips</a></li>`,
+		}},
+		{"OEBPS/content.opf", []string{
+			"<dc:title>My &lt;Book&gt;</dc:title>",
+			"<dc:language>fr</dc:language>",
+			`<meta property="dcterms:modified">2024-05-01T00:00:00Z</meta>`,
+			`<item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>`,
+			`<item id="image-1" href="images/img/logo.png" media-type="image/png"/>`,
+			"<itemref idref=\"chapter-1\"/>\n    <itemref idref=\"chapter-2\"/>\n    <itemref idref=\"chapter-3\"/>",
+		}},
+	}
+	for _, tt := range tests {
+		for _, expected := range tt.contains {
+			if !strings.Contains(files[tt.file], expected) {
+				t.Errorf("%s should contain %q, got:\n%s", tt.file, expected, files[tt.file])
+			}
+		}
+	}
+}
+
+// Test the export of a directory without pages
+func TestWriteEPUBEmpty(t *testing.T) {
+	tmpDir := t.TempDir()
+	m := &mount{Root: tmpDir, Index: "README.md", Tree: newDocTree(tmpDir, "", "README.md")}
+	if err := writeEPUB(io.Discard, m, "Empty", "en", time.Now()); err == nil {
+		t.Errorf("writeEPUB() should fail without pages")
+	}
+}
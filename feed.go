@@ -0,0 +1,470 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"html"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxFeedEntries is the number of most recent pages listed in a feed
+const maxFeedEntries = 50
+
+// Feed file names, served in the feed directories
+const (
+	atomFeedName = "feed.atom"
+	rssFeedName  = "feed.rss"
+)
+
+// feedDirs are the URL paths of the directories with feeds, set with -feeds
+var feedDirs []string
+
+// baseURL is the absolute URL of the site used in feeds and the sitemap, set
+// with -base-url (taken from the request when empty)
+var baseURL string
+
+// parseFeedDirs normalizes the URL paths of the feed directories
+func parseFeedDirs(values []string) []string {
+	dirs := make([]string, 0, len(values))
+	for _, value := range values {
+		dirs = append(dirs, path.Clean("/"+value))
+	}
+	return dirs
+}
+
+// feedEntry is a page listed in a feed
+type feedEntry struct {
+	Page      *pageInfo
+	Published time.Time
+	Updated   time.Time
+	Author    string
+	Content   string
+}
+
+// parseFeedDate parses a front matter date
+func parseFeedDate(value string) (time.Time, bool) {
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02"} {
+		if date, err := time.Parse(layout, strings.TrimSpace(value)); err == nil {
+			return date, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// entryDates returns the creation and last update dates of a page and its
+// author: the front matter ones, else the first and last commits changing
+// the file, else its modification time. The history of the file is only
+// walked when the front matter gives neither the date nor the author.
+func entryDates(repo *gitRepo, filePath string, meta frontMatter) (published, updated time.Time, author string) {
+	if info, err := os.Stat(filePath); err == nil {
+		published, updated = info.ModTime(), info.ModTime()
+	}
+	date, dated := parseFeedDate(meta.Date)
+	if repo != nil {
+		if rel, ok := repo.relPath(filePath); ok {
+			if last, err := repo.lastCommit(rel); err == nil && last != nil {
+				published, updated, author = last.Author.When, last.Author.When, last.Author.Name
+			}
+			if !dated || meta.Author == "" {
+				if first, err := repo.firstCommit(rel); err == nil && first != nil {
+					published, author = first.Author.When, first.Author.Name
+				}
+			}
+		}
+	}
+
+	if dated {
+		published = date
+		if updated.Before(date) {
+			updated = date
+		}
+	} else if meta.Date != "" {
+		log.Printf("Invalid date %q in %s", meta.Date, filePath)
+	}
+	if meta.Author != "" {
+		author = meta.Author
+	}
+	return published, updated, author
+}
+
+// linkAttribute matches the link and source attributes of rendered HTML
+var linkAttribute = regexp.MustCompile(`\b(href|src)="([^"]*)"`)
+
+// absoluteLinks resolves the links of rendered HTML against the absolute URL
+// of its page, feed readers not knowing where the content comes from
+func absoluteLinks(content, page string) string {
+	base, err := url.Parse(page)
+	if err != nil {
+		return content
+	}
+	return linkAttribute.ReplaceAllStringFunc(content, func(attr string) string {
+		m := linkAttribute.FindStringSubmatch(attr)
+		ref, err := url.Parse(html.UnescapeString(m[2]))
+		if err != nil {
+			return attr
+		}
+		return m[1] + "=\"" + html.EscapeString(base.ResolveReference(ref).String()) + "\""
+	})
+}
+
+// cachedFeed holds the entries of a feed directory, valid until the tree or
+// HEAD changes
+type cachedFeed struct {
+	tree    *docTree
+	stamp   uint64
+	head    gitHash
+	base    string
+	entries []*feedEntry
+}
+
+// feedCache keeps the entries of the feed directories by URL path
+var feedCache = struct {
+	mu    sync.Mutex
+	feeds map[string]*cachedFeed
+}{feeds: map[string]*cachedFeed{}}
+
+// feedSortDate returns the date selecting and ordering the entries of a feed,
+// known before they are built: the front matter date, else the date of the
+// last commit changing the file (cached until HEAD moves), else its
+// modification time
+func feedSortDate(repo *gitRepo, filePath string, meta frontMatter) time.Time {
+	if date, ok := parseFeedDate(meta.Date); ok {
+		return date
+	}
+	if repo != nil {
+		if rel, ok := repo.relPath(filePath); ok {
+			if commit, err := repo.lastCommit(rel); err == nil && commit != nil {
+				return commit.Author.When
+			}
+		}
+	}
+	if info, err := os.Stat(filePath); err == nil {
+		return info.ModTime()
+	}
+	return time.Time{}
+}
+
+// feedEntries returns the pages of a feed directory, most recent first by
+// feedSortDate. Only the most recent pages are read and rendered, once until
+// the tree or HEAD changes.
+func feedEntries(r *http.Request, m *mount, dir string) []*feedEntry {
+	if m.Tree == nil {
+		return nil
+	}
+	m.Tree.mu.RLock()
+	files, stamp := m.Tree.files, m.Tree.stamp
+	m.Tree.mu.RUnlock()
+	var head gitHash
+	if m.Repo != nil {
+		head, _ = m.Repo.head()
+	}
+	base := siteURL(r, "")
+
+	feedCache.mu.Lock()
+	cached := feedCache.feeds[dir]
+	feedCache.mu.Unlock()
+	if cached != nil && cached.tree == m.Tree && cached.stamp == stamp && cached.head == head && cached.base == base {
+		return cached.entries
+	}
+
+	type candidate struct {
+		page     *pageInfo
+		filePath string
+		date     time.Time
+	}
+	prefix := strings.TrimSuffix(pageURL(dir), "/") + "/"
+	var candidates []candidate
+	for _, page := range files {
+		// The page of the directory itself is not an entry
+		if !strings.HasPrefix(page.URL, prefix) || page.URL == prefix || page.Meta.Draft {
+			continue
+		}
+		filePath := filepath.Join(m.Root, filepath.FromSlash(page.File))
+		candidates = append(candidates, candidate{page, filePath, feedSortDate(m.Repo, filePath, page.Meta)})
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].date.After(candidates[j].date)
+	})
+	if len(candidates) > maxFeedEntries {
+		candidates = candidates[:maxFeedEntries]
+	}
+
+	var entries []*feedEntry
+	for _, c := range candidates {
+		content, err := os.ReadFile(c.filePath)
+		if err != nil {
+			continue
+		}
+		meta, body := splitFrontMatter(content)
+		body, _ = expandIncludes(body, c.filePath, m.Root)
+		content = renderMarkdown(body, pageMarkdownOptions(meta), m.Tree)
+		entry := &feedEntry{
+			Page:    c.page,
+			Content: absoluteLinks(string(content), base+c.page.URL),
+		}
+		entry.Published, entry.Updated, entry.Author = entryDates(m.Repo, c.filePath, meta)
+		entries = append(entries, entry)
+	}
+
+	feedCache.mu.Lock()
+	feedCache.feeds[dir] = &cachedFeed{tree: m.Tree, stamp: stamp, head: head, base: base, entries: entries}
+	feedCache.mu.Unlock()
+	return entries
+}
+
+// feedTitle returns the title of the page of a feed directory, else its name
+func feedTitle(m *mount, dir string) string {
+	if m.Tree != nil {
+		m.Tree.mu.RLock()
+		defer m.Tree.mu.RUnlock()
+		for _, page := range m.Tree.files {
+			if page.URL == pageURL(dir) {
+				return page.Title
+			}
+		}
+	}
+	return path.Base(dir)
+}
+
+// siteURL returns the absolute URL of a path, based on -base-url or the
+// request host
+func siteURL(r *http.Request, p string) string {
+	if baseURL != "" {
+		return strings.TrimSuffix(baseURL, "/") + p
+	}
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+	return scheme + "://" + r.Host + p
+}
+
+// feedDir returns the feed directory of a feed URL path, false if the path
+// is not a feed
+func feedDir(urlPath string) (string, bool) {
+	dir, name := path.Split(urlPath)
+	if name != atomFeedName && name != rssFeedName {
+		return "", false
+	}
+	dir = path.Clean(dir)
+	for _, feed := range feedDirs {
+		if feed == dir {
+			return dir, true
+		}
+	}
+	return "", false
+}
+
+// serveFeed serves the Atom (feed.atom) and RSS (feed.rss) feeds of the feed
+// directories, returning false for other paths
+func serveFeed(w http.ResponseWriter, r *http.Request, m *mount, urlPath string) bool {
+	dir, ok := feedDir(urlPath)
+	if !ok {
+		return false
+	}
+
+	entries := feedEntries(r, m, dir)
+	title := feedTitle(m, dir)
+	var feed any
+	contentType := "application/atom+xml; charset=utf-8"
+	if path.Base(urlPath) == atomFeedName {
+		feed = atomFeedOf(r, dir, title, entries)
+	} else {
+		feed = rssFeedOf(r, dir, title, entries)
+		contentType = "application/rss+xml; charset=utf-8"
+	}
+
+	output, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		log.Printf("Error generating feed %s: %v", urlPath, err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return true
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Write([]byte(xml.Header))
+	w.Write(output)
+	return true
+}
+
+// feedLinks renders the feed discovery links of the pages of a feed directory
+func feedLinks(urlPath string) template.HTML {
+	var result strings.Builder
+	for _, dir := range feedDirs {
+		if urlPath != dir && !strings.HasPrefix(urlPath, strings.TrimSuffix(dir, "/")+"/") {
+			continue
+		}
+		base := strings.TrimSuffix(pageURL(dir), "/")
+		fmt.Fprintf(&result, "<link rel=\"alternate\" type=\"application/atom+xml\" href=\"%s/%s\">\n", template.HTMLEscapeString(base), atomFeedName)
+		fmt.Fprintf(&result, "<link rel=\"alternate\" type=\"application/rss+xml\" href=\"%s/%s\">\n", template.HTMLEscapeString(base), rssFeedName)
+	}
+	return template.HTML(result.String())
+}
+
+// atomFeed is an Atom 1.0 feed (RFC 4287)
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr"`
+	Text string `xml:",chardata"`
+}
+
+type atomEntry struct {
+	Title     string      `xml:"title"`
+	ID        string      `xml:"id"`
+	Link      atomLink    `xml:"link"`
+	Published string      `xml:"published"`
+	Updated   string      `xml:"updated"`
+	Author    *atomAuthor `xml:"author,omitempty"`
+	Summary   *atomText   `xml:"summary,omitempty"`
+	Content   atomText    `xml:"content"`
+}
+
+// atomFeedOf builds the Atom feed of a directory
+func atomFeedOf(r *http.Request, dir, title string, entries []*feedEntry) *atomFeed {
+	base := strings.TrimSuffix(pageURL(dir), "/")
+	feed := &atomFeed{
+		Title: title,
+		ID:    siteURL(r, base+"/"+atomFeedName),
+		Links: []atomLink{
+			{Rel: "self", Type: "application/atom+xml", Href: siteURL(r, base+"/"+atomFeedName)},
+			{Rel: "alternate", Type: "text/html", Href: siteURL(r, pageURL(dir))},
+		},
+	}
+
+	var updated time.Time
+	for _, entry := range entries {
+		link := siteURL(r, entry.Page.URL)
+		item := atomEntry{
+			Title:     entry.Page.Title,
+			ID:        link,
+			Link:      atomLink{Rel: "alternate", Type: "text/html", Href: link},
+			Published: entry.Published.UTC().Format(time.RFC3339),
+			Updated:   entry.Updated.UTC().Format(time.RFC3339),
+			Content:   atomText{Type: "html", Text: entry.Content},
+		}
+		if entry.Author != "" {
+			item.Author = &atomAuthor{Name: entry.Author}
+		}
+		if summary := entry.Page.Meta.Summary; summary != "" {
+			item.Summary = &atomText{Type: "text", Text: summary}
+		}
+		feed.Entries = append(feed.Entries, item)
+		if entry.Updated.After(updated) {
+			updated = entry.Updated
+		}
+	}
+	if updated.IsZero() {
+		updated = time.Now()
+	}
+	feed.Updated = updated.UTC().Format(time.RFC3339)
+	return feed
+}
+
+// rssFeed is a RSS 2.0 feed, with the full content of the items
+type rssFeed struct {
+	XMLName   xml.Name   `xml:"rss"`
+	Version   string     `xml:"version,attr"`
+	ContentNS string     `xml:"xmlns:content,attr"`
+	AtomNS    string     `xml:"xmlns:atom,attr"`
+	Channel   rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Self          rssLink   `xml:"atom:link"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssLink struct {
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr"`
+	Href string `xml:"href,attr"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link"`
+	GUID        rssGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate"`
+	Description string  `xml:"description"`
+	Content     string  `xml:"content:encoded"`
+}
+
+// rssFeedOf builds the RSS feed of a directory
+func rssFeedOf(r *http.Request, dir, title string, entries []*feedEntry) *rssFeed {
+	base := strings.TrimSuffix(pageURL(dir), "/")
+	feed := &rssFeed{
+		Version:   "2.0",
+		ContentNS: "http://purl.org/rss/1.0/modules/content/",
+		AtomNS:    "http://www.w3.org/2005/Atom",
+		Channel: rssChannel{
+			Title:       title,
+			Link:        siteURL(r, pageURL(dir)),
+			Description: title,
+			Self:        rssLink{Rel: "self", Type: "application/rss+xml", Href: siteURL(r, base+"/"+rssFeedName)},
+		},
+	}
+
+	var updated time.Time
+	for _, entry := range entries {
+		link := siteURL(r, entry.Page.URL)
+		description := entry.Page.Meta.Summary
+		if description == "" {
+			description = entry.Content
+		}
+		feed.Channel.Items = append(feed.Channel.Items, rssItem{
+			Title:       entry.Page.Title,
+			Link:        link,
+			GUID:        rssGUID{IsPermaLink: true, Value: link},
+			PubDate:     entry.Published.Format(time.RFC1123Z),
+			Description: description,
+			Content:     entry.Content,
+		})
+		if entry.Updated.After(updated) {
+			updated = entry.Updated
+		}
+	}
+	if !updated.IsZero() {
+		feed.Channel.LastBuildDate = updated.Format(time.RFC1123Z)
+	}
+	return feed
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Test front matter dates parsing
+func TestParseFeedDate(t *testing.T) {
+	tests := map[string]string{
+		"2024-05-01":                "2024-05-01T00:00:00Z",
+		"2024-05-01 14:30":          "2024-05-01T14:30:00Z",
+		"2024-05-01T14:30:00+02:00": "2024-05-01T14:30:00+02:00",
+	}
+	for value, want := range tests {
+		date, ok := parseFeedDate(value)
+		if !ok || date.Format(time.RFC3339) != want {
+			t.Errorf("parseFeedDate(%q) = %v, %v, want %s", value, date, ok, want)
+		}
+	}
+	if _, ok := parseFeedDate("May 1st"); ok {
+		t.Errorf("parseFeedDate() should reject unknown formats")
+	}
+}
+
+// Test dates and authors taken from git
+func TestEntryDates(t *testing.T) {
+	dir := gitFixture(t)
+	repo := openGitRepo(dir)
+	filePath := filepath.Join(dir, "docs", "guide.md")
+
+	published, updated, author := entryDates(repo, filePath, frontMatter{})
+	if published.UTC().Format(time.RFC3339) != "2024-01-01T08:00:00Z" || updated.UTC().Format(time.RFC3339) != "2024-03-01T10:00:00Z" || author != "Alice" {
+		t.Errorf("entryDates() = %v, %v, %q, want the first and last commits", published, updated, author)
+	}
+
+	published, updated, author = entryDates(repo, filePath, frontMatter{Date: "2024-04-01", Author: "Bob"})
+	if published.Format("2006-01-02") != "2024-04-01" || updated.Format("2006-01-02") != "2024-04-01" || author != "Bob" {
+		t.Errorf("entryDates() = %v, %v, %q, want the front matter ones", published, updated, author)
+	}
+
+	// The history is not walked when the front matter gives the date and author
+	entryDates(repo, filepath.Join(dir, "other.md"), frontMatter{Date: "2024-04-01", Author: "Bob"})
+	if _, walked := repo.firstCache["other.md"]; walked {
+		t.Errorf("entryDates() should not look for the first commit of a dated page")
+	}
+}
+
+// Test the Atom and RSS feeds of a directory
+func TestServeFeed(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTree(t, tmpDir, map[string]string{
+		"README.md":          "# Home",
+		"posts/README.md":    "# Changelog",
+		"posts/release-1.md": "---\ntitle: Release 1\ndate: 2024-01-10\nsummary: First release\n---\nHello **world**",
+		"posts/release-2.md": "---\ndate: 2024-02-10\nauthor: Bob\n---\n# Release 2\n\nSee [[Release 1]]",
+		"other.md":           "# Other",
+		"posts/draft.md":     "---\ndraft: true\ndate: 2024-03-10\n---\n# Draft",
+	})
+
+	march := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	for _, name := range []string{"posts/release-1.md", "posts/release-2.md"} {
+		os.Chtimes(filepath.Join(tmpDir, name), march, march)
+	}
+
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+
+	oldTree, oldFeeds, oldBase := siteTree, feedDirs, baseURL
+	siteTree = newDocTree(".", "", "README.md")
+	feedDirs = parseFeedDirs([]string{"posts/"})
+	baseURL = "https://docs.example.com/"
+	defer func() { siteTree, feedDirs, baseURL = oldTree, oldFeeds, oldBase }()
+
+	// Atom
+	req := httptest.NewRequest("GET", "/posts/feed.atom", nil)
+	w := httptest.NewRecorder()
+	serveMarkdown(w, req)
+
+	if ct := w.Header().Get("Content-Type"); ct != "application/atom+xml; charset=utf-8" {
+		t.Fatalf("Content-Type = %q, body:\n%s", ct, w.Body.String())
+	}
+	var atom atomFeed
+	if err := xml.Unmarshal(w.Body.Bytes(), &atom); err != nil {
+		t.Fatalf("invalid Atom feed: %v\n%s", err, w.Body.String())
+	}
+	if atom.Title != "Changelog" || atom.ID != "https://docs.example.com/posts/feed.atom" || atom.Updated != "2024-03-01T00:00:00Z" {
+		t.Errorf("feed = %q %q %q", atom.Title, atom.ID, atom.Updated)
+	}
+	if len(atom.Entries) != 2 {
+		t.Fatalf("feed should have 2 entries, got %d", len(atom.Entries))
+	}
+	latest, oldest := atom.Entries[0], atom.Entries[1]
+	if latest.Title != "Release 2" || latest.Link.Href != "https://docs.example.com/posts/release-2" || latest.Author == nil || latest.Author.Name != "Bob" {
+		t.Errorf("latest entry = %+v", latest)
+	}
+	if !strings.Contains(latest.Content.Text, `<a class="godown-wikilink" href="https://docs.example.com/posts/release-1">Release 1</a>`) {
+		t.Errorf("entry content should be the rendered page, got %q", latest.Content.Text)
+	}
+	if oldest.Published != "2024-01-10T00:00:00Z" || oldest.Summary == nil || oldest.Summary.Text != "First release" || !strings.Contains(oldest.Content.Text, "<strong>world</strong>") {
+		t.Errorf("oldest entry = %+v", oldest)
+	}
+
+	// RSS
+	req = httptest.NewRequest("GET", "/posts/feed.rss", nil)
+	w = httptest.NewRecorder()
+	serveMarkdown(w, req)
+
+	if err := xml.Unmarshal(w.Body.Bytes(), new(any)); err != nil {
+		t.Fatalf("invalid RSS feed: %v\n%s", err, w.Body.String())
+	}
+	body := w.Body.String()
+	for _, expected := range []string{
+		`<rss version="2.0" xmlns:content="http://purl.org/rss/1.0/modules/content/" xmlns:atom="http://www.w3.org/2005/Atom">`,
+		"<link>https://docs.example.com/posts</link>",
+		`<atom:link rel="self" type="application/rss+xml" href="https://docs.example.com/posts/feed.rss"></atom:link>`,
+		"<pubDate>Wed, 10 Jan 2024 00:00:00 +0000</pubDate>",
+		"<description>First release</description>",
+		"<content:encoded>&lt;p&gt;Hello &lt;strong&gt;world&lt;/strong&gt;&lt;/p&gt;",
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("RSS feed should contain %q, got:\n%s", expected, body)
+		}
+	}
+
+	// Discovery links and other directories
+	req = httptest.NewRequest("GET", "/posts/release-1", nil)
+	w = httptest.NewRecorder()
+	serveMarkdown(w, req)
+	if !strings.Contains(w.Body.String(), `<link rel="alternate" type="application/atom+xml" href="/posts/feed.atom">`) {
+		t.Errorf("pages of the directory should link to the feeds")
+	}
+
+	req = httptest.NewRequest("GET", "/feed.atom", nil)
+	w = httptest.NewRecorder()
+	serveMarkdown(w, req)
+	if w.Code != 404 {
+		t.Errorf("directories without feeds should not serve one, got %d", w.Code)
+	}
+}
+
+// Test that only the most recent pages of large feeds are read, once until
+// the tree changes
+func TestFeedEntriesRecent(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{"posts/README.md": "# Posts"}
+	for day := 1; day <= maxFeedEntries+10; day++ {
+		date := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, day)
+		files[fmt.Sprintf("posts/post-%02d.md", day)] = fmt.Sprintf("---\ndate: %s\n---\n# Post %d", date.Format("2006-01-02"), day)
+	}
+	writeTree(t, tmpDir, files)
+
+	m := &mount{Root: tmpDir, Index: "README.md", Tree: newDocTree(tmpDir, "", "README.md")}
+	req := httptest.NewRequest("GET", "/posts/feed.atom", nil)
+	entries := feedEntries(req, m, "/posts")
+	if len(entries) != maxFeedEntries || entries[0].Page.File != "posts/post-60.md" || entries[len(entries)-1].Page.File != "posts/post-11.md" {
+		t.Fatalf("feedEntries() = %d entries, want the %d most recent", len(entries), maxFeedEntries)
+	}
+
+	// The rendered entries are kept while the tree is unchanged
+	os.WriteFile(filepath.Join(tmpDir, "posts", "post-60.md"), []byte("---\ndate: 2024-03-01\n---\n# Changed"), 0644)
+	if again := feedEntries(req, m, "/posts"); again[0] != entries[0] {
+		t.Errorf("feedEntries() should be cached until the tree changes")
+	}
+	m.Tree.refresh()
+	if again := feedEntries(req, m, "/posts"); !strings.Contains(again[0].Content, "Changed") {
+		t.Errorf("feedEntries() should be rebuilt when the tree changes")
+	}
+}
+
+// Test absolute URLs without a base URL
+func TestSiteURL(t *testing.T) {
+	req := httptest.NewRequest("GET", "/posts/feed.atom", nil)
+	req.Host = "localhost:8080"
+	if got := siteURL(req, "/posts"); got != "http://localhost:8080/posts" {
+		t.Errorf("siteURL() = %q", got)
+	}
+	req.Header.Set("X-Forwarded-Proto", "https")
+	if got := siteURL(req, "/posts"); got != "https://localhost:8080/posts" {
+		t.Errorf("siteURL() = %q", got)
+	}
+}
+
+// Test links resolution in feed contents
+func TestAbsoluteLinks(t *testing.T) {
+	content := `<a href="other">x</a> <img src="../img/a.png" alt=""> <a href="/guide?a=1&amp;b=2">y</a> <a href="https://example.org">z</a> <a href="#top">t</a>`
+	want := `<a href="https://docs.example.com/posts/other">x</a> <img src="https://docs.example.com/img/a.png" alt=""> <a href="https://docs.example.com/guide?a=1&amp;b=2">y</a> <a href="https://example.org">z</a> <a href="https://docs.example.com/posts/release#top">t</a>`
+	if got := absoluteLinks(content, "https://docs.example.com/posts/release"); got != want {
+		t.Errorf("absoluteLinks() =\n%s\nwant\n%s", got, want)
+	}
+}
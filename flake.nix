@@ -23,7 +23,7 @@
           # x-release-please-end
           src = ./.;
 
-          vendorHash = "sha256-nlaO32vKmi3QVp9rZ8UCn5LIfBhLlkkiYMvuRVRK+BQ=";
+          vendorHash = "sha256-XPC1A3cyT8E6URCLc1mmpJFJL7+ObT+KLStzGAIDWqU=";
 
           meta = with pkgs.lib; {
             description = "A simple Markdown file server written in Go";
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"log"
+
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/gomarkdown/markdown/parser"
+	"gopkg.in/yaml.v3"
+)
+
+// frontMatter holds the metadata declared in the YAML header of a Markdown file
+type frontMatter struct {
+	Title       string   `yaml:"title"`
+	Description string   `yaml:"description"`
+	Date        string   `yaml:"date"`       // Publication date in feeds
+	Summary     string   `yaml:"summary"`    // Summary in feeds
+	Author      string   `yaml:"author"`     // Author in feeds
+	Draft       bool     `yaml:"draft"`      // Left out of the sitemap and feeds
+	Tags        nameList `yaml:"tags"`       // Tags, listed on /__godown/tags
+	Extensions  nameList `yaml:"extensions"` // Markdown extensions enabled or disabled (-name) for the page
+	HTMLFlags   nameList `yaml:"html_flags"` // HTML flags enabled or disabled (-name) for the page
+}
+
+// splitFrontMatter separates the YAML front matter (delimited by "---" lines)
+// from the Markdown body. Content without valid front matter is returned as is.
+func splitFrontMatter(content []byte) (frontMatter, []byte) {
+	var meta frontMatter
+
+	rest, ok := bytes.CutPrefix(content, []byte("---\n"))
+	if !ok {
+		rest, ok = bytes.CutPrefix(content, []byte("---\r\n"))
+	}
+	if !ok {
+		return meta, content
+	}
+
+	// Look for the closing delimiter at the start of a line
+	for offset := 0; offset < len(rest); {
+		end := bytes.IndexByte(rest[offset:], '\n')
+		line := rest[offset:]
+		next := len(rest)
+		if end >= 0 {
+			line = rest[offset : offset+end]
+			next = offset + end + 1
+		}
+		line = bytes.TrimRight(line, "\r")
+
+		if string(line) == "---" || string(line) == "..." {
+			if err := yaml.Unmarshal(rest[:offset], &meta); err != nil {
+				log.Printf("Invalid front matter: %v", err)
+				return frontMatter{}, content
+			}
+			return meta, rest[next:]
+		}
+		offset = next
+	}
+
+	return meta, content
+}
+
+// extractTitle returns the text of the first level 1 heading of a Markdown
+// document, or of the first heading if there is no level 1 heading
+func extractTitle(md []byte) string {
+	p := parser.NewWithExtensions(parser.CommonExtensions)
+	doc := p.Parse(md)
+
+	var first, title string
+	ast.WalkFunc(doc, func(node ast.Node, entering bool) ast.WalkStatus {
+		heading, ok := node.(*ast.Heading)
+		if !ok || !entering {
+			return ast.GoToNext
+		}
+		text := nodeText(heading)
+		if first == "" {
+			first = text
+		}
+		if heading.Level == 1 {
+			title = text
+			return ast.Terminate
+		}
+		return ast.SkipChildren
+	})
+
+	if title == "" {
+		return first
+	}
+	return title
+}
+
+// nodeText concatenates the literal text of all leaves below a node
+func nodeText(node ast.Node) string {
+	var buf bytes.Buffer
+	ast.WalkFunc(node, func(n ast.Node, entering bool) ast.WalkStatus {
+		if !entering {
+			return ast.GoToNext
+		}
+		switch leaf := n.(type) {
+		case *ast.Text:
+			buf.Write(leaf.Literal)
+		case *ast.Code:
+			buf.Write(leaf.Literal)
+		}
+		return ast.GoToNext
+	})
+	return string(bytes.TrimSpace(buf.Bytes()))
+}
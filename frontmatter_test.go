@@ -0,0 +1,83 @@
+package main
+
+import (
+	"testing"
+)
+
+// Test front matter extraction
+func TestSplitFrontMatter(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		wantTitle string
+		wantBody  string
+	}{
+		{
+			name:      "No front matter",
+			input:     "# Title\n",
+			wantTitle: "",
+			wantBody:  "# Title\n",
+		},
+		{
+			name:      "Title in front matter",
+			input:     "---\ntitle: My page\n---\n# Heading\n",
+			wantTitle: "My page",
+			wantBody:  "# Heading\n",
+		},
+		{
+			name:      "CRLF line endings",
+			input:     "---\r\ntitle: Windows\r\n---\r\nBody",
+			wantTitle: "Windows",
+			wantBody:  "Body",
+		},
+		{
+			name:      "Unterminated front matter",
+			input:     "---\ntitle: Oops\n",
+			wantTitle: "",
+			wantBody:  "---\ntitle: Oops\n",
+		},
+		{
+			name:      "Invalid YAML",
+			input:     "---\ntitle: [oops\n---\nBody",
+			wantTitle: "",
+			wantBody:  "---\ntitle: [oops\n---\nBody",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meta, body := splitFrontMatter([]byte(tt.input))
+			if meta.Title != tt.wantTitle {
+				t.Errorf("splitFrontMatter() title = %q, want %q", meta.Title, tt.wantTitle)
+			}
+			if string(body) != tt.wantBody {
+				t.Errorf("splitFrontMatter() body = %q, want %q", body, tt.wantBody)
+			}
+		})
+	}
+}
+
+// Test title extraction from headings
+func TestExtractTitle(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"Level 1 heading", "intro\n\n# Main title\n", "Main title"},
+		{"Level 1 preferred", "## Sub\n\n# Main\n", "Main"},
+		{"First heading fallback", "## Only sub\n", "Only sub"},
+		{"Inline formatting", "# The `godown` **server**\n", "The godown server"},
+		{"Heading in code block", "```\n# not a title\n```\n", ""},
+		{"No heading", "Just text", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := extractTitle([]byte(tt.input))
+			if result != tt.expected {
+				t.Errorf("extractTitle() = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,365 @@
+package main
+
+import (
+	"bytes"
+	"container/list"
+	"fmt"
+	"html/template"
+	"image"
+	"image/draw"
+	_ "image/gif" // GIF decoder of the thumbnails
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// thumbnailSize is the largest side of the thumbnails, in pixels
+	thumbnailSize = 320
+	// maxThumbnailPixels bounds the size of the images decoded to make a
+	// thumbnail, larger images being served as is
+	maxThumbnailPixels = 50_000_000
+	// thumbnailCacheSize bounds the number of thumbnails kept in memory
+	thumbnailCacheSize = 1024
+	// thumbnailJobs bounds the number of images decoded at once
+	thumbnailJobs = 2
+)
+
+// galleryExtensions are the images shown in galleries
+var galleryExtensions = map[string]bool{
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".webp": true, ".bmp": true, ".svg": true,
+}
+
+// thumbnailExtensions are the images reduced by the server
+var thumbnailExtensions = map[string]bool{".png": true, ".jpg": true, ".jpeg": true, ".gif": true}
+
+// thumbnail is a reduced image, valid while its file is unchanged
+type thumbnail struct {
+	path        string
+	modTime     time.Time
+	size        int64
+	contentType string
+	data        []byte
+}
+
+// thumbnailCache keeps the most recently used thumbnails, and the images
+// being reduced so that concurrent requests wait for a single decoding
+type thumbnailCache struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[string]*list.Element // Values are *thumbnail
+	order    *list.List               // Most recently used first
+	pending  map[string]chan struct{}
+}
+
+// newThumbnailCache returns an empty cache of the given capacity
+func newThumbnailCache(capacity int) *thumbnailCache {
+	return &thumbnailCache{
+		capacity: capacity,
+		entries:  map[string]*list.Element{},
+		order:    list.New(),
+		pending:  map[string]chan struct{}{},
+	}
+}
+
+// thumbnails caches the thumbnails by file path
+var thumbnails = newThumbnailCache(thumbnailCacheSize)
+
+// thumbnailSlots limits the concurrent decodings
+var thumbnailSlots = make(chan struct{}, thumbnailJobs)
+
+// get returns the thumbnail of an image, made once until the image changes
+func (c *thumbnailCache) get(filePath string, info os.FileInfo) *thumbnail {
+	for {
+		c.mu.Lock()
+		if element, ok := c.entries[filePath]; ok {
+			thumb := element.Value.(*thumbnail)
+			if thumb.modTime.Equal(info.ModTime()) && thumb.size == info.Size() {
+				c.order.MoveToFront(element)
+				c.mu.Unlock()
+				return thumb
+			}
+		}
+		wait, busy := c.pending[filePath]
+		if busy {
+			c.mu.Unlock()
+			<-wait
+			continue
+		}
+		done := make(chan struct{})
+		c.pending[filePath] = done
+		c.mu.Unlock()
+
+		thumbnailSlots <- struct{}{}
+		thumb, err := makeThumbnail(filePath)
+		<-thumbnailSlots
+		if err != nil {
+			thumb = &thumbnail{}
+		}
+		thumb.path, thumb.modTime, thumb.size = filePath, info.ModTime(), info.Size()
+
+		c.mu.Lock()
+		delete(c.pending, filePath)
+		c.add(thumb)
+		c.mu.Unlock()
+		close(done)
+		return thumb
+	}
+}
+
+// add stores a thumbnail, evicting the least recently used one when the
+// cache is full. The caller holds the lock.
+func (c *thumbnailCache) add(thumb *thumbnail) {
+	if element, ok := c.entries[thumb.path]; ok {
+		element.Value = thumb
+		c.order.MoveToFront(element)
+		return
+	}
+	c.entries[thumb.path] = c.order.PushFront(thumb)
+	if c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*thumbnail).path)
+	}
+}
+
+// isImageFile checks if the file is an image shown in galleries
+func isImageFile(filePath string) bool {
+	return galleryExtensions[strings.ToLower(filepath.Ext(filePath))]
+}
+
+// isThumbnailFile checks if a thumbnail can be made from the file
+func isThumbnailFile(filePath string) bool {
+	return thumbnailExtensions[strings.ToLower(filepath.Ext(filePath))]
+}
+
+// gallery is the content of a directory shown as a gallery
+type gallery struct {
+	images []string
+	dirs   []string
+	files  []string
+}
+
+// readGallery lists the images, subdirectories and other files of a
+// directory, without the hidden ones
+func readGallery(dir string) (*gallery, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	g := &gallery{}
+	for _, entry := range entries {
+		name := entry.Name()
+		switch {
+		case strings.HasPrefix(name, "."):
+		case entry.IsDir():
+			g.dirs = append(g.dirs, name)
+		case isImageFile(name):
+			g.images = append(g.images, name)
+		default:
+			g.files = append(g.files, name)
+		}
+	}
+	return g, nil
+}
+
+// isGallery checks if most files of the directory are images
+func (g *gallery) isGallery() bool {
+	return len(g.images) > 0 && len(g.images)*2 > len(g.images)+len(g.files)
+}
+
+// isGalleryDir checks if a directory is shown as a gallery
+func isGalleryDir(dir string) bool {
+	g, err := readGallery(dir)
+	return err == nil && g.isGallery()
+}
+
+// renderGallery renders the thumbnails of the images of a directory, with
+// links to its browsable subdirectories and its other files
+func renderGallery(g *gallery, dir, urlPath string) string {
+	var result strings.Builder
+	fmt.Fprintf(&result, "<h1>%s</h1>\n", template.HTMLEscapeString(path.Base(urlPath)))
+	fmt.Fprintf(&result, "<p class=\"godown-gallery-info\">Images: %d</p>\n", len(g.images))
+
+	var dirs []string
+	for _, name := range g.dirs {
+		sub := filepath.Join(dir, name)
+		if _, err := os.Stat(filepath.Join(sub, "README.md")); err == nil || isGalleryDir(sub) {
+			dirs = append(dirs, name)
+		}
+	}
+	if len(dirs) > 0 {
+		result.WriteString("<ul class=\"godown-gallery-dirs\">\n")
+		for _, name := range dirs {
+			fmt.Fprintf(&result, "<li><a href=\"%s/\">%s/</a></li>\n",
+				template.HTMLEscapeString(pageURL(path.Join(urlPath, name))),
+				template.HTMLEscapeString(name),
+			)
+		}
+		result.WriteString("</ul>\n")
+	}
+
+	result.WriteString("<div class=\"godown-gallery\">\n")
+	for _, name := range g.images {
+		src := pageURL(path.Join(urlPath, name))
+		thumb := src
+		if isThumbnailFile(name) {
+			thumb += "?thumb"
+		}
+		fmt.Fprintf(&result, "<a class=\"godown-gallery-item\" href=\"%s\" title=\"%s\"><img src=\"%s\" alt=\"%s\" loading=\"lazy\"><span>%s</span></a>\n",
+			template.HTMLEscapeString(src),
+			template.HTMLEscapeString(name),
+			template.HTMLEscapeString(thumb),
+			template.HTMLEscapeString(name),
+			template.HTMLEscapeString(name),
+		)
+	}
+	result.WriteString("</div>\n")
+
+	if len(g.files) > 0 {
+		result.WriteString("<ul class=\"godown-gallery-files\">\n")
+		for _, name := range g.files {
+			fmt.Fprintf(&result, "<li><a href=\"%s\">%s</a></li>\n",
+				template.HTMLEscapeString(pageURL(path.Join(urlPath, name))),
+				template.HTMLEscapeString(name),
+			)
+		}
+		result.WriteString("</ul>\n")
+	}
+	return result.String()
+}
+
+// serveGallery renders a directory of images as a gallery page, reporting
+// whether the directory is one
+func serveGallery(w http.ResponseWriter, r *http.Request, dir, urlPath string) bool {
+	g, err := readGallery(dir)
+	if err != nil || !g.isGallery() {
+		return false
+	}
+	renderPage(w, PageData{
+		Title:     path.Base(urlPath),
+		Content:   template.HTML(renderGallery(g, dir, urlPath)),
+		StylePath: "/__godown_style.css",
+	})
+	return true
+}
+
+// resizeImage reduces an image to the given size, at most its own, averaging
+// the source pixels covered by each pixel of the result. The image is read
+// one row at a time.
+func resizeImage(src image.Image, width, height int) *image.RGBA {
+	bounds := src.Bounds()
+	srcWidth, srcHeight := bounds.Dx(), bounds.Dy()
+
+	// Column of the result of each source column
+	columns := make([]int, srcWidth)
+	for x := 0; x < width; x++ {
+		for sx := x * srcWidth / width; sx < (x+1)*srcWidth/width; sx++ {
+			columns[sx] = x
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	row := image.NewRGBA(image.Rect(0, 0, srcWidth, 1))
+	sums := make([]int, 4*width)
+	counts := make([]int, width)
+	for y := 0; y < height; y++ {
+		clear(sums)
+		clear(counts)
+		for sy := y * srcHeight / height; sy < (y+1)*srcHeight/height; sy++ {
+			draw.Draw(row, row.Bounds(), src, image.Pt(bounds.Min.X, bounds.Min.Y+sy), draw.Src)
+			for sx, x := range columns {
+				for c := 0; c < 4; c++ {
+					sums[4*x+c] += int(row.Pix[4*sx+c])
+				}
+				counts[x]++
+			}
+		}
+		for x := 0; x < width; x++ {
+			offset := dst.PixOffset(x, y)
+			for c := 0; c < 4; c++ {
+				dst.Pix[offset+c] = uint8(sums[4*x+c] / max(counts[x], 1))
+			}
+		}
+	}
+	return dst
+}
+
+// thumbnailDimensions returns the size of the thumbnail of an image, keeping
+// its aspect ratio
+func thumbnailDimensions(width, height int) (int, int) {
+	if width >= height {
+		return thumbnailSize, max(1, height*thumbnailSize/width)
+	}
+	return max(1, width*thumbnailSize/height), thumbnailSize
+}
+
+// makeThumbnail decodes and reduces an image: JPEG for photos, PNG for the
+// other formats keeping their transparency
+func makeThumbnail(filePath string) (*thumbnail, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	config, _, err := image.DecodeConfig(file)
+	if err != nil {
+		return nil, err
+	}
+	if config.Width*config.Height > maxThumbnailPixels {
+		return nil, fmt.Errorf("image of %dx%d pixels is too large", config.Width, config.Height)
+	}
+	if config.Width <= thumbnailSize && config.Height <= thumbnailSize {
+		// Already small enough
+		return &thumbnail{}, nil
+	}
+	if _, err := file.Seek(0, 0); err != nil {
+		return nil, err
+	}
+	src, format, err := image.Decode(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var result thumbnail
+	var buf bytes.Buffer
+	width, height := thumbnailDimensions(config.Width, config.Height)
+	if format == "jpeg" {
+		result.contentType = "image/jpeg"
+		err = jpeg.Encode(&buf, resizeImage(src, width, height), &jpeg.Options{Quality: 85})
+	} else {
+		result.contentType = "image/png"
+		err = png.Encode(&buf, resizeImage(src, width, height))
+	}
+	if err != nil {
+		return nil, err
+	}
+	result.data = buf.Bytes()
+	return &result, nil
+}
+
+// serveThumbnail serves the thumbnail of an image, made once until the image
+// changes; small or undecodable images are served as is
+func serveThumbnail(w http.ResponseWriter, r *http.Request, filePath string) {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	thumb := thumbnails.get(filePath, info)
+	if thumb.data == nil || len(thumb.data) >= int(info.Size()) {
+		serveMedia(w, r, filePath)
+		return
+	}
+
+	w.Header().Set("Content-Type", thumb.contentType)
+	http.ServeContent(w, r, filepath.Base(filePath), thumb.modTime, bytes.NewReader(thumb.data))
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// encodePNG returns a PNG image of the given size filled with a color
+func encodePNG(t *testing.T, width, height int, c color.Color) string {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+// Test the detection of directories of images
+func TestIsGalleryDir(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTree(t, tmpDir, map[string]string{
+		"shots/a.png":     "",
+		"shots/b.jpg":     "",
+		"shots/notes.txt": "",
+		"shots/.hidden":   "",
+		"half/a.png":      "",
+		"half/notes.txt":  "",
+		"docs/guide.md":   "",
+	})
+
+	tests := []struct {
+		dir      string
+		expected bool
+	}{
+		{"shots", true},
+		{"half", false},
+		{"docs", false},
+		{"missing", false},
+	}
+	for _, tt := range tests {
+		if result := isGalleryDir(filepath.Join(tmpDir, tt.dir)); result != tt.expected {
+			t.Errorf("isGalleryDir(%q) = %v, want %v", tt.dir, result, tt.expected)
+		}
+	}
+}
+
+// Test the reduction of images, averaging the pixels
+func TestResizeImage(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 4; x++ {
+			if x%2 == 0 {
+				src.Set(x, y, color.RGBA{255, 0, 0, 255})
+			} else {
+				src.Set(x, y, color.RGBA{0, 0, 255, 255})
+			}
+		}
+	}
+	result := resizeImage(src, 2, 1)
+	if result.Bounds().Dx() != 2 || result.Bounds().Dy() != 1 {
+		t.Fatalf("resizeImage() size = %v, want 2x1", result.Bounds())
+	}
+	if c := result.RGBAAt(0, 0); c != (color.RGBA{127, 0, 127, 255}) {
+		t.Errorf("resizeImage() pixel = %v, want the average of red and blue", c)
+	}
+
+	for _, tt := range []struct{ width, height, thumbWidth, thumbHeight int }{
+		{1280, 720, 320, 180},
+		{600, 1200, 160, 320},
+		{5000, 10, 320, 1},
+	} {
+		if w, h := thumbnailDimensions(tt.width, tt.height); w != tt.thumbWidth || h != tt.thumbHeight {
+			t.Errorf("thumbnailDimensions(%d, %d) = %d, %d, want %d, %d", tt.width, tt.height, w, h, tt.thumbWidth, tt.thumbHeight)
+		}
+	}
+}
+
+// Test the gallery pages and the thumbnails of their images
+func TestServeGallery(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+
+	large := encodePNG(t, 800, 400, color.RGBA{0, 128, 0, 255})
+	writeTree(t, tmpDir, map[string]string{
+		"shots/large image.png": large,
+		"shots/small.png":       encodePNG(t, 10, 10, color.White),
+		"shots/diagram.svg":     "<svg></svg>",
+		"shots/notes.txt":       "notes",
+		"shots/2024/a.png":      large,
+		"shots/empty/file.txt":  "",
+		"docs/README.md":        "# Docs",
+		"docs/a.png":            large,
+	})
+
+	tests := []struct {
+		url        string
+		expected   []string
+		unexpected []string
+	}{
+		{
+			url: "/shots",
+			expected: []string{
+				"<h1>shots</h1>",
+				"Images: 3",
+				`<a href="/shots/2024/">2024/</a>`,
+				`<a class="godown-gallery-item" href="/shots/large%20image.png" title="large image.png"><img src="/shots/large%20image.png?thumb"`,
+				`<img src="/shots/diagram.svg" alt="diagram.svg" loading="lazy">`,
+				`<a href="/shots/notes.txt">notes.txt</a>`,
+			},
+			unexpected: []string{"empty/"},
+		},
+		{
+			url:        "/docs",
+			expected:   []string{`<h1 id="docs">Docs</h1>`},
+			unexpected: []string{"godown-gallery"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			serveMarkdown(w, httptest.NewRequest("GET", tt.url, nil))
+			body := w.Body.String()
+			for _, s := range tt.expected {
+				if !strings.Contains(body, s) {
+					t.Errorf("body should contain %q, got %q", s, body)
+				}
+			}
+			for _, s := range tt.unexpected {
+				if strings.Contains(body, s) {
+					t.Errorf("body should not contain %q", s)
+				}
+			}
+		})
+	}
+
+	w := httptest.NewRecorder()
+	serveMarkdown(w, httptest.NewRequest("GET", "/shots/empty", nil))
+	if w.Code != 404 {
+		t.Errorf("directory without images status = %d, want 404", w.Code)
+	}
+
+	// Large images are reduced, small ones served as is
+	for i := 0; i < 2; i++ {
+		w = httptest.NewRecorder()
+		serveMarkdown(w, httptest.NewRequest("GET", "/shots/large%20image.png?thumb", nil))
+		img, err := png.Decode(w.Body)
+		if err != nil {
+			t.Fatalf("thumbnail is not a PNG image: %v", err)
+		}
+		if size := img.Bounds().Size(); size != image.Pt(320, 160) {
+			t.Errorf("thumbnail size = %v, want 320x160", size)
+		}
+	}
+	cached := false
+	for filePath, element := range thumbnails.entries {
+		cached = cached || strings.HasSuffix(filePath, "large image.png") && element.Value.(*thumbnail).data != nil
+	}
+	if !cached {
+		t.Errorf("thumbnail should be cached")
+	}
+
+	w = httptest.NewRecorder()
+	serveMarkdown(w, httptest.NewRequest("GET", "/shots/small.png?thumb", nil))
+	if w.Body.String() != encodePNG(t, 10, 10, color.White) {
+		t.Errorf("small image should be served as is")
+	}
+}
+
+// Test the eviction of the least recently used thumbnails
+func TestThumbnailCacheEviction(t *testing.T) {
+	tmpDir := t.TempDir()
+	content := encodePNG(t, 400, 400, color.Black)
+	writeTree(t, tmpDir, map[string]string{"a.png": content, "b.png": content, "c.png": content})
+
+	cache := newThumbnailCache(2)
+	get := func(name string) *thumbnail {
+		filePath := filepath.Join(tmpDir, name)
+		info, err := os.Stat(filePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cache.get(filePath, info)
+	}
+	a := get("a.png")
+	get("b.png")
+	if get("a.png") != a {
+		t.Errorf("thumbnail of a.png should come from the cache")
+	}
+	get("c.png")
+
+	if _, ok := cache.entries[filepath.Join(tmpDir, "b.png")]; ok {
+		t.Errorf("least recently used thumbnail b.png should be evicted")
+	}
+	if _, ok := cache.entries[filepath.Join(tmpDir, "a.png")]; !ok || cache.order.Len() != 2 {
+		t.Errorf("cache should keep a.png and c.png")
+	}
+}
+
+// Test concurrent requests for the same thumbnail
+func TestThumbnailCacheConcurrent(t *testing.T) {
+	tmpDir := t.TempDir()
+	filePath := filepath.Join(tmpDir, "a.png")
+	writeTree(t, tmpDir, map[string]string{"a.png": encodePNG(t, 400, 200, color.Black)})
+	info, err := os.Stat(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cache := newThumbnailCache(2)
+	results := make(chan *thumbnail, 8)
+	for i := 0; i < cap(results); i++ {
+		go func() { results <- cache.get(filePath, info) }()
+	}
+	first := <-results
+	for i := 1; i < cap(results); i++ {
+		if thumb := <-results; thumb != first {
+			t.Errorf("concurrent requests should share a single thumbnail")
+		}
+	}
+}
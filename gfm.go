@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+
+	"github.com/gomarkdown/markdown/ast"
+)
+
+// alertTypes are the GitHub alert blockquote markers and their titles
+var alertTypes = map[string]string{
+	"NOTE":      "Note",
+	"TIP":       "Tip",
+	"IMPORTANT": "Important",
+	"WARNING":   "Warning",
+	"CAUTION":   "Caution",
+}
+
+var (
+	alertMarker    = regexp.MustCompile(`^\[!([A-Za-z]+)\][ \t]*(\n|$)`)
+	emojiShortcode = regexp.MustCompile(`:([a-z0-9_+\-]+):`)
+	wwwAutolink    = regexp.MustCompile(`(^|[\s(*_~])(www\.[A-Za-z0-9\-]+(\.[A-Za-z0-9\-]+)+[^\s<]*)`)
+)
+
+// gfmTransform rewrites a parsed document to support the GitHub-flavored
+// Markdown extras not handled by the parser: alerts, task lists, emoji
+// shortcodes and www autolinks
+func gfmTransform(doc ast.Node) {
+	var quotes []*ast.BlockQuote
+	var items []*ast.ListItem
+	var texts []*ast.Text
+	ast.WalkFunc(doc, func(node ast.Node, entering bool) ast.WalkStatus {
+		if !entering {
+			return ast.GoToNext
+		}
+		switch n := node.(type) {
+		case *ast.BlockQuote:
+			quotes = append(quotes, n)
+		case *ast.ListItem:
+			items = append(items, n)
+		case *ast.Text:
+			texts = append(texts, n)
+		}
+		return ast.GoToNext
+	})
+
+	for _, quote := range quotes {
+		transformAlert(quote)
+	}
+	for _, item := range items {
+		transformTaskItem(item)
+	}
+	for _, text := range texts {
+		text.Literal = replaceEmoji(text.Literal)
+		if !insideLink(text) {
+			transformWWWLinks(text)
+		}
+	}
+}
+
+// firstText returns the leading text of the first paragraph of a block
+func firstText(block ast.Node) *ast.Text {
+	children := block.GetChildren()
+	if len(children) == 0 {
+		return nil
+	}
+	para, ok := children[0].(*ast.Paragraph)
+	if !ok || len(para.Children) == 0 {
+		return nil
+	}
+	text, _ := para.Children[0].(*ast.Text)
+	return text
+}
+
+// transformAlert turns a blockquote starting with [!NOTE], [!WARNING]... in
+// a titled alert
+func transformAlert(quote *ast.BlockQuote) {
+	text := firstText(quote)
+	if text == nil {
+		return
+	}
+	match := alertMarker.FindSubmatch(text.Literal)
+	if match == nil {
+		return
+	}
+	kind := strings.ToUpper(string(match[1]))
+	title, ok := alertTypes[kind]
+	if !ok {
+		return
+	}
+
+	text.Literal = text.Literal[len(match[0]):]
+	para := text.Parent.(*ast.Paragraph)
+	if len(text.Literal) == 0 && len(para.Children) == 1 {
+		// The marker was alone in its paragraph
+		ast.RemoveFromTree(para)
+	}
+
+	quote.Attribute = &ast.Attribute{Classes: [][]byte{
+		[]byte("godown-alert"),
+		[]byte("godown-alert-" + strings.ToLower(kind)),
+	}}
+	heading := &ast.Paragraph{}
+	heading.Attribute = &ast.Attribute{Classes: [][]byte{[]byte("godown-alert-title")}}
+	ast.AppendChild(heading, &ast.Text{Leaf: ast.Leaf{Literal: []byte(title)}})
+	heading.Parent = quote
+	quote.Children = append([]ast.Node{heading}, quote.Children...)
+}
+
+// transformTaskItem renders the [ ] and [x] prefixes of list items as
+// checkboxes
+func transformTaskItem(item *ast.ListItem) {
+	text := firstText(item)
+	if text == nil {
+		return
+	}
+	for _, marker := range []string{"[ ]", "[x]", "[X]"} {
+		rest, ok := bytes.CutPrefix(text.Literal, []byte(marker))
+		if !ok || (len(rest) > 0 && rest[0] != ' ') {
+			continue
+		}
+		checked := ""
+		if marker != "[ ]" {
+			checked = " checked"
+		}
+		text.Literal = bytes.TrimPrefix(rest, []byte(" "))
+		checkbox := &ast.HTMLSpan{Leaf: ast.Leaf{Literal: []byte("<input type=\"checkbox\" class=\"godown-task\" disabled" + checked + "> ")}}
+		insertBefore(text, checkbox)
+		return
+	}
+}
+
+// replaceEmoji replaces the known :shortcode: emoji of a text
+func replaceEmoji(text []byte) []byte {
+	if bytes.IndexByte(text, ':') < 0 {
+		return text
+	}
+	return emojiShortcode.ReplaceAllFunc(text, func(code []byte) []byte {
+		if emoji, ok := emojiShortcodes[string(code[1:len(code)-1])]; ok {
+			return []byte(emoji)
+		}
+		return code
+	})
+}
+
+// insideLink checks if a node is part of a link
+func insideLink(node ast.Node) bool {
+	for parent := node.GetParent(); parent != nil; parent = parent.GetParent() {
+		if _, ok := parent.(*ast.Link); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// transformWWWLinks links the bare www.example.com addresses of a text,
+// the parser only detecting addresses with a scheme
+func transformWWWLinks(text *ast.Text) {
+	matches := wwwAutolink.FindAllSubmatchIndex(text.Literal, -1)
+	if matches == nil {
+		return
+	}
+
+	literal := text.Literal
+	var nodes []ast.Node
+	last := 0
+	for _, m := range matches {
+		start, end := m[4], m[5]
+		// Trailing punctuation is not part of the address
+		end = start + len(bytes.TrimRight(literal[start:end], ".,:;!?\"')*_~"))
+		nodes = append(nodes, &ast.Text{Leaf: ast.Leaf{Literal: literal[last:start]}})
+		link := &ast.Link{Destination: append([]byte("http://"), literal[start:end]...)}
+		ast.AppendChild(link, &ast.Text{Leaf: ast.Leaf{Literal: literal[start:end]}})
+		nodes = append(nodes, link)
+		last = end
+	}
+	nodes = append(nodes, &ast.Text{Leaf: ast.Leaf{Literal: literal[last:]}})
+
+	for _, node := range nodes {
+		insertBefore(text, node)
+	}
+	ast.RemoveFromTree(text)
+}
+
+// insertBefore inserts a node before a sibling
+func insertBefore(sibling, node ast.Node) {
+	parent := sibling.GetParent()
+	children := parent.GetChildren()
+	for i, child := range children {
+		if child == sibling {
+			children = append(children[:i], append([]ast.Node{node}, children[i:]...)...)
+			break
+		}
+	}
+	node.SetParent(parent)
+	parent.SetChildren(children)
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// Test GitHub-flavored Markdown extras
+func TestMdToHTMLGFM(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		contains   []string
+		unexpected []string
+	}{
+		{
+			name:     "Note alert",
+			input:    "> [!NOTE]\n> Useful information",
+			contains: []string{`<blockquote class="godown-alert godown-alert-note">`, `<p class="godown-alert-title">Note</p>`, "<p>Useful information</p>"},
+		},
+		{
+			name:       "Warning alert",
+			input:      "> [!WARNING]\n>\n> Be careful",
+			contains:   []string{`class="godown-alert godown-alert-warning"`, ">Warning</p>", "<p>Be careful</p>"},
+			unexpected: []string{"[!WARNING]", "<p></p>"},
+		},
+		{
+			name:       "Unknown alert",
+			input:      "> [!UNKNOWN]\n> Text",
+			contains:   []string{"<blockquote>", "[!UNKNOWN]"},
+			unexpected: []string{"godown-alert"},
+		},
+		{
+			name:  "Task list",
+			input: "- [ ] todo\n- [x] done\n- [link](page.md)\n- [X]",
+			contains: []string{
+				`<li><input type="checkbox" class="godown-task" disabled> todo</li>`,
+				`<li><input type="checkbox" class="godown-task" disabled checked> done</li>`,
+				`<li><a href="page.md"`,
+				`<li><input type="checkbox" class="godown-task" disabled checked> </li>`,
+			},
+		},
+		{
+			name:     "Footnote",
+			input:    "Text[^note]\n\n[^note]: The note.",
+			contains: []string{`<sup class="footnote-ref" id="fnref:note"><a href="#fn:note">1</a></sup>`, `<li id="fn:note">The note. <a class="footnote-return" href="#fnref:note">&#8617;</a></li>`},
+		},
+		{
+			name:       "Emoji",
+			input:      "Done :tada: :+1: :not_an_emoji: `:smile:`",
+			contains:   []string{"Done 🎉 👍 :not_an_emoji:", "<code>:smile:</code>"},
+			unexpected: []string{"😄"},
+		},
+		{
+			name:     "Bare URLs",
+			input:    "See https://example.com/docs, www.example.org. or (www.example.net/a?b=1)",
+			contains: []string{`<a href="https://example.com/docs">https://example.com/docs</a>,`, `<a href="http://www.example.org">www.example.org</a>.`, `(<a href="http://www.example.net/a?b=1">www.example.net/a?b=1</a>)`},
+		},
+		{
+			name:       "URL in link",
+			input:      "[www.example.org](https://example.org)",
+			contains:   []string{`<a href="https://example.org">www.example.org</a>`},
+			unexpected: []string{"http://www"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := string(mdToHTML([]byte(tt.input)))
+			for _, expected := range tt.contains {
+				if !strings.Contains(result, expected) {
+					t.Errorf("mdToHTML() should contain %q, got:\n%s", expected, result)
+				}
+			}
+			for _, unexpected := range tt.unexpected {
+				if strings.Contains(result, unexpected) {
+					t.Errorf("mdToHTML() should not contain %q, got:\n%s", unexpected, result)
+				}
+			}
+		})
+	}
+}
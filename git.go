@@ -0,0 +1,813 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Git object types, as stored in pack files
+const (
+	gitObjCommit   = 1
+	gitObjTree     = 2
+	gitObjBlob     = 3
+	gitObjTag      = 4
+	gitObjOfsDelta = 6
+	gitObjRefDelta = 7
+)
+
+// gitCacheSize bounds the number of decoded objects kept in memory
+const gitCacheSize = 4096
+
+var errGitObjectNotFound = errors.New("git object not found")
+
+// gitHash is a SHA-1 object name
+type gitHash [20]byte
+
+func (h gitHash) String() string {
+	return hex.EncodeToString(h[:])
+}
+
+// Short returns the abbreviated object name
+func (h gitHash) Short() string {
+	return h.String()[:7]
+}
+
+// parseGitHash decodes a 40 characters hexadecimal object name
+func parseGitHash(s string) (gitHash, error) {
+	var h gitHash
+	s = strings.TrimSpace(s)
+	if len(s) != 40 {
+		return h, fmt.Errorf("invalid object name %q", s)
+	}
+	if _, err := hex.Decode(h[:], []byte(s)); err != nil {
+		return h, fmt.Errorf("invalid object name %q: %w", s, err)
+	}
+	return h, nil
+}
+
+// gitObject is a decoded (inflated and undeltified) git object
+type gitObject struct {
+	typ  int
+	data []byte
+}
+
+// gitSignature is the author or committer of a commit
+type gitSignature struct {
+	Name  string
+	Email string
+	When  time.Time
+}
+
+// gitCommit holds the fields of a commit object used by godown
+type gitCommit struct {
+	Hash    gitHash
+	Tree    gitHash
+	Parents []gitHash
+	Author  gitSignature
+	Message string
+}
+
+// Subject returns the first line of the commit message
+func (c *gitCommit) Subject() string {
+	subject, _, _ := strings.Cut(strings.TrimSpace(c.Message), "\n")
+	return subject
+}
+
+// gitPack is a pack file and its version 2 index
+type gitPack struct {
+	path  string // Path of the index
+	file  *os.File
+	idx   []byte
+	count int
+
+	mu    sync.Mutex
+	cache map[int64]*gitObject // Objects decoded from this pack, by offset
+}
+
+// gitRepo reads a local git repository directly from its .git directory
+type gitRepo struct {
+	workTree  string // Top-level directory of the working tree
+	gitDir    string // Directory holding HEAD
+	commonDir string // Directory holding objects and refs (differs for worktrees)
+
+	mu        sync.Mutex
+	packs     []*gitPack
+	packsTime time.Time // Modification time of the pack directory when loaded
+	loaded    bool
+	objects   map[gitHash]*gitObject
+
+	cacheHead  gitHash
+	lastCache  map[string]*gitCommit
+	firstCache map[string]*gitCommit
+}
+
+// siteRepo is the git repository containing the current directory (nil outside git)
+var siteRepo *gitRepo
+
+// openGitRepo finds the git repository containing dir, returning nil when
+// dir is not inside a repository
+func openGitRepo(dir string) *gitRepo {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil
+	}
+
+	for {
+		dotGit := filepath.Join(abs, ".git")
+		if info, err := os.Stat(dotGit); err == nil {
+			gitDir := dotGit
+			if !info.IsDir() {
+				// Worktrees and submodules use a "gitdir: <path>" file
+				content, err := os.ReadFile(dotGit)
+				if err != nil {
+					return nil
+				}
+				target, ok := strings.CutPrefix(strings.TrimSpace(string(content)), "gitdir: ")
+				if !ok {
+					return nil
+				}
+				if !filepath.IsAbs(target) {
+					target = filepath.Join(abs, target)
+				}
+				gitDir = target
+			}
+
+			commonDir := gitDir
+			if content, err := os.ReadFile(filepath.Join(gitDir, "commondir")); err == nil {
+				commonDir = strings.TrimSpace(string(content))
+				if !filepath.IsAbs(commonDir) {
+					commonDir = filepath.Join(gitDir, commonDir)
+				}
+			}
+
+			return &gitRepo{
+				workTree:   abs,
+				gitDir:     gitDir,
+				commonDir:  commonDir,
+				objects:    map[gitHash]*gitObject{},
+				lastCache:  map[string]*gitCommit{},
+				firstCache: map[string]*gitCommit{},
+			}
+		}
+
+		parent := filepath.Dir(abs)
+		if parent == abs {
+			return nil
+		}
+		abs = parent
+	}
+}
+
+// head resolves the commit checked out in the working tree
+func (r *gitRepo) head() (gitHash, error) {
+	content, err := os.ReadFile(filepath.Join(r.gitDir, "HEAD"))
+	if err != nil {
+		return gitHash{}, err
+	}
+	line := strings.TrimSpace(string(content))
+	if ref, ok := strings.CutPrefix(line, "ref: "); ok {
+		return r.resolveRef(ref)
+	}
+	return parseGitHash(line)
+}
+
+// resolveRef resolves a full reference name (e.g. refs/heads/main) from
+// loose refs, then from packed refs
+func (r *gitRepo) resolveRef(ref string) (gitHash, error) {
+	for _, dir := range []string{r.gitDir, r.commonDir} {
+		content, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(ref)))
+		if err == nil {
+			line := strings.TrimSpace(string(content))
+			if target, ok := strings.CutPrefix(line, "ref: "); ok {
+				return r.resolveRef(target)
+			}
+			return parseGitHash(line)
+		}
+	}
+
+	file, err := os.Open(filepath.Join(r.commonDir, "packed-refs"))
+	if err != nil {
+		return gitHash{}, fmt.Errorf("unknown ref %s", ref)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		hash, name, ok := strings.Cut(scanner.Text(), " ")
+		if ok && name == ref {
+			return parseGitHash(hash)
+		}
+	}
+	return gitHash{}, fmt.Errorf("unknown ref %s", ref)
+}
+
+// readObject returns a decoded object, looking in the cache, the pack files
+// and the loose objects
+func (r *gitRepo) readObject(h gitHash) (*gitObject, error) {
+	r.mu.Lock()
+	if obj, ok := r.objects[h]; ok {
+		r.mu.Unlock()
+		return obj, nil
+	}
+	if !r.loaded {
+		r.loadPacks()
+	}
+	packs := r.packs
+	r.mu.Unlock()
+
+	obj, err := r.readPacked(packs, h)
+	if errors.Is(err, errGitObjectNotFound) {
+		obj, err = r.readLoose(h)
+	}
+	if errors.Is(err, errGitObjectNotFound) {
+		// The object may have been packed since the packs were loaded
+		r.mu.Lock()
+		r.loadPacks()
+		packs = r.packs
+		r.mu.Unlock()
+		obj, err = r.readPacked(packs, h)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	if len(r.objects) >= gitCacheSize {
+		r.objects = map[gitHash]*gitObject{}
+	}
+	r.objects[h] = obj
+	r.mu.Unlock()
+	return obj, nil
+}
+
+// loadPacks opens the index of the pack files added since the last call,
+// when the pack directory changed (r.mu must be held). The slice is replaced
+// rather than modified and the packs are not closed, as readers may still
+// use the previous slice: the files of removed packs are closed by the
+// garbage collector once unused.
+func (r *gitRepo) loadPacks() {
+	dir := filepath.Join(r.commonDir, "objects", "pack")
+	info, err := os.Stat(dir)
+	if r.loaded && (err != nil || info.ModTime().Equal(r.packsTime)) {
+		return
+	}
+	r.loaded = true
+	if err == nil {
+		r.packsTime = info.ModTime()
+	}
+
+	opened := map[string]*gitPack{}
+	for _, pack := range r.packs {
+		opened[pack.path] = pack
+	}
+	var packs []*gitPack
+	indexes, _ := filepath.Glob(filepath.Join(dir, "*.idx"))
+	for _, idxPath := range indexes {
+		pack, ok := opened[idxPath]
+		if !ok {
+			if pack, err = openGitPack(idxPath); err != nil {
+				continue
+			}
+		}
+		packs = append(packs, pack)
+	}
+	r.packs = packs
+}
+
+// openGitPack reads a version 2 pack index and opens the matching pack
+func openGitPack(idxPath string) (*gitPack, error) {
+	idx, err := os.ReadFile(idxPath)
+	if err != nil {
+		return nil, err
+	}
+	if len(idx) < 8+256*4 || !bytes.Equal(idx[:8], []byte{0xff, 't', 'O', 'c', 0, 0, 0, 2}) {
+		return nil, fmt.Errorf("unsupported pack index %s", idxPath)
+	}
+
+	file, err := os.Open(strings.TrimSuffix(idxPath, ".idx") + ".pack")
+	if err != nil {
+		return nil, err
+	}
+
+	return &gitPack{
+		path:  idxPath,
+		file:  file,
+		idx:   idx,
+		count: int(binary.BigEndian.Uint32(idx[8+255*4:])),
+		cache: map[int64]*gitObject{},
+	}, nil
+}
+
+// readPacked looks for an object in the pack files
+func (r *gitRepo) readPacked(packs []*gitPack, h gitHash) (*gitObject, error) {
+	for _, pack := range packs {
+		if offset, ok := pack.find(h); ok {
+			return r.readPackObject(pack, offset)
+		}
+	}
+	return nil, errGitObjectNotFound
+}
+
+// find returns the offset of an object in the pack using the index fanout
+// table and a binary search on the sorted object names
+func (p *gitPack) find(h gitHash) (int64, bool) {
+	const fanout = 8
+	lo := 0
+	if h[0] > 0 {
+		lo = int(binary.BigEndian.Uint32(p.idx[fanout+(int(h[0])-1)*4:]))
+	}
+	hi := int(binary.BigEndian.Uint32(p.idx[fanout+int(h[0])*4:]))
+
+	names := fanout + 256*4
+	i := lo + sort.Search(hi-lo, func(i int) bool {
+		start := names + (lo+i)*20
+		return bytes.Compare(p.idx[start:start+20], h[:]) >= 0
+	})
+	if i >= hi || !bytes.Equal(p.idx[names+i*20:names+i*20+20], h[:]) {
+		return 0, false
+	}
+
+	offsets := names + p.count*20 + p.count*4
+	offset := binary.BigEndian.Uint32(p.idx[offsets+i*4:])
+	if offset&0x80000000 == 0 {
+		return int64(offset), true
+	}
+
+	large := offsets + p.count*4 + int(offset&0x7fffffff)*8
+	return int64(binary.BigEndian.Uint64(p.idx[large:])), true
+}
+
+// readPackObject decodes the object stored at the given offset of a pack,
+// applying delta chains
+func (r *gitRepo) readPackObject(pack *gitPack, offset int64) (*gitObject, error) {
+	pack.mu.Lock()
+	obj, ok := pack.cache[offset]
+	pack.mu.Unlock()
+	if ok {
+		return obj, nil
+	}
+
+	header := make([]byte, 64)
+	n, err := pack.file.ReadAt(header, offset)
+	if n == 0 {
+		return nil, err
+	}
+	header = header[:n]
+
+	// Type and inflated size
+	pos := 0
+	b := header[pos]
+	pos++
+	typ := int(b>>4) & 7
+	size := int64(b & 0x0f)
+	for shift := 4; b&0x80 != 0 && pos < len(header); shift += 7 {
+		b = header[pos]
+		pos++
+		size |= int64(b&0x7f) << shift
+	}
+
+	var base *gitObject
+	switch typ {
+	case gitObjOfsDelta:
+		b = header[pos]
+		pos++
+		rel := int64(b & 0x7f)
+		for b&0x80 != 0 && pos < len(header) {
+			b = header[pos]
+			pos++
+			rel = ((rel + 1) << 7) | int64(b&0x7f)
+		}
+		base, err = r.readPackObject(pack, offset-rel)
+	case gitObjRefDelta:
+		var h gitHash
+		copy(h[:], header[pos:pos+20])
+		pos += 20
+		base, err = r.readObject(h)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	zr, err := zlib.NewReader(io.NewSectionReader(pack.file, offset+int64(pos), 1<<62))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	data := make([]byte, size)
+	if _, err := io.ReadFull(zr, data); err != nil {
+		return nil, err
+	}
+
+	if base != nil {
+		data, err = applyGitDelta(base.data, data)
+		if err != nil {
+			return nil, err
+		}
+		typ = base.typ
+	}
+	obj = &gitObject{typ: typ, data: data}
+
+	pack.mu.Lock()
+	if len(pack.cache) >= gitCacheSize {
+		pack.cache = map[int64]*gitObject{}
+	}
+	pack.cache[offset] = obj
+	pack.mu.Unlock()
+	return obj, nil
+}
+
+// applyGitDelta rebuilds an object from its base and a delta
+func applyGitDelta(base, delta []byte) ([]byte, error) {
+	errCorrupt := errors.New("corrupt git delta")
+
+	varint := func() (int, error) {
+		value, shift := 0, 0
+		for {
+			if len(delta) == 0 {
+				return 0, errCorrupt
+			}
+			b := delta[0]
+			delta = delta[1:]
+			value |= int(b&0x7f) << shift
+			shift += 7
+			if b&0x80 == 0 {
+				return value, nil
+			}
+		}
+	}
+
+	baseSize, err := varint()
+	if err != nil || baseSize != len(base) {
+		return nil, errCorrupt
+	}
+	targetSize, err := varint()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]byte, 0, targetSize)
+	for len(delta) > 0 {
+		op := delta[0]
+		delta = delta[1:]
+
+		if op&0x80 == 0 {
+			// Insert the next op bytes
+			if op == 0 || int(op) > len(delta) {
+				return nil, errCorrupt
+			}
+			result = append(result, delta[:op]...)
+			delta = delta[op:]
+			continue
+		}
+
+		// Copy a range of the base
+		var offset, size int
+		for i := 0; i < 4; i++ {
+			if op&(1<<i) != 0 {
+				if len(delta) == 0 {
+					return nil, errCorrupt
+				}
+				offset |= int(delta[0]) << (8 * i)
+				delta = delta[1:]
+			}
+		}
+		for i := 0; i < 3; i++ {
+			if op&(0x10<<i) != 0 {
+				if len(delta) == 0 {
+					return nil, errCorrupt
+				}
+				size |= int(delta[0]) << (8 * i)
+				delta = delta[1:]
+			}
+		}
+		if size == 0 {
+			size = 0x10000
+		}
+		if offset+size > len(base) {
+			return nil, errCorrupt
+		}
+		result = append(result, base[offset:offset+size]...)
+	}
+
+	if len(result) != targetSize {
+		return nil, errCorrupt
+	}
+	return result, nil
+}
+
+// readLoose reads a zlib-compressed loose object
+func (r *gitRepo) readLoose(h gitHash) (*gitObject, error) {
+	name := h.String()
+	file, err := os.Open(filepath.Join(r.commonDir, "objects", name[:2], name[2:]))
+	if err != nil {
+		return nil, errGitObjectNotFound
+	}
+	defer file.Close()
+
+	zr, err := zlib.NewReader(file)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	content, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, err
+	}
+
+	header, data, ok := bytes.Cut(content, []byte{0})
+	if !ok {
+		return nil, fmt.Errorf("corrupt loose object %s", name)
+	}
+	kind, _, _ := strings.Cut(string(header), " ")
+	types := map[string]int{"commit": gitObjCommit, "tree": gitObjTree, "blob": gitObjBlob, "tag": gitObjTag}
+	typ, ok := types[kind]
+	if !ok {
+		return nil, fmt.Errorf("unknown object type %q", kind)
+	}
+	return &gitObject{typ: typ, data: data}, nil
+}
+
+// readCommit reads and parses a commit object
+func (r *gitRepo) readCommit(h gitHash) (*gitCommit, error) {
+	obj, err := r.readObject(h)
+	if err != nil {
+		return nil, err
+	}
+	if obj.typ != gitObjCommit {
+		return nil, fmt.Errorf("object %s is not a commit", h)
+	}
+
+	commit := &gitCommit{Hash: h}
+	headers, message, _ := strings.Cut(string(obj.data), "\n\n")
+	commit.Message = message
+	for _, line := range strings.Split(headers, "\n") {
+		key, value, _ := strings.Cut(line, " ")
+		switch key {
+		case "tree":
+			commit.Tree, err = parseGitHash(value)
+		case "parent":
+			var parent gitHash
+			parent, err = parseGitHash(value)
+			commit.Parents = append(commit.Parents, parent)
+		case "author":
+			commit.Author = parseGitSignature(value)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return commit, nil
+}
+
+// parseGitSignature parses "Name <email> timestamp timezone"
+func parseGitSignature(value string) gitSignature {
+	var sig gitSignature
+	name, rest, ok := strings.Cut(value, " <")
+	if !ok {
+		sig.Name = value
+		return sig
+	}
+	sig.Name = name
+	email, rest, _ := strings.Cut(rest, "> ")
+	sig.Email = email
+
+	fields := strings.Fields(rest)
+	if len(fields) < 1 {
+		return sig
+	}
+	seconds, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return sig
+	}
+	sig.When = time.Unix(seconds, 0).UTC()
+	if len(fields) > 1 && len(fields[1]) == 5 {
+		hours, _ := strconv.Atoi(fields[1][1:3])
+		minutes, _ := strconv.Atoi(fields[1][3:5])
+		offset := hours*3600 + minutes*60
+		if fields[1][0] == '-' {
+			offset = -offset
+		}
+		sig.When = sig.When.In(time.FixedZone(fields[1], offset))
+	}
+	return sig
+}
+
+// treeEntry returns the object name of a slash-separated path in a tree
+func (r *gitRepo) treeEntry(tree gitHash, rel string) (gitHash, bool, error) {
+	current := tree
+	for _, name := range strings.Split(rel, "/") {
+		obj, err := r.readObject(current)
+		if err != nil {
+			return gitHash{}, false, err
+		}
+		if obj.typ != gitObjTree {
+			return gitHash{}, false, nil
+		}
+
+		found := false
+		for data := obj.data; len(data) > 0; {
+			// Entry format: "<mode> <name>\0<20 bytes hash>"
+			header, rest, ok := bytes.Cut(data, []byte{0})
+			if !ok || len(rest) < 20 {
+				return gitHash{}, false, fmt.Errorf("corrupt tree %s", current)
+			}
+			_, entryName, _ := bytes.Cut(header, []byte(" "))
+			if string(entryName) == name {
+				copy(current[:], rest[:20])
+				found = true
+				break
+			}
+			data = rest[20:]
+		}
+		if !found {
+			return gitHash{}, false, nil
+		}
+	}
+	return current, true, nil
+}
+
+// fileHistory returns the commits changing a file (slash-separated path
+// relative to the working tree), newest first. Like "git log -- <path>", it
+// only follows a parent whose version of the file is unchanged.
+func (r *gitRepo) fileHistory(rel string, limit int) ([]*gitCommit, error) {
+	head, err := r.head()
+	if err != nil {
+		return nil, err
+	}
+
+	var history []*gitCommit
+	commit, err := r.readCommit(head)
+	if err != nil {
+		return nil, err
+	}
+	entry, exists, err := r.treeEntry(commit.Tree, rel)
+	if err != nil {
+		return nil, err
+	}
+
+	for exists && (limit <= 0 || len(history) < limit) {
+		var next *gitCommit
+		var nextEntry gitHash
+		var nextExists bool
+
+		for i, parentHash := range commit.Parents {
+			parent, err := r.readCommit(parentHash)
+			if err != nil {
+				// Missing parents (shallow clones) end the history
+				continue
+			}
+			parentEntry, parentExists, err := r.treeEntry(parent.Tree, rel)
+			if err != nil {
+				return nil, err
+			}
+			if parentExists && parentEntry == entry {
+				// Unchanged in this parent: the change comes from its history
+				next, nextEntry, nextExists = parent, parentEntry, true
+				break
+			}
+			if i == 0 {
+				next, nextEntry, nextExists = parent, parentEntry, parentExists
+			}
+		}
+
+		if next == nil || !nextExists || nextEntry != entry {
+			history = append(history, commit)
+		}
+		if next == nil {
+			break
+		}
+		commit, entry, exists = next, nextEntry, nextExists
+	}
+	return history, nil
+}
+
+// lastCommit returns the last commit changing a file, cached until HEAD moves
+func (r *gitRepo) lastCommit(rel string) (*gitCommit, error) {
+	return r.cachedCommit(rel, false)
+}
+
+// firstCommit returns the commit adding a file, cached until HEAD moves
+func (r *gitRepo) firstCommit(rel string) (*gitCommit, error) {
+	return r.cachedCommit(rel, true)
+}
+
+// cachedCommit returns the last or the first commit changing a file. The
+// whole history is only walked for the first one, which also gives the last.
+func (r *gitRepo) cachedCommit(rel string, first bool) (*gitCommit, error) {
+	head, err := r.head()
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	if head != r.cacheHead {
+		r.cacheHead = head
+		r.lastCache = map[string]*gitCommit{}
+		r.firstCache = map[string]*gitCommit{}
+	}
+	cache := r.lastCache
+	if first {
+		cache = r.firstCache
+	}
+	commit, ok := cache[rel]
+	r.mu.Unlock()
+	if ok {
+		return commit, nil
+	}
+
+	limit := 1
+	if first {
+		limit = 0
+	}
+	history, err := r.fileHistory(rel, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	var last *gitCommit
+	if len(history) > 0 {
+		last, commit = history[0], history[len(history)-1]
+	}
+	r.mu.Lock()
+	r.lastCache[rel] = last
+	if first {
+		r.firstCache[rel] = commit
+	}
+	r.mu.Unlock()
+	return commit, nil
+}
+
+// relPath returns the slash-separated path of a file relative to the
+// working tree, or false if it is outside
+func (r *gitRepo) relPath(filePath string) (string, bool) {
+	abs, err := filepath.Abs(filePath)
+	if err != nil {
+		return "", false
+	}
+	rel, err := filepath.Rel(r.workTree, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
+
+// lastModified returns the date of the last commit changing a file when it
+// is tracked by git, else its modification time
+func lastModified(repo *gitRepo, filePath string) (time.Time, bool) {
+	if repo != nil {
+		if rel, ok := repo.relPath(filePath); ok {
+			commit, err := repo.lastCommit(rel)
+			if err == nil && commit != nil {
+				return commit.Author.When, true
+			}
+		}
+	}
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return info.ModTime(), true
+}
+
+// pageMetadata renders the last update of a file: its last commit when it
+// is tracked by git, else its modification time
+func pageMetadata(repo *gitRepo, filePath string) template.HTML {
+	if repo != nil {
+		if rel, ok := repo.relPath(filePath); ok {
+			commit, err := repo.lastCommit(rel)
+			if err == nil && commit != nil {
+				return template.HTML(fmt.Sprintf(
+					"Last updated on <time datetime=\"%s\">%s</time> by %s (<code>%s</code>) &middot; <a href=\"?history\">History</a>",
+					commit.Author.When.Format(time.RFC3339),
+					commit.Author.When.Format("2006-01-02"),
+					template.HTMLEscapeString(commit.Author.Name),
+					commit.Hash.Short(),
+				))
+			}
+		}
+	}
+
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return ""
+	}
+	return template.HTML(fmt.Sprintf(
+		"Last modified on <time datetime=\"%s\">%s</time>",
+		info.ModTime().Format(time.RFC3339),
+		info.ModTime().Format("2006-01-02"),
+	))
+}
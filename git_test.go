@@ -0,0 +1,229 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// gitFixture creates a git repository with a small history, skipping the
+// test when the git binary is not available
+func gitFixture(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git binary not available")
+	}
+
+	dir := t.TempDir()
+	run := func(date string, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=Alice", "GIT_AUTHOR_EMAIL=alice@example.com",
+			"GIT_COMMITTER_NAME=Alice", "GIT_COMMITTER_EMAIL=alice@example.com",
+			"GIT_AUTHOR_DATE="+date, "GIT_COMMITTER_DATE="+date,
+			"GIT_CONFIG_GLOBAL=/dev/null", "GIT_CONFIG_NOSYSTEM=1",
+		)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	commit := func(date, message string, files map[string]string) {
+		t.Helper()
+		writeTree(t, dir, files)
+		run(date, "add", "-A")
+		run(date, "commit", "-q", "-m", message)
+	}
+
+	run("2024-01-01T00:00:00Z", "init", "-q", "-b", "main")
+	commit("2024-01-01T10:00:00+02:00", "Add docs", map[string]string{
+		"README.md":     "# Home\n",
+		"docs/guide.md": "# Guide\n\nFirst version\n",
+	})
+	commit("2024-02-01T10:00:00Z", "Update guide", map[string]string{
+		"docs/guide.md": "# Guide\n\nSecond version\n",
+	})
+	run("2024-03-01T10:00:00Z", "checkout", "-q", "-b", "topic")
+	commit("2024-03-01T10:00:00Z", "Topic change", map[string]string{
+		"docs/guide.md": "# Guide\n\nThird version\n",
+	})
+	run("2024-03-02T10:00:00Z", "checkout", "-q", "main")
+	commit("2024-03-02T10:00:00Z", "Unrelated change", map[string]string{
+		"other.md": "# Other\n",
+	})
+	run("2024-03-03T10:00:00Z", "merge", "-q", "--no-ff", "-m", "Merge topic", "topic")
+	return dir
+}
+
+// commitSubjects lists the subjects of commits
+func commitSubjects(commits []*gitCommit) string {
+	var subjects []string
+	for _, commit := range commits {
+		subjects = append(subjects, commit.Subject())
+	}
+	return strings.Join(subjects, "|")
+}
+
+// Test reading history from loose objects and from pack files
+func TestGitFileHistory(t *testing.T) {
+	dir := gitFixture(t)
+
+	check := func(t *testing.T) {
+		repo := openGitRepo(filepath.Join(dir, "docs"))
+		if repo == nil {
+			t.Fatal("openGitRepo() should find the repository from a subdirectory")
+		}
+
+		history, err := repo.fileHistory("docs/guide.md", 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := commitSubjects(history); got != "Topic change|Update guide|Add docs" {
+			t.Errorf("fileHistory(docs/guide.md) = %v", got)
+		}
+
+		history, err = repo.fileHistory("README.md", 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := commitSubjects(history); got != "Add docs" {
+			t.Errorf("fileHistory(README.md) = %v", got)
+		}
+
+		history, err = repo.fileHistory("missing.md", 0)
+		if err != nil || len(history) != 0 {
+			t.Errorf("fileHistory(missing.md) = %v, %v", history, err)
+		}
+
+		commit, err := repo.lastCommit("README.md")
+		if err != nil || commit == nil {
+			t.Fatalf("lastCommit() = %v, %v", commit, err)
+		}
+		if commit.Author.Name != "Alice" || commit.Author.Email != "alice@example.com" {
+			t.Errorf("author = %+v", commit.Author)
+		}
+		want := time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC)
+		if !commit.Author.When.Equal(want) {
+			t.Errorf("author date = %v, want %v", commit.Author.When, want)
+		}
+		if _, offset := commit.Author.When.Zone(); offset != 2*3600 {
+			t.Errorf("author timezone offset = %d, want 7200", offset)
+		}
+	}
+
+	t.Run("Loose objects", check)
+
+	cmd := exec.Command("git", "gc", "-q", "--aggressive")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git gc: %v\n%s", err, out)
+	}
+	if loose, _ := filepath.Glob(filepath.Join(dir, ".git", "refs", "heads", "*")); len(loose) != 0 {
+		t.Fatalf("git gc should pack refs, found %v", loose)
+	}
+	t.Run("Pack files", check)
+}
+
+// Test that new packs are added without closing the packs in use
+func TestGitLoadPacks(t *testing.T) {
+	dir := gitFixture(t)
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=Bob", "GIT_AUTHOR_EMAIL=bob@example.com",
+			"GIT_COMMITTER_NAME=Bob", "GIT_COMMITTER_EMAIL=bob@example.com",
+			"GIT_CONFIG_GLOBAL=/dev/null", "GIT_CONFIG_NOSYSTEM=1",
+		)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	git("gc", "-q")
+
+	repo := openGitRepo(dir)
+	if _, err := repo.fileHistory("README.md", 0); err != nil {
+		t.Fatal(err)
+	}
+	old := repo.packs
+	if len(old) != 1 {
+		t.Fatalf("packs = %d, want 1", len(old))
+	}
+
+	// Unchanged pack directory: nothing is read again
+	repo.mu.Lock()
+	repo.loadPacks()
+	same := len(repo.packs) == 1 && repo.packs[0] == old[0]
+	repo.mu.Unlock()
+	if !same {
+		t.Errorf("loadPacks() should keep the packs of an unchanged directory")
+	}
+
+	writeTree(t, dir, map[string]string{"new.md": "# New\n"})
+	git("add", "-A")
+	git("commit", "-q", "-m", "Add new page")
+	git("repack", "-q", "-d")
+
+	history, err := repo.fileHistory("new.md", 0)
+	if err != nil || commitSubjects(history) != "Add new page" {
+		t.Fatalf("fileHistory(new.md) = %v, %v", commitSubjects(history), err)
+	}
+	if len(repo.packs) != 2 {
+		t.Errorf("packs = %d, want 2", len(repo.packs))
+	}
+	if _, err := old[0].file.Stat(); err != nil {
+		t.Errorf("loaded packs should stay open: %v", err)
+	}
+}
+
+// Test delta decoding
+func TestApplyGitDelta(t *testing.T) {
+	base := []byte("Hello world")
+	// base size 11, target size 12, copy 6 bytes at offset 0 (0x90: only the
+	// size byte follows), insert the 6 bytes "there!"
+	delta := []byte{11, 12, 0x90, 6, 6, 't', 'h', 'e', 'r', 'e', '!'}
+
+	result, err := applyGitDelta(base, delta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(result) != "Hello there!" {
+		t.Errorf("applyGitDelta() = %q, want %q", result, "Hello there!")
+	}
+
+	if _, err := applyGitDelta([]byte("short"), delta); err == nil {
+		t.Errorf("applyGitDelta() should reject a base of the wrong size")
+	}
+}
+
+// Test page metadata rendering
+func TestPageMetadata(t *testing.T) {
+	dir := gitFixture(t)
+
+	oldRepo := siteRepo
+	defer func() { siteRepo = oldRepo }()
+
+	siteRepo = openGitRepo(dir)
+	html := string(pageMetadata(siteRepo, filepath.Join(dir, "docs", "guide.md")))
+	if !strings.Contains(html, "by Alice") || !strings.Contains(html, ">2024-03-01</time>") {
+		t.Errorf("pageMetadata() should show the last commit, got %s", html)
+	}
+
+	// Untracked files and files outside git fall back to the modification time
+	writeTree(t, dir, map[string]string{"new.md": "# New"})
+	html = string(pageMetadata(siteRepo, filepath.Join(dir, "new.md")))
+	if !strings.HasPrefix(html, "Last modified on") {
+		t.Errorf("pageMetadata() should fall back to mtime for untracked files, got %s", html)
+	}
+
+	siteRepo = nil
+	html = string(pageMetadata(siteRepo, filepath.Join(dir, "README.md")))
+	if !strings.HasPrefix(html, "Last modified on") {
+		t.Errorf("pageMetadata() should fall back to mtime outside git, got %s", html)
+	}
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxHistorySize bounds the number of commits listed in the history view
+const maxHistorySize = 500
+
+// serveGitView serves the history (?history), revision (?rev=<sha>) and
+// diff (?from=<sha>&to=<sha>) views of a file, returning false when none
+// is requested
+func serveGitView(w http.ResponseWriter, r *http.Request, repo *gitRepo, filePath string) bool {
+	query := r.URL.Query()
+	_, history := query["history"]
+	rev := query.Get("rev")
+	from := query.Get("from")
+	if !history && rev == "" && from == "" {
+		return false
+	}
+
+	if repo == nil {
+		http.Error(w, "Not a git repository", http.StatusNotFound)
+		return true
+	}
+	rel, ok := repo.relPath(filePath)
+	if !ok {
+		http.Error(w, "File outside the git repository", http.StatusNotFound)
+		return true
+	}
+
+	switch {
+	case history:
+		serveHistory(w, r, repo, filePath, rel)
+	case from != "":
+		serveDiff(w, r, repo, filePath, rel, from, query.Get("to"))
+	default:
+		serveRevision(w, r, repo, filePath, rel, rev)
+	}
+	return true
+}
+
+// resolveRevision finds the commit designated by HEAD, a full object name or
+// an abbreviated object name of a commit changing the file
+func (r *gitRepo) resolveRevision(rev, rel string) (*gitCommit, error) {
+	if rev == "" || rev == "HEAD" {
+		head, err := r.head()
+		if err != nil {
+			return nil, err
+		}
+		return r.readCommit(head)
+	}
+
+	if h, err := parseGitHash(rev); err == nil {
+		return r.readCommit(h)
+	}
+
+	if len(rev) < 4 || strings.Trim(strings.ToLower(rev), "0123456789abcdef") != "" {
+		return nil, fmt.Errorf("invalid revision %q", rev)
+	}
+	history, err := r.fileHistory(rel, maxHistorySize)
+	if err != nil {
+		return nil, err
+	}
+	rev = strings.ToLower(rev)
+	for _, commit := range history {
+		if strings.HasPrefix(commit.Hash.String(), rev) {
+			return commit, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown revision %q", rev)
+}
+
+// fileAt returns the content of a file in a commit
+func (r *gitRepo) fileAt(commit *gitCommit, rel string) ([]byte, error) {
+	entry, exists, err := r.treeEntry(commit.Tree, rel)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, fmt.Errorf("%s does not exist in %s", rel, commit.Hash.Short())
+	}
+	obj, err := r.readObject(entry)
+	if err != nil {
+		return nil, err
+	}
+	if obj.typ != gitObjBlob {
+		return nil, fmt.Errorf("%s is not a file in %s", rel, commit.Hash.Short())
+	}
+	return obj.data, nil
+}
+
+// serveHistory lists the commits changing a file
+func serveHistory(w http.ResponseWriter, r *http.Request, repo *gitRepo, filePath, rel string) {
+	history, err := repo.fileHistory(rel, maxHistorySize)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	name := filepath.Base(filePath)
+	var result strings.Builder
+	fmt.Fprintf(&result, "<h1>History of %s</h1>\n", template.HTMLEscapeString(name))
+	fmt.Fprintf(&result, "<p><a href=\"%s\">Current version</a></p>\n", template.HTMLEscapeString(r.URL.EscapedPath()))
+
+	if len(history) == 0 {
+		result.WriteString("<p>This file has not been committed yet.</p>\n")
+	} else {
+		result.WriteString("<table class=\"godown-history\">\n")
+		result.WriteString("<tr><th>Date</th><th>Author</th><th>Message</th><th>Commit</th><th></th></tr>\n")
+		for i, commit := range history {
+			diff := ""
+			if i < len(history)-1 {
+				diff = fmt.Sprintf("<a href=\"?from=%s&amp;to=%s\">diff</a>", history[i+1].Hash, commit.Hash)
+			}
+			fmt.Fprintf(&result,
+				"<tr><td><time datetime=\"%s\">%s</time></td><td>%s</td><td>%s</td><td><a href=\"?rev=%s\"><code>%s</code></a></td><td>%s</td></tr>\n",
+				commit.Author.When.Format(time.RFC3339),
+				commit.Author.When.Format("2006-01-02 15:04"),
+				template.HTMLEscapeString(commit.Author.Name),
+				template.HTMLEscapeString(commit.Subject()),
+				commit.Hash,
+				commit.Hash.Short(),
+				diff,
+			)
+		}
+		result.WriteString("</table>\n")
+	}
+
+	renderPage(w, PageData{
+		Title:     name + " (history)",
+		Content:   template.HTML(result.String()),
+		StylePath: "/__godown_style.css",
+	})
+}
+
+// serveRevision renders a file as it was in a given commit
+func serveRevision(w http.ResponseWriter, r *http.Request, repo *gitRepo, filePath, rel, rev string) {
+	commit, err := repo.resolveRevision(rev, rel)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	content, err := repo.fileAt(commit, rel)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	name := filepath.Base(filePath)
+	var result strings.Builder
+	fmt.Fprintf(&result,
+		"<div class=\"godown-file-info\">Version of <strong>%s</strong> at <code>%s</code> (%s, %s): %s<br>\n<a href=\"%s\">Current version</a> &middot; <a href=\"?history\">History</a></div>\n",
+		template.HTMLEscapeString(name),
+		commit.Hash.Short(),
+		commit.Author.When.Format("2006-01-02"),
+		template.HTMLEscapeString(commit.Author.Name),
+		template.HTMLEscapeString(commit.Subject()),
+		template.HTMLEscapeString(r.URL.EscapedPath()),
+	)
+
+	switch {
+	case isMarkdownFile(filePath):
+		meta, body := splitFrontMatter(content)
+		result.Write(renderMarkdown(body, pageMarkdownOptions(meta), nil))
+	case utf8.Valid(content):
+		result.WriteString("<pre class=\"godown-text\">" + template.HTMLEscapeString(string(content)) + "</pre>")
+	default:
+		const maxDisplaySize = 64 * 1024
+		if len(content) > maxDisplaySize {
+			content = content[:maxDisplaySize]
+		}
+		result.WriteString(formatBinaryAsHex(content))
+	}
+
+	renderPage(w, PageData{
+		Title:     fmt.Sprintf("%s (%s)", name, commit.Hash.Short()),
+		Content:   template.HTML(result.String()),
+		StylePath: "/__godown_style.css",
+	})
+}
+
+// serveDiff renders the changes of a file between two commits, block by
+// block for Markdown files and line by line for other files
+func serveDiff(w http.ResponseWriter, r *http.Request, repo *gitRepo, filePath, rel, from, to string) {
+	var versions [2]struct {
+		commit  *gitCommit
+		content []byte
+	}
+	for i, rev := range []string{from, to} {
+		commit, err := repo.resolveRevision(rev, rel)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		content, err := repo.fileAt(commit, rel)
+		if err != nil {
+			// The file may not exist yet in the old revision
+			content = nil
+		}
+		versions[i].commit = commit
+		versions[i].content = content
+	}
+	oldVersion, newVersion := versions[0], versions[1]
+
+	name := filepath.Base(filePath)
+	var result strings.Builder
+	fmt.Fprintf(&result,
+		"<div class=\"godown-file-info\">Changes of <strong>%s</strong> from <a href=\"?rev=%s\"><code>%s</code></a> to <a href=\"?rev=%s\"><code>%s</code></a><br>\n<a href=\"%s\">Current version</a> &middot; <a href=\"?history\">History</a></div>\n",
+		template.HTMLEscapeString(name),
+		oldVersion.commit.Hash, oldVersion.commit.Hash.Short(),
+		newVersion.commit.Hash, newVersion.commit.Hash.Short(),
+		template.HTMLEscapeString(r.URL.EscapedPath()),
+	)
+
+	switch {
+	case isMarkdownFile(filePath):
+		_, oldBody := splitFrontMatter(oldVersion.content)
+		_, newBody := splitFrontMatter(newVersion.content)
+		result.WriteString(renderMarkdownDiff(string(oldBody), string(newBody)))
+	case utf8.Valid(oldVersion.content) && utf8.Valid(newVersion.content):
+		result.WriteString(renderLineDiff(string(oldVersion.content), string(newVersion.content)))
+	default:
+		fmt.Fprintf(&result, "<p>Binary files differ (%s &rarr; %s bytes).</p>\n",
+			formatBytes(int64(len(oldVersion.content))), formatBytes(int64(len(newVersion.content))))
+	}
+
+	renderPage(w, PageData{
+		Title:     name + " (diff)",
+		Content:   template.HTML(result.String()),
+		StylePath: "/__godown_style.css",
+	})
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+// Test history, revision and diff views
+func TestServeGitViews(t *testing.T) {
+	dir := gitFixture(t)
+
+	oldWd, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(oldWd)
+
+	oldRepo := siteRepo
+	siteRepo = openGitRepo(".")
+	defer func() { siteRepo = oldRepo }()
+
+	history, err := siteRepo.fileHistory("docs/guide.md", 0)
+	if err != nil || len(history) != 3 {
+		t.Fatalf("fileHistory() = %v, %v", history, err)
+	}
+	first, second := history[2].Hash.String(), history[1].Hash.String()
+
+	get := func(target string) (int, string) {
+		req := httptest.NewRequest("GET", target, nil)
+		w := httptest.NewRecorder()
+		serveMarkdown(w, req)
+		return w.Code, w.Body.String()
+	}
+
+	t.Run("History", func(t *testing.T) {
+		code, body := get("/docs/guide?history")
+		if code != http.StatusOK {
+			t.Fatalf("status = %d, body:\n%s", code, body)
+		}
+		for _, expected := range []string{"Topic change", "Update guide", "Add docs", "?rev=" + first, "?from=" + first + "&amp;to=" + second} {
+			if !strings.Contains(body, expected) {
+				t.Errorf("history should contain %q, got:\n%s", expected, body)
+			}
+		}
+	})
+
+	t.Run("Revision", func(t *testing.T) {
+		code, body := get("/docs/guide?rev=" + first[:8])
+		if code != http.StatusOK {
+			t.Fatalf("status = %d, body:\n%s", code, body)
+		}
+		if !strings.Contains(body, "<p>First version</p>") {
+			t.Errorf("revision should render the old content, got:\n%s", body)
+		}
+
+		if code, _ := get("/docs/guide?rev=zzzz"); code != http.StatusNotFound {
+			t.Errorf("invalid revision status = %d, want 404", code)
+		}
+	})
+
+	t.Run("Diff", func(t *testing.T) {
+		code, body := get("/docs/guide?from=" + first + "&to=" + second)
+		if code != http.StatusOK {
+			t.Fatalf("status = %d, body:\n%s", code, body)
+		}
+		if !strings.Contains(body, "<div class=\"godown-diff-del\">\n<p>First version</p>") {
+			t.Errorf("diff should show the removed block, got:\n%s", body)
+		}
+		if !strings.Contains(body, "<div class=\"godown-diff-ins\">\n<p>Second version</p>") {
+			t.Errorf("diff should show the added block, got:\n%s", body)
+		}
+	})
+
+	t.Run("Outside git", func(t *testing.T) {
+		siteRepo = nil
+		defer func() { siteRepo = openGitRepo(".") }()
+
+		if code, _ := get("/docs/guide?history"); code != http.StatusNotFound {
+			t.Errorf("history outside git status = %d, want 404", code)
+		}
+	})
+}
@@ -2,4 +2,9 @@ module github.com/badele/godown
 
 go 1.25.1
 
-require github.com/gomarkdown/markdown v0.0.0-20250810172220-2e2c11897d1a
+require (
+	github.com/BurntSushi/toml v1.6.0
+	github.com/gomarkdown/markdown v0.0.0-20250810172220-2e2c11897d1a
+	golang.org/x/net v0.50.0
+	gopkg.in/yaml.v3 v3.0.1
+)
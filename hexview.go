@@ -0,0 +1,507 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// defaultHexWindow is the number of bytes displayed when no length is requested
+	defaultHexWindow = 64 * 1024
+	// maxHexWindow bounds the number of bytes read for a single page
+	maxHexWindow = 1024 * 1024
+	// maxSearchMatches bounds the number of matches collected in a file
+	maxSearchMatches = 1000
+	// searchChunkSize is the size of the blocks read while searching a file
+	searchChunkSize = 64 * 1024
+)
+
+// hexOptions are the display options of the hex viewer
+type hexOptions struct {
+	Mode   string // Byte representation: hex, oct, dec or bin
+	Width  int    // Bytes per line
+	Group  int    // Bytes per group
+	Endian string // "le" or "be" to show groups as words, "" for bytes
+}
+
+// defaultHexOptions is the classic "od -A x -t x1z" layout
+var defaultHexOptions = hexOptions{Mode: "hex", Width: 16, Group: 1}
+
+// hexOptionsFromQuery reads the display options from the query string,
+// ignoring unsupported values
+func hexOptionsFromQuery(query url.Values) hexOptions {
+	opts := defaultHexOptions
+	if mode := query.Get("mode"); slices.Contains([]string{"hex", "oct", "dec", "bin"}, mode) {
+		opts.Mode = mode
+	}
+	if width, err := strconv.Atoi(query.Get("width")); err == nil && slices.Contains([]int{8, 16, 32}, width) {
+		opts.Width = width
+	}
+	if group, err := strconv.Atoi(query.Get("group")); err == nil && slices.Contains([]int{1, 2, 4, 8}, group) {
+		opts.Group = group
+	}
+	if endian := query.Get("endian"); endian == "le" || endian == "be" {
+		opts.Endian = endian
+	}
+	return opts
+}
+
+// cellWidth returns the number of characters of a value of size bytes
+func (o hexOptions) cellWidth(size int) int {
+	switch o.Mode {
+	case "oct":
+		return (size*8 + 2) / 3
+	case "dec":
+		maxValue := uint64(1)<<(8*size) - 1
+		if size == 8 {
+			maxValue = ^uint64(0)
+		}
+		return len(strconv.FormatUint(maxValue, 10))
+	case "bin":
+		return size * 8
+	}
+	return size * 2
+}
+
+// formatValue formats a byte or word value with the display mode
+func (o hexOptions) formatValue(value uint64, size int) string {
+	width := o.cellWidth(size)
+	switch o.Mode {
+	case "oct":
+		return fmt.Sprintf("%0*o", width, value)
+	case "dec":
+		return fmt.Sprintf("%*d", width, value)
+	case "bin":
+		return fmt.Sprintf("%0*b", width, value)
+	}
+	return fmt.Sprintf("%0*x", width, value)
+}
+
+// wordValue decodes a group of bytes as an integer, padding short groups
+func wordValue(group []byte, size int, endian string) uint64 {
+	buf := make([]byte, 8)
+	if endian == "be" {
+		copy(buf[8-size:], group)
+		return binary.BigEndian.Uint64(buf)
+	}
+	copy(buf, group)
+	return binary.LittleEndian.Uint64(buf)
+}
+
+// formatBinaryAsHex formats binary data in a hexdump-like format (similar to od -A x -t x1z)
+// It displays: offset | hex bytes (16 per line) | ASCII representation
+func formatBinaryAsHex(data []byte) string {
+	return formatHexDump(data, 0, defaultHexOptions, nil)
+}
+
+// formatHexDump formats binary data read at the given file offset with the
+// display options. Bytes flagged in highlight (indexed like data) are marked.
+func formatHexDump(data []byte, base int64, opts hexOptions, highlight []bool) string {
+	var result strings.Builder
+
+	wordSize := 1
+	if opts.Endian != "" {
+		wordSize = opts.Group
+	}
+	cell := opts.cellWidth(wordSize)
+
+	// Cells are separated by a space, groups by an extra space
+	separator := func(i int) string {
+		if opts.Group > 1 && opts.Endian == "" && i > 0 && i%opts.Group == 0 {
+			return "  "
+		}
+		return " "
+	}
+	cellsWidth := 0
+	for i := 0; i < opts.Width; i += wordSize {
+		cellsWidth += len(separator(i)) + cell
+	}
+
+	result.WriteString("<div class=\"godown-hexdump\">\n")
+	result.WriteString("<div class=\"godown-hexdump-header\">Offset   ")
+	for i := 0; i < opts.Width; i += wordSize {
+		result.WriteString(separator(i))
+		fmt.Fprintf(&result, "%-*s", cell, fmt.Sprintf("%02X", i))
+	}
+	result.WriteString("  ASCII\n")
+	result.WriteString("--------  " + strings.Repeat("-", cellsWidth-1) + "  " + strings.Repeat("-", opts.Width) + "</div>\n")
+
+	marked := func(i int) bool {
+		return i < len(highlight) && highlight[i]
+	}
+
+	for i := 0; i < len(data); i += opts.Width {
+		// Offset
+		fmt.Fprintf(&result, "<div><span class=\"offset\">%08x</span> ", base+int64(i))
+
+		end := min(i+opts.Width, len(data))
+
+		// Byte or word values
+		written := 0
+		for j := i; j < end; j += wordSize {
+			result.WriteString(separator(j - i))
+			groupEnd := min(j+wordSize, end)
+
+			var value string
+			if wordSize == 1 {
+				value = opts.formatValue(uint64(data[j]), 1)
+			} else {
+				value = opts.formatValue(wordValue(data[j:groupEnd], wordSize, opts.Endian), wordSize)
+			}
+
+			mark := false
+			for k := j; k < groupEnd; k++ {
+				mark = mark || marked(k)
+			}
+			if mark {
+				result.WriteString("<mark>" + value + "</mark>")
+			} else {
+				result.WriteString(value)
+			}
+			written += len(separator(j-i)) + cell
+		}
+
+		// Pad short lines
+		result.WriteString(strings.Repeat(" ", cellsWidth-written))
+		result.WriteString("  ")
+
+		// ASCII representation
+		for j := i; j < end; j++ {
+			b := data[j]
+			char := "."
+			if b >= 32 && b <= 126 {
+				// Escape HTML special characters
+				char = template.HTMLEscapeString(string(b))
+			}
+			if marked(j) {
+				char = "<mark>" + char + "</mark>"
+			}
+			result.WriteString(char)
+		}
+
+		result.WriteString("</div>\n")
+	}
+
+	result.WriteString("</div>")
+	return result.String()
+}
+
+// parseOffset parses a decimal or 0x-prefixed hexadecimal byte count
+func parseOffset(value string) (int64, bool) {
+	n, err := strconv.ParseInt(strings.TrimSpace(value), 0, 64)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return n, true
+}
+
+// hexLength returns the window length requested by the length query parameter
+func hexLength(query url.Values) int64 {
+	if value, ok := parseOffset(query.Get("length")); ok && value > 0 {
+		return min(value, maxHexWindow)
+	}
+	return defaultHexWindow
+}
+
+// hexWindow returns the window of the file to display from the offset and
+// length query parameters, clamped to the file size
+func hexWindow(query url.Values, size int64) (offset, length int64) {
+	length = hexLength(query)
+	if value, ok := parseOffset(query.Get("offset")); ok {
+		offset = value
+	}
+	if offset >= size {
+		// Show the last window of the file
+		offset = max(size-length, 0)
+	}
+	return offset, min(length, size-offset)
+}
+
+// hexPageURL returns the query string of a window of the hex viewer
+func hexPageURL(query url.Values, offset, length int64) string {
+	values := url.Values{}
+	for key, value := range query {
+		values[key] = value
+	}
+	values.Set("offset", fmt.Sprintf("0x%x", offset))
+	values.Set("length", strconv.FormatInt(length, 10))
+	return "?" + values.Encode()
+}
+
+// hiddenInputs renders the query parameters as hidden form fields, except
+// the excluded ones
+func hiddenInputs(query url.Values, exclude ...string) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		if !slices.Contains(exclude, key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var result strings.Builder
+	for _, key := range keys {
+		for _, value := range query[key] {
+			fmt.Fprintf(&result, "<input type=\"hidden\" name=\"%s\" value=\"%s\">\n", template.HTMLEscapeString(key), template.HTMLEscapeString(value))
+		}
+	}
+	return result.String()
+}
+
+// hexNavigation renders the links to the other windows and the
+// jump-to-offset form
+func hexNavigation(query url.Values, offset, length, size int64) string {
+	step := hexLength(query)
+
+	var result strings.Builder
+	result.WriteString("<div class=\"godown-hex-nav\">\n")
+	if offset > 0 {
+		fmt.Fprintf(&result, "<a href=\"%s\">&laquo; First</a>\n", template.HTMLEscapeString(hexPageURL(query, 0, step)))
+		fmt.Fprintf(&result, "<a href=\"%s\" rel=\"prev\">&lsaquo; Previous</a>\n", template.HTMLEscapeString(hexPageURL(query, max(offset-step, 0), step)))
+	}
+	if offset+length < size {
+		fmt.Fprintf(&result, "<a href=\"%s\" rel=\"next\">Next &rsaquo;</a>\n", template.HTMLEscapeString(hexPageURL(query, offset+length, step)))
+		last := (size - 1) / step * step
+		fmt.Fprintf(&result, "<a href=\"%s\">Last &raquo;</a>\n", template.HTMLEscapeString(hexPageURL(query, last, step)))
+	}
+
+	// Jump to offset, keeping the other parameters
+	result.WriteString("<form method=\"get\">\n")
+	result.WriteString(hiddenInputs(query, "offset", "length"))
+	fmt.Fprintf(&result, "<label>Offset <input type=\"text\" name=\"offset\" value=\"0x%x\" size=\"12\"></label>\n", offset)
+	fmt.Fprintf(&result, "<input type=\"hidden\" name=\"length\" value=\"%d\">\n", step)
+	result.WriteString("<button type=\"submit\">Go</button>\n</form>\n</div>\n")
+	return result.String()
+}
+
+// hexControls renders the display mode and search form
+func hexControls(query url.Values, opts hexOptions) string {
+	selectField := func(name, label, current string, options [][2]string) string {
+		var field strings.Builder
+		fmt.Fprintf(&field, "<label>%s <select name=\"%s\">", label, name)
+		for _, option := range options {
+			selected := ""
+			if option[0] == current {
+				selected = " selected"
+			}
+			fmt.Fprintf(&field, "<option value=\"%s\"%s>%s</option>", option[0], selected, option[1])
+		}
+		field.WriteString("</select></label>\n")
+		return field.String()
+	}
+
+	var result strings.Builder
+	result.WriteString("<form method=\"get\" class=\"godown-hex-controls\">\n")
+	result.WriteString(hiddenInputs(query, "mode", "width", "group", "endian", "find", "findtype"))
+	result.WriteString(selectField("mode", "Mode", opts.Mode, [][2]string{{"hex", "Hexadecimal"}, {"oct", "Octal"}, {"dec", "Decimal"}, {"bin", "Binary"}}))
+	result.WriteString(selectField("width", "Bytes/line", strconv.Itoa(opts.Width), [][2]string{{"8", "8"}, {"16", "16"}, {"32", "32"}}))
+	result.WriteString(selectField("group", "Group", strconv.Itoa(opts.Group), [][2]string{{"1", "1"}, {"2", "2"}, {"4", "4"}, {"8", "8"}}))
+	result.WriteString(selectField("endian", "Words", opts.Endian, [][2]string{{"", "Bytes"}, {"le", "Little-endian"}, {"be", "Big-endian"}}))
+	fmt.Fprintf(&result, "<label>Search <input type=\"text\" name=\"find\" value=\"%s\"></label>\n", template.HTMLEscapeString(query.Get("find")))
+	result.WriteString(selectField("findtype", "as", query.Get("findtype"), [][2]string{{"text", "ASCII"}, {"hex", "Hex bytes"}}))
+	result.WriteString("<button type=\"submit\">Apply</button>\n</form>\n")
+	return result.String()
+}
+
+// searchPattern returns the bytes to search from the find query parameter:
+// hexadecimal pairs (spaces allowed) for findtype=hex, raw text otherwise
+func searchPattern(query url.Values) ([]byte, error) {
+	find := query.Get("find")
+	if find == "" {
+		return nil, nil
+	}
+	if query.Get("findtype") != "hex" {
+		return []byte(find), nil
+	}
+	digits := strings.Join(strings.Fields(strings.TrimPrefix(strings.ToLower(find), "0x")), "")
+	pattern, err := hex.DecodeString(digits)
+	if err != nil || len(pattern) == 0 {
+		return nil, fmt.Errorf("invalid hex pattern %q", find)
+	}
+	return pattern, nil
+}
+
+// searchFile finds the offsets of a byte pattern in a file, reading it by
+// chunks, and stops after limit matches
+func searchFile(ra io.ReaderAt, size int64, pattern []byte, limit int) (matches []int64, truncated bool) {
+	if len(pattern) == 0 {
+		return nil, false
+	}
+
+	// Chunks overlap so that matches across boundaries are found
+	overlap := int64(len(pattern) - 1)
+	buf := make([]byte, searchChunkSize+overlap)
+	for start := int64(0); start < size; start += searchChunkSize {
+		n, err := ra.ReadAt(buf, start)
+		if n == 0 && err != nil {
+			break
+		}
+		chunk := buf[:n]
+		for from := 0; from < searchChunkSize; {
+			i := bytes.Index(chunk[from:], pattern)
+			if i < 0 || from+i >= searchChunkSize {
+				// Matches starting in the overlap belong to the next chunk
+				break
+			}
+			matches = append(matches, start+int64(from+i))
+			if len(matches) >= limit {
+				return matches, true
+			}
+			from += i + 1
+		}
+	}
+	return matches, false
+}
+
+// highlightMatches flags the bytes of the window (length bytes read at
+// offset) that are part of a match, including matches crossing its edges
+func highlightMatches(ra io.ReaderAt, size, offset int64, length int, pattern []byte) []bool {
+	if len(pattern) == 0 || length == 0 {
+		return nil
+	}
+
+	overlap := int64(len(pattern) - 1)
+	start := max(offset-overlap, 0)
+	end := min(offset+int64(length)+overlap, size)
+	buf := make([]byte, end-start)
+	n, _ := ra.ReadAt(buf, start)
+	buf = buf[:n]
+
+	highlight := make([]bool, length)
+	for from := 0; ; {
+		i := bytes.Index(buf[from:], pattern)
+		if i < 0 {
+			break
+		}
+		for k := 0; k < len(pattern); k++ {
+			if pos := start + int64(from+i+k) - offset; pos >= 0 && pos < int64(length) {
+				highlight[pos] = true
+			}
+		}
+		from += i + 1
+	}
+	return highlight
+}
+
+// searchResults renders the number of matches and the jump-to-match links
+func searchResults(query url.Values, matches []int64, truncated bool, offset, length int64, opts hexOptions) string {
+	step := hexLength(query)
+	lineStart := func(match int64) int64 {
+		return match - match%int64(opts.Width)
+	}
+
+	var result strings.Builder
+	result.WriteString("<div class=\"godown-hex-search\">\n")
+	count := strconv.Itoa(len(matches))
+	if truncated {
+		count = "more than " + count
+	}
+	fmt.Fprintf(&result, "<strong>%s matches</strong> for <code>%s</code>\n", count, template.HTMLEscapeString(query.Get("find")))
+
+	// Closest matches outside the displayed window
+	prev := sort.Search(len(matches), func(i int) bool { return matches[i] >= offset }) - 1
+	next := sort.Search(len(matches), func(i int) bool { return matches[i] >= offset+length })
+	if prev >= 0 {
+		fmt.Fprintf(&result, "<a href=\"%s\">&lsaquo; Previous match</a>\n", template.HTMLEscapeString(hexPageURL(query, lineStart(matches[prev]), step)))
+	}
+	if next < len(matches) {
+		fmt.Fprintf(&result, "<a href=\"%s\">Next match &rsaquo;</a>\n", template.HTMLEscapeString(hexPageURL(query, lineStart(matches[next]), step)))
+	}
+
+	if len(matches) > 0 {
+		result.WriteString("<details><summary>All matches</summary>\n<ul>\n")
+		for _, match := range matches[:min(len(matches), 100)] {
+			fmt.Fprintf(&result, "<li><a href=\"%s\">0x%08x</a></li>\n", template.HTMLEscapeString(hexPageURL(query, lineStart(match), step)), match)
+		}
+		result.WriteString("</ul>\n</details>\n")
+	}
+	result.WriteString("</div>\n")
+	return result.String()
+}
+
+// serveBinaryFile serves a binary file with hexadecimal dump display. Only the
+// requested window (?offset=&length=) is read, so memory stays bounded
+// whatever the file size.
+func serveBinaryFile(w http.ResponseWriter, r *http.Request, filePath string) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	size := info.Size()
+
+	query := r.URL.Query()
+	opts := hexOptionsFromQuery(query)
+	offset, length := hexWindow(query, size)
+
+	displayContent := make([]byte, length)
+	n, err := file.ReadAt(displayContent, offset)
+	if err != nil && err != io.EOF {
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	displayContent = displayContent[:n]
+
+	// Search the whole file and highlight the matches of the window
+	search := ""
+	pattern, err := searchPattern(query)
+	if err != nil {
+		search = "<div class=\"godown-hex-search\">" + template.HTMLEscapeString(err.Error()) + "</div>\n"
+	} else if pattern != nil {
+		matches, truncated := searchFile(file, size, pattern, maxSearchMatches)
+		search = searchResults(query, matches, truncated, offset, int64(n), opts)
+	}
+	highlight := highlightMatches(file, size, offset, n, pattern)
+
+	// Format as hexdump
+	hexDump := formatHexDump(displayContent, offset, opts, highlight)
+
+	// Add file info header
+	fileInfo := "<div class=\"godown-file-info\">\n"
+	fileInfo += fmt.Sprintf("<strong>File:</strong> %s<br>\n", template.HTMLEscapeString(filepath.Base(filePath)))
+	fileInfo += fmt.Sprintf("<strong>Size:</strong> %s bytes", formatBytes(size))
+	if int64(n) < size {
+		fileInfo += fmt.Sprintf(" (showing %s from offset 0x%x to 0x%x)", formatBytes(int64(n)), offset, offset+int64(n))
+	}
+	fileInfo += "\n</div>\n"
+
+	// Summary of the recognized file format
+	formatPanel := renderFormatInfo(identifyFormat(file, size))
+
+	navigation := ""
+	if int64(n) < size {
+		navigation = hexNavigation(query, offset, int64(n), size)
+	}
+
+	htmlContent := fileInfo + formatPanel + hexControls(query, opts) + search + navigation + hexDump + navigation
+
+	title := filepath.Base(filePath) + " (binary)"
+
+	data := PageData{
+		Title:     title,
+		Content:   template.HTML(htmlContent),
+		StylePath: "/__godown_style.css",
+		Views:     template.HTML(viewLinks(r, filePath, viewHex)),
+	}
+
+	renderPage(w, data)
+}
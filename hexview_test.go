@@ -0,0 +1,242 @@
+package main
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// Test window selection from the query string
+func TestHexWindow(t *testing.T) {
+	tests := []struct {
+		query      string
+		size       int64
+		wantOffset int64
+		wantLength int64
+	}{
+		{"", 100, 0, 100},
+		{"", 1 << 20, 0, defaultHexWindow},
+		{"offset=0x100&length=256", 1 << 20, 0x100, 256},
+		{"offset=1000&length=512", 1200, 1000, 200},
+		{"offset=5000", 1200, 0, 1200},
+		{"offset=5000&length=100", 1200, 1100, 100},
+		{"offset=-5&length=abc", 100, 0, 100},
+		{"length=999999999", 10 << 20, 0, maxHexWindow},
+		{"", 0, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			query, _ := url.ParseQuery(tt.query)
+			offset, length := hexWindow(query, tt.size)
+			if offset != tt.wantOffset || length != tt.wantLength {
+				t.Errorf("hexWindow(%q, %d) = %d, %d, want %d, %d", tt.query, tt.size, offset, length, tt.wantOffset, tt.wantLength)
+			}
+		})
+	}
+}
+
+// Test hex dump offsets for a window
+func TestFormatHexDumpOffsets(t *testing.T) {
+	result := formatHexDump([]byte("ABCDEFGHIJKLMNOPQR"), 0x1000, defaultHexOptions, nil)
+	for _, expected := range []string{"00001000", "00001010", "41 42 43"} {
+		if !strings.Contains(result, expected) {
+			t.Errorf("formatHexDump() should contain %q, got:\n%s", expected, result)
+		}
+	}
+}
+
+// Test hex dump display modes
+func TestFormatHexDumpModes(t *testing.T) {
+	data := []byte{0x01, 0x02, 0x03, 0x04, 0xff, 0x00, 0x41, 0x42}
+
+	tests := []struct {
+		name     string
+		query    string
+		expected []string
+	}{
+		{"octal", "mode=oct", []string{"001 002 003 004 377 000 101 102"}},
+		{"decimal", "mode=dec", []string{"  1   2   3   4 255   0  65  66"}},
+		{"binary", "mode=bin", []string{"00000001 00000010", "11111111"}},
+		{"grouped", "group=4", []string{"01 02 03 04  ff 00 41 42"}},
+		{"little-endian words", "group=4&endian=le", []string{"04030201 424100ff"}},
+		{"big-endian words", "group=2&endian=be", []string{"0102 0304 ff00 4142"}},
+		{"width", "width=8", []string{"00000000", "..AB"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, _ := url.ParseQuery(tt.query)
+			result := formatHexDump(data, 0, hexOptionsFromQuery(query), nil)
+			for _, expected := range tt.expected {
+				if !strings.Contains(result, expected) {
+					t.Errorf("formatHexDump(%s) should contain %q, got:\n%s", tt.query, expected, result)
+				}
+			}
+		})
+	}
+}
+
+// Test unsupported display options fall back to the defaults
+func TestHexOptionsFromQuery(t *testing.T) {
+	query, _ := url.ParseQuery("mode=base64&width=12&group=3&endian=middle")
+	if opts := hexOptionsFromQuery(query); opts != defaultHexOptions {
+		t.Errorf("hexOptionsFromQuery() = %+v, want %+v", opts, defaultHexOptions)
+	}
+}
+
+// Test search patterns
+func TestSearchPattern(t *testing.T) {
+	tests := []struct {
+		query   string
+		want    []byte
+		wantErr bool
+	}{
+		{"", nil, false},
+		{"find=PK", []byte("PK"), false},
+		{"find=50+4b+03&findtype=hex", []byte{0x50, 0x4b, 0x03}, false},
+		{"find=0x504b&findtype=hex", []byte{0x50, 0x4b}, false},
+		{"find=zz&findtype=hex", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			query, _ := url.ParseQuery(tt.query)
+			got, err := searchPattern(query)
+			if (err != nil) != tt.wantErr || !bytes.Equal(got, tt.want) {
+				t.Errorf("searchPattern(%q) = %v, %v, want %v", tt.query, got, err, tt.want)
+			}
+		})
+	}
+}
+
+// Test searching a file across chunk boundaries
+func TestSearchFile(t *testing.T) {
+	content := make([]byte, 3*searchChunkSize)
+	positions := []int64{10, searchChunkSize - 2, 2*searchChunkSize + 100}
+	for _, pos := range positions {
+		copy(content[pos:], "needle")
+	}
+	ra := bytes.NewReader(content)
+
+	matches, truncated := searchFile(ra, int64(len(content)), []byte("needle"), maxSearchMatches)
+	if truncated || !slices.Equal(matches, positions) {
+		t.Errorf("searchFile() = %v, %v, want %v", matches, truncated, positions)
+	}
+
+	matches, truncated = searchFile(ra, int64(len(content)), []byte("needle"), 2)
+	if !truncated || len(matches) != 2 {
+		t.Errorf("searchFile() with limit = %v, %v, want 2 truncated matches", matches, truncated)
+	}
+}
+
+// Test highlighting matches crossing the window edges
+func TestHighlightMatches(t *testing.T) {
+	content := []byte("xxABCxxxxABC")
+	highlight := highlightMatches(bytes.NewReader(content), int64(len(content)), 3, 8, []byte("ABC"))
+	want := []bool{true, true, false, false, false, false, true, true}
+	if !slices.Equal(highlight, want) {
+		t.Errorf("highlightMatches() = %v, want %v", highlight, want)
+	}
+}
+
+// Test searching in the binary file viewer
+func TestServeBinaryFileSearch(t *testing.T) {
+	tmpDir := t.TempDir()
+	content := make([]byte, 200*1024)
+	copy(content[0x20010:], "\x00MAGIC\x00")
+	binFile := filepath.Join(tmpDir, "data.bin")
+	if err := os.WriteFile(binFile, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest("GET", "/data.bin?find=MAGIC&length=4096", nil)
+	w := httptest.NewRecorder()
+	serveBinaryFile(w, req, binFile)
+
+	body := w.Body.String()
+	for _, expected := range []string{
+		"1 matches",
+		`href="?find=MAGIC&amp;length=4096&amp;offset=0x20010">Next match`,
+		`class="godown-hex-controls"`,
+		`name="find" value="MAGIC"`,
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("search page should contain %q", expected)
+		}
+	}
+
+	// The window holding the match highlights it
+	req = httptest.NewRequest("GET", "/data.bin?find=MAGIC&length=4096&offset=0x20010", nil)
+	w = httptest.NewRecorder()
+	serveBinaryFile(w, req, binFile)
+	if body := w.Body.String(); !strings.Contains(body, "<mark>4d</mark>") || !strings.Contains(body, "<mark>M</mark>") {
+		t.Errorf("match should be highlighted, got:\n%s", body)
+	}
+}
+
+// Test paginated binary file display
+func TestServeBinaryFilePagination(t *testing.T) {
+	tmpDir := t.TempDir()
+	content := make([]byte, 200*1024)
+	for i := range content {
+		content[i] = byte(i % 251)
+	}
+	binFile := filepath.Join(tmpDir, "large.bin")
+	if err := os.WriteFile(binFile, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest("GET", "/large.bin?offset=0x10000&length=4096", nil)
+	w := httptest.NewRecorder()
+
+	serveBinaryFile(w, req, binFile)
+
+	body := w.Body.String()
+	for _, expected := range []string{
+		"showing 4.0 KB from offset 0x10000 to 0x11000",
+		"00010000",
+		"00010ff0",
+		`href="?length=4096&amp;offset=0x11000" rel="next"`,
+		`href="?length=4096&amp;offset=0xf000" rel="prev"`,
+		`href="?length=4096&amp;offset=0x31000">Last`,
+		`name="offset" value="0x10000"`,
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("serveBinaryFile() body should contain %q", expected)
+		}
+	}
+	if strings.Contains(body, "00011000") {
+		t.Errorf("serveBinaryFile() should not display bytes after the window")
+	}
+}
+
+// Test the format summary above the hex dump
+func TestServeBinaryFileFormat(t *testing.T) {
+	tmpDir := t.TempDir()
+	sqlite := make([]byte, 512)
+	copy(sqlite, "SQLite format 3\x00")
+	dbFile := filepath.Join(tmpDir, "data.db")
+	if err := os.WriteFile(dbFile, sqlite, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest("GET", "/data.db", nil)
+	w := httptest.NewRecorder()
+
+	serveBinaryFile(w, req, dbFile)
+
+	body := w.Body.String()
+	format := strings.Index(body, "<strong>Format:</strong> SQLite database")
+	if format < 0 {
+		t.Fatalf("serveBinaryFile() should show the format summary")
+	}
+	if dump := strings.Index(body, "00000000"); dump < format {
+		t.Errorf("format summary should be shown above the hex dump")
+	}
+}
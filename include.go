@@ -0,0 +1,268 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// maxIncludeDepth is the maximum nesting of included files
+const maxIncludeDepth = 8
+
+// maxIncludeSize bounds the size of the files included and embedded by a
+// page, a file included several times counting each time
+const maxIncludeSize = 10 << 20
+
+// directivePattern matches the {{< include "path" >}} and
+// {{< snippet "path" name="value" >}} directives
+var directivePattern = regexp.MustCompile(`\{\{<\s*(include|snippet)\s+"([^"]+)"((?:\s+\w+="[^"]*")*)\s*>\}\}`)
+
+// directiveParam matches the name="value" parameters of a directive
+var directiveParam = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// listItem matches the first line of a list item, whose indented lines
+// continue the item instead of starting a code block
+var listItem = regexp.MustCompile(`^ {0,3}([-*+]|\d{1,9}[.)])(\s|$)`)
+
+// expansion is the state of the expansion of the directives of a page
+type expansion struct {
+	root     string
+	page     string   // Absolute path of the expanded page
+	included []string // Included files
+	problems []string // Failed directives, as "file:line: message"
+	size     int      // Size of the files read
+}
+
+// expandIncludes replaces the directives of a Markdown page, outside code
+// blocks, with the body of the included files and the embedded snippets,
+// given relative to the file and confined to the root. It also returns the
+// included files. Failed directives are replaced by a warning, reported by
+// the check command.
+func expandIncludes(body []byte, filePath, root string) ([]byte, []string) {
+	e := newExpansion(body, filePath, root)
+	if e == nil {
+		return body, nil
+	}
+	return e.expand(body, []string{e.page}), e.included
+}
+
+// checkDirectives returns the directives of a Markdown page which cannot be
+// expanded
+func checkDirectives(body []byte, filePath, root string) []string {
+	e := newExpansion(body, filePath, root)
+	if e == nil {
+		return nil
+	}
+	e.expand(body, []string{e.page})
+	return e.problems
+}
+
+// newExpansion prepares the expansion of a page, nil when it has no
+// directive
+func newExpansion(body []byte, filePath, root string) *expansion {
+	if !directivePattern.Match(body) {
+		return nil
+	}
+	// Symbolic links are resolved as for the included files
+	if resolved, err := filepath.EvalSymlinks(filePath); err == nil {
+		filePath = resolved
+	}
+	if resolved, err := filepath.EvalSymlinks(root); err == nil {
+		root = resolved
+	}
+	page, err := filepath.Abs(filePath)
+	if err != nil {
+		return nil
+	}
+	if root, err = filepath.Abs(root); err != nil {
+		return nil
+	}
+	return &expansion{root: root, page: page}
+}
+
+// expand expands the directives of the last file of the stack of included
+// files
+func (e *expansion) expand(body []byte, stack []string) []byte {
+	var result bytes.Buffer
+	fence := ""
+	blank, indented, list := true, false, false
+	for n, line := range bytes.SplitAfter(body, []byte("\n")) {
+		trimmed := strings.TrimSpace(string(line))
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			result.Write(line)
+			continue
+		}
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fence = trimmed[:3]
+			result.Write(line)
+			continue
+		}
+
+		// Indented code blocks start after a blank line, outside of lists
+		isIndented := bytes.HasPrefix(line, []byte("    ")) || bytes.HasPrefix(line, []byte("\t"))
+		switch {
+		case trimmed == "":
+			blank = true
+			result.Write(line)
+			continue
+		case isIndented && (indented || blank && !list):
+			blank, indented = false, true
+			result.Write(line)
+			continue
+		case !isIndented:
+			list = listItem.Match(line)
+		}
+		blank, indented = false, false
+
+		// Directives of the code spans are left as is
+		spans := codeSpan.FindAllIndex(line, -1)
+		last := 0
+		for _, loc := range directivePattern.FindAllSubmatchIndex(line, -1) {
+			if insideSpan(spans, loc[0]) {
+				continue
+			}
+			result.Write(line[last:loc[0]])
+			result.Write(e.directive(line, loc, n+1, stack))
+			last = loc[1]
+		}
+		result.Write(line[last:])
+	}
+	return result.Bytes()
+}
+
+// insideSpan checks if an offset is inside one of the spans
+func insideSpan(spans [][]int, offset int) bool {
+	for _, span := range spans {
+		if offset >= span[0] && offset < span[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// directive returns the expansion of the directive found at loc in a line
+// of the last file of the stack, or a warning when it fails
+func (e *expansion) directive(line []byte, loc []int, lineNumber int, stack []string) []byte {
+	filePath := stack[len(stack)-1]
+	name, target := string(line[loc[2]:loc[3]]), string(line[loc[4]:loc[5]])
+	params := map[string]string{}
+	for _, param := range directiveParam.FindAllSubmatch(line[loc[6]:loc[7]], -1) {
+		params[string(param[1])] = string(param[2])
+	}
+
+	var content []byte
+	var err error
+	action := "include"
+	if name == "include" {
+		content, err = e.include(target, stack)
+	} else {
+		action = "embed"
+		content, err = e.snippet(target, params, filePath)
+	}
+	if err != nil {
+		rel, _ := filepath.Rel(e.root, filePath)
+		problem := fmt.Sprintf("%s:%d: cannot %s %q: %v", filepath.ToSlash(rel), lineNumber, action, target, err)
+		e.problems = append(e.problems, problem)
+		return []byte(fmt.Sprintf("\n> [!WARNING]\n> Cannot %s `%s`: %v\n", action, target, err))
+	}
+	return content
+}
+
+// include returns the expanded body of a file included by the last file of
+// the stack
+func (e *expansion) include(target string, stack []string) ([]byte, error) {
+	if len(stack) > maxIncludeDepth {
+		return nil, fmt.Errorf("more than %d nested includes", maxIncludeDepth)
+	}
+
+	resolved, err := e.resolve(target, stack[len(stack)-1])
+	if err != nil {
+		return nil, err
+	}
+	for _, parent := range stack {
+		if parent == resolved {
+			return nil, fmt.Errorf("include cycle")
+		}
+	}
+
+	content, err := e.readFile(resolved)
+	if err != nil {
+		return nil, err
+	}
+	e.included = append(e.included, resolved)
+	_, body := splitFrontMatter(content)
+	return e.expand(body, append(stack, resolved)), nil
+}
+
+// readFile reads an included or embedded file while the size budget of the
+// page lasts
+func (e *expansion) readFile(path string) ([]byte, error) {
+	if e.size >= maxIncludeSize {
+		return nil, fmt.Errorf("more than %s of included files", formatBytes(maxIncludeSize))
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("file not readable")
+	}
+	if e.size += len(content); e.size > maxIncludeSize {
+		return nil, fmt.Errorf("more than %s of included files", formatBytes(maxIncludeSize))
+	}
+	return content, nil
+}
+
+// resolve returns the absolute path of a file given relative to another file,
+// which must be in the root
+func (e *expansion) resolve(target, filePath string) (string, error) {
+	path := filepath.Join(filepath.Dir(filePath), filepath.FromSlash(target))
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", fmt.Errorf("file not found")
+	}
+	resolved, err = filepath.Abs(resolved)
+	if err != nil {
+		return "", err
+	}
+	if !insideRoot(resolved, e.root) {
+		return "", fmt.Errorf("outside of the served directory")
+	}
+	return resolved, nil
+}
+
+// insideRoot checks if an absolute path is in the root directory
+func insideRoot(path, root string) bool {
+	rootPath, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return false
+	}
+	rootPath, err = filepath.Abs(rootPath)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(rootPath, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// includesSignature adds the state of the included files, which can be
+// hidden or not Markdown files, to the signature of a tree
+func includesSignature(stamp uint64, includes []string) uint64 {
+	if len(includes) == 0 {
+		return stamp
+	}
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%d\n", stamp)
+	for _, file := range includes {
+		if info, err := os.Stat(file); err == nil {
+			fmt.Fprintf(h, "%s|%d|%d\n", file, info.ModTime().UnixNano(), info.Size())
+		} else {
+			fmt.Fprintf(h, "%s|missing\n", file)
+		}
+	}
+	return h.Sum64()
+}
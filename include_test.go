@@ -0,0 +1,199 @@
+package main
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Test the expansion of include directives
+func TestExpandIncludes(t *testing.T) {
+	tmpDir := t.TempDir()
+	root := filepath.Join(tmpDir, "docs")
+	writeTree(t, tmpDir, map[string]string{
+		"secret.md":                "Secret content",
+		"docs/shared/setup.md":     "---\ntitle: Setup\n---\nRun `make setup`.\n{{< include \"nested.md\" >}}\n",
+		"docs/shared/nested.md":    "Nested content\n",
+		"docs/shared/cycle-a.md":   "A\n{{< include \"cycle-b.md\" >}}\n",
+		"docs/shared/cycle-b.md":   "B\n{{< include \"cycle-a.md\" >}}\n",
+		"docs/shared/deep.md":      "{{< include \"deep.md\" >}}\n",
+		"docs/shared/.snippet.txt": "Hidden snippet\n",
+	})
+
+	tests := []struct {
+		name       string
+		body       string
+		contains   []string
+		unexpected []string
+		includes   int
+	}{
+		{
+			name:       "nested include",
+			body:       "# Install\n{{< include \"../shared/setup.md\" >}}\n",
+			contains:   []string{"# Install\n", "Run `make setup`.", "Nested content"},
+			unexpected: []string{"title: Setup", "{{<"},
+			includes:   2,
+		},
+		{
+			name:     "hidden file",
+			body:     "{{<include \"../shared/.snippet.txt\">}}",
+			contains: []string{"Hidden snippet"},
+			includes: 1,
+		},
+		{
+			name:     "missing file",
+			body:     "{{< include \"missing.md\" >}}",
+			contains: []string{"> [!WARNING]", "Cannot include `missing.md`: file not found"},
+		},
+		{
+			name:       "outside of the root",
+			body:       "{{< include \"../../secret.md\" >}}",
+			contains:   []string{"outside of the served directory"},
+			unexpected: []string{"Secret content"},
+		},
+		{
+			name:     "cycle",
+			body:     "{{< include \"../shared/cycle-a.md\" >}}",
+			contains: []string{"A\n", "B\n", "Cannot include `cycle-a.md`: include cycle"},
+			includes: 2,
+		},
+		{
+			name:     "self include",
+			body:     "{{< include \"../shared/deep.md\" >}}",
+			contains: []string{"include cycle"},
+			includes: 1,
+		},
+		{
+			name:     "code span",
+			body:     "Write `{{< include \"../shared/nested.md\" >}}` or {{< include \"../shared/nested.md\" >}}\n",
+			contains: []string{"Write `{{< include \"../shared/nested.md\" >}}` or Nested content"},
+			includes: 1,
+		},
+		{
+			name:       "indented code block",
+			body:       "Example:\n\n    {{< include \"../shared/nested.md\" >}}\n\n    more code\n",
+			contains:   []string{"    {{< include \"../shared/nested.md\" >}}\n"},
+			unexpected: []string{"Nested content"},
+		},
+		{
+			name:     "list item continuation",
+			body:     "- Item\n\n    {{< include \"../shared/nested.md\" >}}\n",
+			contains: []string{"    Nested content"},
+			includes: 1,
+		},
+		{
+			name:       "code block",
+			body:       "```\n{{< include \"../shared/nested.md\" >}}\n```\n",
+			contains:   []string{"{{< include \"../shared/nested.md\" >}}"},
+			unexpected: []string{"Nested content"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, includes := expandIncludes([]byte(tt.body), filepath.Join(root, "guide", "install.md"), root)
+			for _, s := range tt.contains {
+				if !strings.Contains(string(result), s) {
+					t.Errorf("expandIncludes() = %q, should contain %q", result, s)
+				}
+			}
+			for _, s := range tt.unexpected {
+				if strings.Contains(string(result), s) {
+					t.Errorf("expandIncludes() = %q, should not contain %q", result, s)
+				}
+			}
+			if len(includes) != tt.includes {
+				t.Errorf("expandIncludes() included %v, want %d files", includes, tt.includes)
+			}
+		})
+	}
+}
+
+// Test the nesting limit of included files
+func TestExpandIncludesDepth(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{}
+	for i := 0; i <= maxIncludeDepth+1; i++ {
+		files[fmt.Sprintf("level/%d.md", i)] = fmt.Sprintf("level %d\n{{< include \"%d.md\" >}}\n", i, i+1)
+	}
+	writeTree(t, tmpDir, files)
+
+	result, _ := expandIncludes([]byte("{{< include \"level/0.md\" >}}"), filepath.Join(tmpDir, "index.md"), tmpDir)
+	if !strings.Contains(string(result), "nested includes") {
+		t.Errorf("expandIncludes() = %q, should stop nesting", result)
+	}
+	if strings.Contains(string(result), fmt.Sprintf("level %d", maxIncludeDepth+1)) {
+		t.Errorf("expandIncludes() = %q, should not include the last level", result)
+	}
+}
+
+// Test the size limit of included files, a diamond of includes growing
+// exponentially
+func TestExpandIncludesSize(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		fmt.Sprintf("level/%d.md", maxIncludeDepth-1): strings.Repeat("x", 100<<10) + "\n",
+	}
+	for i := 0; i < maxIncludeDepth-1; i++ {
+		files[fmt.Sprintf("level/%d.md", i)] = fmt.Sprintf("{{< include \"%d.md\" >}}\n{{< include \"%d.md\" >}}\n", i+1, i+1)
+	}
+	writeTree(t, tmpDir, files)
+
+	page := filepath.Join(tmpDir, "index.md")
+	body := []byte("{{< include \"level/0.md\" >}}")
+	result, _ := expandIncludes(body, page, tmpDir)
+	if !strings.Contains(string(result), "of included files") {
+		t.Errorf("expandIncludes() should stop at %d bytes of included files", maxIncludeSize)
+	}
+	if len(result) > 2*maxIncludeSize {
+		t.Errorf("expandIncludes() = %d bytes, want at most %d", len(result), 2*maxIncludeSize)
+	}
+	if problems := checkDirectives(body, page, tmpDir); len(problems) == 0 {
+		t.Errorf("checkDirectives() should report the size limit")
+	}
+}
+
+// Test that included files are rendered and watched with the tree
+func TestIncludedPage(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTree(t, tmpDir, map[string]string{
+		"README.md":        "# Home\n\n{{< include \".shared/intro.md\" >}}\n",
+		".shared/intro.md": "Welcome to [[Guide]].\n",
+		"guide.md":         "# Guide",
+	})
+
+	tree := newDocTree(tmpDir, "", "README.md")
+	if backlinks := tree.backlinks("guide.md"); !strings.Contains(string(backlinks), "Home") {
+		t.Errorf("backlinks() = %q, should list the page including the link", backlinks)
+	}
+
+	m := &mount{Root: tmpDir, Index: "README.md", Tree: tree}
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/", nil)
+	serveMarkdownFile(w, r, m, filepath.Join(tmpDir, "README.md"))
+	if body := w.Body.String(); !strings.Contains(body, "Welcome to") {
+		t.Errorf("serveMarkdownFile() should render the included file, got %q", body)
+	}
+
+	// Editing the hidden included file changes the signature
+	intro := filepath.Join(tmpDir, ".shared", "intro.md")
+	if err := os.WriteFile(intro, []byte("Welcome home.\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(intro, later, later); err != nil {
+		t.Fatal(err)
+	}
+	stamp := treeSignature(tmpDir, nil)
+	if includesSignature(stamp, tree.includes) == tree.stamp {
+		t.Error("includesSignature() should change when an included file changes")
+	}
+	tree.refresh()
+	if backlinks := tree.backlinks("guide.md"); strings.Contains(string(backlinks), "Home") {
+		t.Errorf("backlinks() = %q, should be refreshed", backlinks)
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bytes"
 	"flag"
 	"fmt"
 	"html/template"
@@ -8,13 +9,11 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
+	"time"
 	"unicode/utf8"
-
-	"github.com/gomarkdown/markdown"
-	"github.com/gomarkdown/markdown/html"
-	"github.com/gomarkdown/markdown/parser"
 )
 
 const htmlTemplate = `<!DOCTYPE html>
@@ -24,9 +23,22 @@ const htmlTemplate = `<!DOCTYPE html>
     <meta name="viewport" content="width=device-width, initial-scale=1.0">
     <title>{{.Title}}</title>
     <link rel="stylesheet" href="{{.StylePath}}">
-</head>
+    <script src="/__godown/assets/godown.js" defer></script>
+    {{.Feeds}}</head>
 <body>
-    {{.Content}}
+    {{if .Sidebar}}<nav class="godown-sidebar">
+    {{.Sidebar}}</nav>
+    {{end}}{{if .Views}}<nav class="godown-views">
+    {{.Views}}</nav>
+    {{end}}{{.Content}}
+    {{if .Tags}}<nav class="godown-page-tags">
+    {{.Tags}}</nav>
+    {{end}}{{if .Backlinks}}<aside class="godown-backlinks">
+    {{.Backlinks}}</aside>
+    {{end}}{{if .PageMeta}}<footer class="godown-page-meta">{{.PageMeta}}</footer>
+    {{end}}{{if .Pagination}}<nav class="godown-pagination">
+    {{.Pagination}}</nav>
+    {{end}}
 </body>
 </html>`
 
@@ -87,6 +99,12 @@ pre code {
     padding: 0;
 }
 
+/* Highlighted code keeps the block of the page */
+body pre code.hljs {
+    background: none;
+    padding: 0;
+}
+
 a {
     color: var(--link-color);
     text-decoration: none;
@@ -126,6 +144,652 @@ img {
     max-width: 100%;
     height: auto;
 }
+
+/* GitHub alerts */
+.godown-alert {
+    padding: 8px 16px;
+    color: inherit;
+    border-left-color: var(--alert-color);
+}
+
+.godown-alert-title {
+    font-weight: bold;
+    color: var(--alert-color);
+}
+
+.godown-alert-note {
+    --alert-color: #0969da;
+}
+
+.godown-alert-tip {
+    --alert-color: #1a7f37;
+}
+
+.godown-alert-important {
+    --alert-color: #8250df;
+}
+
+.godown-alert-warning {
+    --alert-color: #9a6700;
+}
+
+.godown-alert-caution {
+    --alert-color: #cf222e;
+}
+
+/* Task lists */
+li:has(> .godown-task),
+li:has(> p:first-child > .godown-task) {
+    list-style: none;
+}
+
+.godown-task {
+    margin: 0 4px 0 -20px;
+}
+
+/* Footnotes */
+.footnotes {
+    margin-top: 40px;
+    font-size: 14px;
+    color: var(--quote-text);
+}
+
+.footnotes hr {
+    border: none;
+    border-top: 1px solid var(--border-color);
+}
+
+.footnote-ref a,
+.footnote-return {
+    padding: 0 2px;
+}
+
+/* Mermaid diagrams */
+pre.mermaid {
+    background: none;
+    border: none;
+    text-align: center;
+}
+
+/* Math formulas, TeX source until typeset */
+.godown-math-display {
+    display: block;
+    margin: 16px 0;
+    overflow-x: auto;
+    text-align: center;
+}
+
+/* Sidebar navigation */
+.godown-sidebar {
+    font-size: 14px;
+    margin-bottom: 24px;
+    padding-bottom: 12px;
+    border-bottom: 1px solid var(--border-color);
+}
+
+.godown-sidebar ul {
+    list-style: none;
+    margin: 0;
+    padding-left: 16px;
+}
+
+.godown-sidebar > ul {
+    padding-left: 0;
+}
+
+.godown-sidebar summary {
+    cursor: pointer;
+}
+
+.godown-sidebar a.active {
+    font-weight: bold;
+}
+
+/* Links to the presentations of a file */
+.godown-views {
+    display: flex;
+    justify-content: flex-end;
+    gap: 12px;
+    font-size: 13px;
+}
+
+.godown-views a.active {
+    font-weight: bold;
+}
+
+/* Tags */
+.godown-page-tags {
+    margin-top: 30px;
+}
+
+.godown-tag {
+    display: inline-block;
+    margin: 0 4px 4px 0;
+    padding: 2px 10px;
+    border-radius: 12px;
+    background: var(--code-bg);
+    border: 1px solid var(--border-color);
+    font-size: 13px;
+}
+
+.godown-tag::before {
+    content: "#";
+    color: var(--quote-text);
+}
+
+.godown-tags {
+    list-style: none;
+    padding-left: 0;
+}
+
+.godown-tag-count {
+    color: var(--quote-text);
+    font-size: 13px;
+}
+
+.godown-tagged p {
+    margin: 2px 0 10px;
+    color: var(--quote-text);
+}
+
+/* Wiki links and the pages linking to the current one */
+.godown-wikilink-missing {
+    color: var(--quote-text);
+    border-bottom: 1px dashed var(--quote-text);
+    cursor: help;
+}
+
+.godown-backlinks {
+    margin-top: 40px;
+    padding-top: 10px;
+    border-top: 1px solid var(--border-color);
+    font-size: 14px;
+}
+
+.godown-backlinks h2 {
+    font-size: 16px;
+    border: none;
+}
+
+/* Last update of the page */
+.godown-page-meta {
+    margin-top: 40px;
+    font-size: 13px;
+    color: var(--quote-text);
+}
+
+/* File information banner */
+.godown-file-info {
+    margin-bottom: 20px;
+    padding: 10px;
+    background: var(--code-bg);
+    border-radius: 5px;
+    border: 1px solid var(--border-color);
+}
+
+/* Binary format summary */
+.godown-format {
+    margin-bottom: 20px;
+    font-size: 14px;
+}
+
+.godown-format table {
+    width: auto;
+    margin: 8px 0;
+}
+
+.godown-format th,
+.godown-format td {
+    padding: 2px 8px;
+}
+
+.godown-format summary {
+    cursor: pointer;
+}
+
+/* Hex viewer navigation */
+.godown-hex-nav,
+.godown-table-nav {
+    display: flex;
+    flex-wrap: wrap;
+    align-items: center;
+    gap: 12px;
+    margin: 12px 0;
+}
+
+.godown-hex-nav form {
+    margin-left: auto;
+}
+
+/* Hex dump */
+.godown-hexdump {
+    font-family: 'Courier New', monospace;
+    font-size: 12px;
+    overflow-x: auto;
+    padding: 10px;
+    background: var(--code-bg);
+    border-radius: 3px;
+}
+
+.godown-hexdump > div {
+    white-space: pre;
+}
+
+.godown-hexdump-header,
+.godown-hexdump .offset {
+    color: var(--quote-text);
+}
+
+.godown-hexdump mark {
+    background: rgba(255, 200, 0, 0.5);
+    color: inherit;
+}
+
+/* Hex viewer display options and search */
+.godown-hex-controls {
+    display: flex;
+    flex-wrap: wrap;
+    align-items: center;
+    gap: 12px;
+    margin: 12px 0;
+    font-size: 14px;
+}
+
+.godown-hex-search {
+    display: flex;
+    flex-wrap: wrap;
+    align-items: baseline;
+    gap: 12px;
+    margin: 12px 0;
+}
+
+.godown-hex-search details {
+    flex-basis: 100%;
+}
+
+.godown-hex-search ul {
+    columns: 4 10em;
+    font-family: 'Courier New', monospace;
+}
+
+/* Git history and diffs */
+.godown-history td {
+    vertical-align: top;
+}
+
+.godown-text {
+    white-space: pre-wrap;
+    word-wrap: break-word;
+}
+
+.godown-text-truncated {
+    padding: 8px 12px;
+    border-left: 4px solid var(--quote-border);
+    background: var(--code-bg);
+}
+
+.godown-diff .del,
+.godown-diff-del {
+    background: rgba(248, 81, 73, 0.15);
+}
+
+.godown-diff .ins,
+.godown-diff-ins {
+    background: rgba(46, 160, 67, 0.15);
+}
+
+.godown-diff span {
+    display: block;
+}
+
+.godown-diff-del,
+.godown-diff-ins {
+    padding: 0 8px;
+    border-left: 4px solid;
+}
+
+.godown-diff-del {
+    border-color: rgb(248, 81, 73);
+    text-decoration: line-through;
+}
+
+.godown-diff-ins {
+    border-color: rgb(46, 160, 67);
+}
+
+/* Previous/next page links */
+.godown-pagination {
+    display: flex;
+    justify-content: space-between;
+    margin-top: 40px;
+    padding-top: 16px;
+    border-top: 1px solid var(--border-color);
+}
+
+.godown-pagination .next {
+    margin-left: auto;
+}
+
+/* Single-page book */
+.godown-book-toc ol {
+    columns: 2;
+}
+
+.godown-chapter {
+    margin-top: 60px;
+}
+
+/* CSV and TSV tables */
+.godown-table {
+    overflow-x: auto;
+}
+
+.godown-table-info {
+    color: var(--quote-text);
+    font-size: 0.9em;
+}
+
+.godown-table-controls {
+    display: flex;
+    gap: 8px;
+    align-items: center;
+    margin-bottom: 8px;
+}
+
+.godown-table-controls input {
+    flex: 1;
+}
+
+.godown-csv th {
+    cursor: pointer;
+    white-space: nowrap;
+}
+
+.godown-csv th[aria-sort="ascending"]::after {
+    content: " \25B2";
+}
+
+.godown-csv th[aria-sort="descending"]::after {
+    content: " \25BC";
+}
+
+.godown-csv td.godown-number {
+    text-align: right;
+    font-variant-numeric: tabular-nums;
+}
+
+/* JSON, YAML and TOML trees */
+.godown-data-info {
+    color: var(--quote-text);
+    font-size: 0.9em;
+}
+
+.godown-data-error {
+    padding: 8px 12px;
+    border-left: 4px solid #d73a49;
+    background: var(--code-bg);
+    margin-bottom: 16px;
+}
+
+.godown-data-tree,
+.godown-data-tree ul {
+    list-style: none;
+    margin: 0;
+    padding-left: 20px;
+    font-family: 'Courier New', monospace;
+}
+
+.godown-data-tree {
+    padding-left: 0;
+}
+
+.godown-data-tree summary {
+    cursor: pointer;
+}
+
+.godown-data-key {
+    color: var(--link-color);
+}
+
+.godown-data-index,
+.godown-data-size {
+    color: var(--quote-text);
+}
+
+.godown-data-string {
+    color: #22863a;
+}
+
+.godown-data-number,
+.godown-data-bool,
+.godown-data-null,
+.godown-data-date {
+    color: #b35900;
+}
+
+.godown-pointer {
+    visibility: hidden;
+    padding: 0 4px;
+    border: 1px solid var(--border-color);
+    border-radius: 3px;
+    background: none;
+    color: var(--quote-text);
+    font-size: 0.8em;
+    cursor: pointer;
+}
+
+.godown-data-tree li:hover > .godown-pointer,
+.godown-data-tree summary:hover > .godown-pointer,
+.godown-pointer:focus {
+    visibility: visible;
+}
+
+.godown-pointer.copied {
+    visibility: visible;
+    color: #22863a;
+    border-color: #22863a;
+}
+
+.godown-text mark {
+    display: inline-block;
+    width: 100%;
+}
+
+/* Jupyter notebooks */
+.godown-cell {
+    margin: 16px 0;
+}
+
+.godown-cell-input,
+.godown-cell-output {
+    display: flex;
+    gap: 8px;
+}
+
+.godown-cell-input pre,
+.godown-output {
+    flex: 1;
+    min-width: 0;
+    margin: 0;
+}
+
+.godown-cell-output {
+    margin-top: 8px;
+}
+
+.godown-prompt {
+    flex: 0 0 60px;
+    color: var(--quote-text);
+    font-family: 'Courier New', monospace;
+    font-size: 0.85em;
+    text-align: right;
+    padding-top: 15px;
+}
+
+.godown-output pre {
+    margin: 0;
+    background: none;
+    border: none;
+    padding: 0 15px;
+}
+
+.godown-output img {
+    max-width: 100%;
+}
+
+.godown-output-html {
+    overflow-x: auto;
+}
+
+.godown-output-stderr,
+.godown-output-error {
+    background: rgba(215, 58, 73, 0.1) !important;
+}
+
+/* Image galleries */
+.godown-gallery-info {
+    color: var(--quote-text);
+}
+
+.godown-gallery {
+    display: grid;
+    grid-template-columns: repeat(auto-fill, minmax(180px, 1fr));
+    gap: 12px;
+    margin: 16px 0;
+}
+
+.godown-gallery-item {
+    display: flex;
+    flex-direction: column;
+    border: 1px solid var(--border-color);
+    border-radius: 6px;
+    overflow: hidden;
+    background: var(--code-bg);
+    color: var(--text-color);
+    text-decoration: none;
+}
+
+.godown-gallery-item img {
+    width: 100%;
+    aspect-ratio: 4 / 3;
+    object-fit: contain;
+}
+
+.godown-gallery-item span {
+    padding: 4px 8px;
+    font-size: 0.85em;
+    overflow: hidden;
+    text-overflow: ellipsis;
+    white-space: nowrap;
+    border-top: 1px solid var(--border-color);
+}
+
+.godown-gallery-item:hover {
+    border-color: var(--link-color);
+}
+
+.godown-lightbox {
+    position: fixed;
+    inset: 0;
+    z-index: 100;
+    display: flex;
+    align-items: center;
+    justify-content: center;
+    background: rgba(0, 0, 0, 0.9);
+}
+
+.godown-lightbox[hidden] {
+    display: none;
+}
+
+.godown-lightbox img {
+    max-width: 90vw;
+    max-height: 85vh;
+}
+
+.godown-lightbox-caption {
+    position: absolute;
+    bottom: 16px;
+    color: #fff;
+}
+
+.godown-lightbox button {
+    position: absolute;
+    background: none;
+    border: none;
+    color: #fff;
+    font-size: 2.5em;
+    cursor: pointer;
+    padding: 16px;
+}
+
+.godown-lightbox-previous {
+    left: 0;
+}
+
+.godown-lightbox-next {
+    right: 0;
+}
+
+.godown-lightbox-close {
+    top: 0;
+    right: 0;
+}
+
+/* Printing: content only, the book chapters on new pages */
+@media print {
+    body {
+        max-width: none;
+        margin: 0;
+        color: #000;
+        background: #fff;
+    }
+
+    .godown-sidebar,
+    .godown-views,
+    .godown-pagination,
+    .godown-page-meta,
+    .godown-page-tags,
+    .godown-backlinks {
+        display: none;
+    }
+
+    .godown-chapter {
+        margin-top: 0;
+        break-before: page;
+    }
+
+    h1, h2, h3, h4, h5, h6 {
+        break-after: avoid;
+    }
+
+    pre, blockquote, table, img, figure {
+        break-inside: avoid;
+    }
+
+    a {
+        color: inherit;
+    }
+}
+
+@media (min-width: 1200px) {
+    body:has(> .godown-sidebar) {
+        margin-left: 320px;
+    }
+
+    .godown-sidebar {
+        position: fixed;
+        top: 0;
+        bottom: 0;
+        left: 0;
+        width: 260px;
+        margin: 0;
+        padding: 20px;
+        overflow-y: auto;
+        border-bottom: none;
+        border-right: 1px solid var(--border-color);
+    }
+}
 `
 
 var (
@@ -136,21 +800,21 @@ var (
 )
 
 type PageData struct {
-	Title     string
-	Content   template.HTML
-	StylePath string
+	Title      string
+	Content    template.HTML
+	StylePath  string
+	Sidebar    template.HTML
+	Pagination template.HTML
+	PageMeta   template.HTML
+	Views      template.HTML
+	Backlinks  template.HTML
+	Tags       template.HTML
+	Feeds      template.HTML
 }
 
+// mdToHTML converts Markdown to HTML with the options of the site
 func mdToHTML(md []byte) []byte {
-	extensions := parser.CommonExtensions | parser.AutoHeadingIDs | parser.Tables | parser.FencedCode
-	p := parser.NewWithExtensions(extensions)
-	doc := p.Parse(md)
-
-	htmlFlags := html.CommonFlags | html.HrefTargetBlank
-	opts := html.RendererOptions{Flags: htmlFlags}
-	renderer := html.NewRenderer(opts)
-
-	return markdown.Render(doc, renderer)
+	return renderMarkdown(md, siteMarkdown, nil)
 }
 
 // isMediaFile checks if the file is a media file (image, svg, video) or a static file
@@ -210,57 +874,6 @@ func isTextFile(filePath string) bool {
 	return true
 }
 
-// formatBinaryAsHex formats binary data in a hexdump-like format (similar to od -A x -t x1z)
-// It displays: offset | hex bytes (16 per line) | ASCII representation
-func formatBinaryAsHex(data []byte) string {
-	var result strings.Builder
-	const bytesPerLine = 16
-
-	result.WriteString("<div style=\"font-family: 'Courier New', monospace; font-size: 12px;\">\n")
-	result.WriteString("<div style=\"color: #666; margin-bottom: 8px;\">")
-	result.WriteString("Offset&nbsp;&nbsp; 00 01 02 03 04 05 06 07 08 09 0A 0B 0C 0D 0E 0F  ASCII</br>")
-	result.WriteString("--------  -----------------------------------------------  ----------------</div>\n")
-
-	for i := 0; i < len(data); i += bytesPerLine {
-		// Offset
-		result.WriteString(fmt.Sprintf("<div><span style=\"color: #0366d6;\">%08x</span>  ", i))
-
-		// Hex bytes
-		end := i + bytesPerLine
-		if end > len(data) {
-			end = len(data)
-		}
-
-		// Print hex values
-		for j := i; j < end; j++ {
-			result.WriteString(fmt.Sprintf("%02x ", data[j]))
-		}
-
-		// Pad if less than 16 bytes
-		for j := end; j < i+bytesPerLine; j++ {
-			result.WriteString("   ")
-		}
-
-		result.WriteString(" ")
-
-		// ASCII representation
-		for j := i; j < end; j++ {
-			b := data[j]
-			if b >= 32 && b <= 126 {
-				// Escape HTML special characters
-				result.WriteString(template.HTMLEscapeString(string(b)))
-			} else {
-				result.WriteString(".")
-			}
-		}
-
-		result.WriteString("</div>\n")
-	}
-
-	result.WriteString("</div>")
-	return result.String()
-}
-
 // getContentType returns the appropriate Content-Type for a file
 func getContentType(path string) string {
 	ext := strings.ToLower(filepath.Ext(path))
@@ -329,79 +942,62 @@ func serveMedia(w http.ResponseWriter, r *http.Request, filePath string) {
 	}
 }
 
-// serveTextFile serves a text file wrapped in HTML
+// maxTextSize is the size of the beginning of a file shown as text, larger
+// files being truncated
+const maxTextSize = 1 << 20
+
+// serveTextFile serves a text file wrapped in HTML, at most its first
+// maxTextSize bytes
 func serveTextFile(w http.ResponseWriter, r *http.Request, filePath string) {
-	content, err := os.ReadFile(filePath)
+	file, err := os.Open(filePath)
 	if err != nil {
 		http.NotFound(w, r)
 		return
 	}
-
-	// Escape HTML special characters to prevent XSS
-	escapedContent := template.HTMLEscapeString(string(content))
-
-	// Wrap the text content in a <pre> tag to preserve formatting
-	htmlContent := "<pre style=\"white-space: pre-wrap; word-wrap: break-word;\">" + escapedContent + "</pre>"
-
-	title := filepath.Base(filePath)
-
-	data := PageData{
-		Title:     title,
-		Content:   template.HTML(htmlContent),
-		StylePath: "/__godown_style.css",
-	}
-
-	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	if err := tmpl.Execute(w, data); err != nil {
-		log.Printf("Template error: %v", err)
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		http.NotFound(w, r)
+		return
 	}
-}
-
-// serveBinaryFile serves a binary file with hexadecimal dump display
-func serveBinaryFile(w http.ResponseWriter, r *http.Request, filePath string) {
-	content, err := os.ReadFile(filePath)
+	content, err := io.ReadAll(io.LimitReader(file, maxTextSize))
 	if err != nil {
 		http.NotFound(w, r)
 		return
 	}
 
-	// Limit display to first 64KB for performance
-	const maxDisplaySize = 64 * 1024
-	displayContent := content
-	var truncated bool
-	if len(content) > maxDisplaySize {
-		displayContent = content[:maxDisplaySize]
-		truncated = true
+	// Truncated files end with their last whole line
+	notice := ""
+	if info.Size() > int64(len(content)) {
+		if end := bytes.LastIndexByte(content, '\n'); end >= 0 {
+			content = content[:end+1]
+		}
+		notice = fmt.Sprintf("<p class=\"godown-text-truncated\">Showing the first %s of %s, the <a href=\"?view=hex\">hex view</a> browses the whole file and <a href=\"?raw\">?raw</a> downloads it.</p>\n",
+			formatBytes(int64(len(content))), formatBytes(info.Size()))
 	}
 
-	// Format as hexdump
-	hexDump := formatBinaryAsHex(displayContent)
+	// Escape HTML special characters to prevent XSS, replacing the invalid
+	// UTF-8 sequences of binary files shown as text
+	escapedContent := template.HTMLEscapeString(strings.ToValidUTF8(string(content), "\uFFFD"))
 
-	// Add file info header
-	fileInfo := fmt.Sprintf("<div style=\"margin-bottom: 20px; padding: 10px; background: var(--code-bg); border-radius: 5px; border: 1px solid var(--border-color);\">\n")
-	fileInfo += fmt.Sprintf("<strong>File:</strong> %s<br>\n", template.HTMLEscapeString(filepath.Base(filePath)))
-	fileInfo += fmt.Sprintf("<strong>Size:</strong> %s bytes", formatBytes(int64(len(content))))
-	if truncated {
-		fileInfo += fmt.Sprintf(" (showing first %s)", formatBytes(int64(len(displayContent))))
-	}
-	fileInfo += "\n</div>\n"
+	// Wrap the text content in a <pre> tag to preserve formatting
+	htmlContent := notice + "<pre class=\"godown-text\">" + escapedContent + "</pre>"
 
-	htmlContent := fileInfo + hexDump
+	title := filepath.Base(filePath)
 
-	title := filepath.Base(filePath) + " (binary)"
+	view := fileView(r, filePath)
+	if view == viewSource {
+		title += " (source)"
+	}
 
 	data := PageData{
 		Title:     title,
 		Content:   template.HTML(htmlContent),
 		StylePath: "/__godown_style.css",
+		Views:     template.HTML(viewLinks(r, filePath, view)),
 	}
 
-	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	if err := tmpl.Execute(w, data); err != nil {
-		log.Printf("Template error: %v", err)
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-	}
+	renderPage(w, data)
 }
 
 // formatBytes formats a byte count in human-readable format
@@ -418,66 +1014,152 @@ func formatBytes(bytes int64) string {
 	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
 }
 
-func serveMarkdown(w http.ResponseWriter, r *http.Request) {
-	path := r.URL.Path
-	if path == "/" {
-		path = "/" + indexFile
+// resolveFilePath maps a cleaned request path to the file to serve in the
+// root directory: media and existing files as is, else the Markdown page
+// with the .md extension added, else the README of the directory
+func resolveFilePath(root, path string) (string, bool) {
+	filePath := filepath.Join(root, path)
+	if isMediaFile(filePath) {
+		return filePath, true
 	}
 
+	// If not a .md file, check if the file exists as-is
+	if !strings.HasSuffix(path, ".md") {
+		if info, err := os.Stat(filePath); err == nil && !info.IsDir() {
+			return filePath, true
+		}
+		// File doesn't exist with original name, try with .md extension
+		filePath += ".md"
+	}
+
+	if info, err := os.Stat(filePath); err == nil && !info.IsDir() {
+		return filePath, true
+	}
+
+	// Try without .md for directories
+	readmePath := filepath.Join(strings.TrimSuffix(filePath, ".md"), "README.md")
+	if _, err := os.Stat(readmePath); err == nil {
+		return readmePath, true
+	}
+	return "", false
+}
+
+func serveMarkdown(w http.ResponseWriter, r *http.Request) {
 	// Clean the path
-	path = filepath.Clean(path)
+	urlPath := path.Clean("/" + r.URL.Path)
 
-	// Check if the requested file is a media file
-	filePath := filepath.Join(".", path)
-	if isMediaFile(filePath) {
-		serveMedia(w, r, filePath)
+	// The root page lists the mount points
+	if urlPath == "/" && len(mounts) > 0 {
+		serveLanding(w, r)
 		return
 	}
 
-	// Check if file exists with unknown extension
-	// If not a .md file, check if the file exists as-is
-	originalFilePath := filePath
-	if !strings.HasSuffix(path, ".md") {
-		// Check if file exists with original extension
-		if _, err := os.Stat(originalFilePath); err == nil {
-			// File exists, check if it's a text file
-			if isTextFile(originalFilePath) {
-				serveTextFile(w, r, originalFilePath)
-				return
-			}
-			// Not a text file, serve as binary with hex dump
-			serveBinaryFile(w, r, originalFilePath)
-			return
+	m, rel := findMount(urlPath)
+	if m == nil {
+		http.NotFound(w, r)
+		return
+	}
+	if m.Prefix != "" && r.URL.Path == m.Prefix {
+		// Relative links of the index page need the trailing slash
+		target := m.Prefix + "/"
+		if r.URL.RawQuery != "" {
+			target += "?" + r.URL.RawQuery
 		}
-		// File doesn't exist with original name, try with .md extension
-		path += ".md"
-		filePath = filepath.Join(".", path)
+		http.Redirect(w, r, target, http.StatusMovedPermanently)
+		return
+	}
+	dir := filepath.Join(m.Root, rel)
+	if rel == "/" {
+		rel = "/" + m.Index
 	}
 
-	// Read the file
-	content, err := os.ReadFile(filePath)
-	if err != nil {
-		// Try without .md for directories
-		dirPath := strings.TrimSuffix(filePath, ".md")
-		readmePath := filepath.Join(dirPath, "README.md")
-		content, err = os.ReadFile(readmePath)
-		if err != nil {
+	// Atom and RSS feeds of the feed directories
+	if serveFeed(w, r, m, urlPath) {
+		return
+	}
+
+	filePath, ok := resolveFilePath(m.Root, rel)
+	if !ok {
+		// Directories of images without README are shown as a gallery
+		if !serveGallery(w, r, dir, urlPath) {
 			http.NotFound(w, r)
-			return
 		}
-		filePath = readmePath
+		return
+	}
+
+	// History, revision and diff views of the file
+	if serveGitView(w, r, m.Repo, filePath) {
+		return
+	}
+
+	// Original bytes of the file
+	if _, raw := r.URL.Query()["raw"]; raw {
+		serveRaw(w, r, filePath)
+		return
+	}
+
+	// Reduced images of the galleries
+	if _, thumb := r.URL.Query()["thumb"]; thumb && isThumbnailFile(filePath) {
+		serveThumbnail(w, r, filePath)
+		return
+	}
+
+	switch fileView(r, filePath) {
+	case viewMedia:
+		serveMedia(w, r, filePath)
+	case viewTable:
+		serveTableFile(w, r, filePath)
+	case viewData:
+		serveDataFile(w, r, filePath)
+	case viewNotebook:
+		serveNotebookFile(w, r, filePath)
+	case viewText, viewSource:
+		serveTextFile(w, r, filePath)
+	case viewHex:
+		serveBinaryFile(w, r, filePath)
+	default:
+		serveMarkdownFile(w, r, m, filePath)
+	}
+}
+
+// serveMarkdownFile renders a Markdown file of a mount point as an HTML page
+func serveMarkdownFile(w http.ResponseWriter, r *http.Request, m *mount, filePath string) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		http.NotFound(w, r)
+		return
 	}
 
 	// Convert and render
-	htmlContent := mdToHTML(content)
+	meta, body := splitFrontMatter(content)
+	body, _ = expandIncludes(body, filePath, m.Root)
+	htmlContent := renderMarkdown(body, pageMarkdownOptions(meta), m.Tree)
 	title := filepath.Base(filePath)
+	if meta.Title != "" {
+		title = meta.Title
+	}
 
 	data := PageData{
 		Title:     title,
 		Content:   template.HTML(htmlContent),
 		StylePath: "/__godown_style.css",
+		Views:     template.HTML(viewLinks(r, filePath, viewMarkdown)),
+		Tags:      pageTags(meta.Tags),
+		Feeds:     feedLinks(path.Clean("/" + r.URL.Path)),
+	}
+	data.PageMeta = pageMetadata(m.Repo, filePath)
+	if m.Tree != nil {
+		current := m.relPath(filePath)
+		data.Sidebar = m.Tree.sidebar(current)
+		data.Pagination = m.Tree.pagination(current)
+		data.Backlinks = m.Tree.backlinks(current)
 	}
 
+	renderPage(w, data)
+}
+
+// renderPage writes a page using the HTML template
+func renderPage(w http.ResponseWriter, data PageData) {
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 	if err := tmpl.Execute(w, data); err != nil {
 		log.Printf("Template error: %v", err)
@@ -485,11 +1167,34 @@ func serveMarkdown(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// commands are the subcommands of godown, which serves the documentation
+// without one
+var commands = map[string]func(args []string) error{
+	"check": runCheck,
+	"epub":  runEPUB,
+}
+
 func main() {
+	if len(os.Args) > 1 {
+		if command, ok := commands[os.Args[1]]; ok {
+			if err := command(os.Args[2:]); err != nil {
+				log.Fatalf("Error: %v", err)
+			}
+			return
+		}
+	}
+
 	// Define flags
 	portFlag := flag.String("port", defaultPort, "HTTP server port (or PORT env var)")
 	styleFlag := flag.String("style", "", "Custom CSS file path (or STYLE env var)")
 	indexFlag := flag.String("index", "README.md", "Default index file (or INDEX env var)")
+	extensionsFlag := flag.String("extensions", "", "Markdown parser extensions to enable, or disable with a - prefix, comma-separated (or EXTENSIONS env var)")
+	htmlFlagsFlag := flag.String("html-flags", "", "HTML renderer flags to enable, or disable with a - prefix, comma-separated (or HTML_FLAGS env var)")
+	var mountFlags mountList
+	flag.Var(&mountFlags, "mount", "Serve a directory under a URL prefix: /prefix=path[,index=FILE] (repeatable, or MOUNTS env var separated by ;)")
+	feedsFlag := flag.String("feeds", "", "URL paths of the directories with Atom and RSS feeds, comma-separated (or FEEDS env var)")
+	baseURLFlag := flag.String("base-url", "", "Absolute URL of the site used in feeds and the sitemap, default from the request (or BASE_URL env var)")
+	robotsFlag := flag.String("robots", "", "robots.txt file to serve instead of the generated one (or ROBOTS env var)")
 	flag.Parse()
 
 	// Priority: environment variable > flag > default
@@ -508,6 +1213,26 @@ func main() {
 		indexFile = *indexFlag
 	}
 
+	extensions := os.Getenv("EXTENSIONS")
+	if extensions == "" {
+		extensions = *extensionsFlag
+	}
+	htmlFlags := os.Getenv("HTML_FLAGS")
+	if htmlFlags == "" {
+		htmlFlags = *htmlFlagsFlag
+	}
+	var err error
+	siteMarkdown, err = defaultMarkdownOptions.with(splitNames(extensions), splitNames(htmlFlags))
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+
+	// Binaries built without `just assets` show diagrams, formulas and code
+	// as source
+	for _, name := range missingLibraries(assetFS) {
+		log.Printf("Warning: %s is not embedded in this binary, run `just assets` before building", name)
+	}
+
 	// Display CSS mode
 	if customStylePath == "" {
 		log.Printf("Using embedded CSS")
@@ -515,8 +1240,58 @@ func main() {
 		log.Printf("Using custom CSS: %s", customStylePath)
 	}
 
+	feeds := os.Getenv("FEEDS")
+	if feeds == "" {
+		feeds = *feedsFlag
+	}
+	feedDirs = parseFeedDirs(splitNames(feeds))
+
+	baseURL = os.Getenv("BASE_URL")
+	if baseURL == "" {
+		baseURL = *baseURLFlag
+	}
+
+	robotsFile = os.Getenv("ROBOTS")
+	if robotsFile == "" {
+		robotsFile = *robotsFlag
+	}
+
+	mountValues := []string(mountFlags)
+	if env := os.Getenv("MOUNTS"); env != "" {
+		mountValues = strings.Split(env, ";")
+	}
+	mounts, err = parseMounts(mountValues, indexFile)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+
+	if len(mounts) == 0 {
+		// Git metadata of the pages
+		siteRepo = openGitRepo(".")
+		if siteRepo != nil {
+			log.Printf("Git repository: %s", siteRepo.workTree)
+		}
+
+		// Build the navigation tree and keep it up to date
+		siteTree = newDocTree(".", "", indexFile)
+		go siteTree.watch(2 * time.Second)
+	}
+
+	for _, m := range mounts {
+		m.Repo = openGitRepo(m.Root)
+		m.Tree = newDocTree(m.Root, m.Prefix, m.Index)
+		go m.Tree.watch(2 * time.Second)
+		log.Printf("Mount %s: %s (index: %s)", m.Prefix, m.Root, m.Index)
+	}
+
 	// Routes
 	http.HandleFunc("/__godown_style.css", serveCSS)
+	http.HandleFunc(assetPrefix, serveAsset)
+	http.HandleFunc("/sitemap.xml", serveSitemap)
+	http.HandleFunc("/robots.txt", serveRobots)
+	http.HandleFunc(bookPath, serveBook)
+	http.HandleFunc(tagsPath, serveTags)
+	http.HandleFunc(tagsPath+"/", serveTags)
 	http.HandleFunc("/", serveMarkdown)
 
 	log.Printf("Serving Markdown files on http://localhost:%s", port)
@@ -34,7 +34,7 @@ func TestMdToHTML(t *testing.T) {
 		{
 			name:     "Link",
 			input:    "[Google](https://google.com)",
-			contains: `<p><a href="https://google.com" target=`,
+			contains: `<p><a href="https://google.com">Google</a></p>`,
 		},
 		{
 			name:     "Code block",
@@ -510,6 +510,29 @@ func TestServeTextFile(t *testing.T) {
 	}
 }
 
+// Test the truncation of large files shown as text
+func TestServeTextFileTruncated(t *testing.T) {
+	tmpDir := t.TempDir()
+	txtFile := filepath.Join(tmpDir, "large.txt")
+	line := strings.Repeat("x", 99) + "\n"
+	if err := os.WriteFile(txtFile, []byte(strings.Repeat(line, maxTextSize/len(line)+10)), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	serveTextFile(w, httptest.NewRequest("GET", "/large.txt", nil), txtFile)
+	body := w.Body.String()
+
+	if !strings.Contains(body, `<p class="godown-text-truncated">Showing the first`) {
+		t.Errorf("serveTextFile() should show a truncation notice")
+	}
+	text := body[strings.Index(body, `<pre class="godown-text">`):]
+	text = text[:strings.Index(text, "</pre>")]
+	if lines := strings.Count(text, "\n"); lines != maxTextSize/len(line) {
+		t.Errorf("serveTextFile() shows %d lines, want %d whole lines", lines, maxTextSize/len(line))
+	}
+}
+
 // Test binary file server
 func TestServeBinaryFile(t *testing.T) {
 	tmpDir := t.TempDir()
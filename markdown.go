@@ -0,0 +1,198 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/gomarkdown/markdown"
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/gomarkdown/markdown/html"
+	"github.com/gomarkdown/markdown/parser"
+	"gopkg.in/yaml.v3"
+)
+
+// parserExtensions are the parser extensions that can be enabled by name
+var parserExtensions = map[string]parser.Extensions{
+	"no-intra-emphasis":      parser.NoIntraEmphasis,
+	"tables":                 parser.Tables,
+	"fenced-code":            parser.FencedCode,
+	"autolink":               parser.Autolink,
+	"strikethrough":          parser.Strikethrough,
+	"lax-html-blocks":        parser.LaxHTMLBlocks,
+	"space-headings":         parser.SpaceHeadings,
+	"hard-line-breaks":       parser.HardLineBreak,
+	"non-blocking-space":     parser.NonBlockingSpace,
+	"footnotes":              parser.Footnotes,
+	"no-empty-line-before":   parser.NoEmptyLineBeforeBlock,
+	"heading-ids":            parser.HeadingIDs,
+	"titleblock":             parser.Titleblock,
+	"auto-heading-ids":       parser.AutoHeadingIDs,
+	"backslash-line-breaks":  parser.BackslashLineBreak,
+	"definition-lists":       parser.DefinitionLists,
+	"math":                   parser.MathJax,
+	"ordered-list-start":     parser.OrderedListStart,
+	"attributes":             parser.Attributes,
+	"superscript":            parser.SuperSubscript,
+	"empty-lines-break-list": parser.EmptyLinesBreakList,
+	"mmark":                  parser.Mmark,
+}
+
+// rendererFlags are the HTML renderer flags that can be enabled by name
+var rendererFlags = map[string]html.Flags{
+	"skip-html":             html.SkipHTML,
+	"skip-images":           html.SkipImages,
+	"skip-links":            html.SkipLinks,
+	"safelink":              html.Safelink,
+	"nofollow":              html.NofollowLinks,
+	"noreferrer":            html.NoreferrerLinks,
+	"noopener":              html.NoopenerLinks,
+	"target-blank":          html.HrefTargetBlank,
+	"footnote-return-links": html.FootnoteReturnLinks,
+	"smartypants":           html.CommonFlags,
+	"smartypants-angled":    html.SmartypantsAngledQuotes,
+	"smartypants-nbsp":      html.SmartypantsQuotesNBSP,
+	"toc":                   html.TOC,
+	"lazy-load-images":      html.LazyLoadImages,
+}
+
+// markdownOptions are the parser extensions and renderer flags of mdToHTML
+type markdownOptions struct {
+	Extensions parser.Extensions
+	Flags      html.Flags
+}
+
+// defaultMarkdownOptions render GitHub-like pages, links opening in the
+// same tab
+var defaultMarkdownOptions = markdownOptions{
+	Extensions: parser.CommonExtensions | parser.AutoHeadingIDs | parser.Tables | parser.FencedCode | parser.Footnotes,
+	Flags:      html.CommonFlags | html.FootnoteReturnLinks,
+}
+
+// siteMarkdown are the options of the site, set with -extensions and -html-flags
+var siteMarkdown = defaultMarkdownOptions
+
+// nameList is a list of option names, given as a YAML sequence or a
+// comma-separated string
+type nameList []string
+
+func (l *nameList) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*l = splitNames(node.Value)
+		return nil
+	}
+	var names []string
+	if err := node.Decode(&names); err != nil {
+		return err
+	}
+	*l = names
+	return nil
+}
+
+// splitNames splits a comma-separated list of names
+func splitNames(value string) []string {
+	var names []string
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// with returns the options with extensions and flags enabled, or disabled
+// when their name starts with "-"
+func (o markdownOptions) with(extensions, flags []string) (markdownOptions, error) {
+	for _, name := range extensions {
+		name, disable := strings.CutPrefix(strings.TrimPrefix(name, "+"), "-")
+		ext, ok := parserExtensions[name]
+		if !ok {
+			return o, fmt.Errorf("unknown Markdown extension %q (available: %s)", name, optionNames(parserExtensions))
+		}
+		if disable {
+			o.Extensions &^= ext
+		} else {
+			o.Extensions |= ext
+		}
+	}
+	for _, name := range flags {
+		name, disable := strings.CutPrefix(strings.TrimPrefix(name, "+"), "-")
+		flag, ok := rendererFlags[name]
+		if !ok {
+			return o, fmt.Errorf("unknown HTML flag %q (available: %s)", name, optionNames(rendererFlags))
+		}
+		if disable {
+			o.Flags &^= flag
+		} else {
+			o.Flags |= flag
+		}
+	}
+	return o, nil
+}
+
+// optionNames lists the names of the options, sorted
+func optionNames[T any](options map[string]T) string {
+	names := make([]string, 0, len(options))
+	for name := range options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// xhtml returns the options rendering well-formed XML
+func (o markdownOptions) xhtml() markdownOptions {
+	o.Flags |= html.UseXHTML
+	return o
+}
+
+// pageMarkdownOptions returns the site options overridden by the front
+// matter of a page
+func pageMarkdownOptions(meta frontMatter) markdownOptions {
+	opts, err := siteMarkdown.with(meta.Extensions, meta.HTMLFlags)
+	if err != nil {
+		log.Printf("Invalid front matter options: %v", err)
+		return siteMarkdown
+	}
+	return opts
+}
+
+// renderMarkdown converts Markdown to HTML with the given options, resolving
+// the wiki links against the tree (left as text when nil)
+func renderMarkdown(md []byte, opts markdownOptions, tree *docTree) []byte {
+	return markdown.Render(parseMarkdown(md, opts, tree), newRenderer(opts))
+}
+
+// parseMarkdown parses Markdown with the given options and applies the
+// godown extensions to the document
+func parseMarkdown(md []byte, opts markdownOptions, tree *docTree) ast.Node {
+	p := parser.NewWithExtensions(opts.Extensions)
+	doc := p.Parse(md)
+
+	wikiTransform(doc, tree)
+
+	// GitHub alerts, task lists, emoji and www autolinks
+	gfmTransform(doc)
+	mathTransform(doc)
+	return doc
+}
+
+// newRenderer returns the HTML renderer of the given options
+func newRenderer(opts markdownOptions) *html.Renderer {
+	return html.NewRenderer(html.RendererOptions{
+		Flags:                      opts.Flags,
+		FootnoteReturnLinkContents: "&#8617;",
+		RenderNodeHook:             renderNodeHook,
+	})
+}
+
+// renderNodeHook renders the nodes drawn in the browser: Mermaid diagrams
+// and math formulas
+func renderNodeHook(w io.Writer, node ast.Node, entering bool) (ast.WalkStatus, bool) {
+	if status, handled := renderMermaid(w, node, entering); handled {
+		return status, true
+	}
+	return renderMath(w, node, entering)
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gomarkdown/markdown/html"
+	"github.com/gomarkdown/markdown/parser"
+)
+
+// Test enabling and disabling options by name
+func TestMarkdownOptionsWith(t *testing.T) {
+	opts, err := defaultMarkdownOptions.with([]string{"hard-line-breaks", "-tables"}, []string{"target-blank", "+nofollow", "-smartypants"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.Extensions&parser.HardLineBreak == 0 || opts.Extensions&parser.Tables != 0 {
+		t.Errorf("extensions = %b, want hard line breaks without tables", opts.Extensions)
+	}
+	if opts.Flags&html.HrefTargetBlank == 0 || opts.Flags&html.NofollowLinks == 0 || opts.Flags&html.Smartypants != 0 {
+		t.Errorf("flags = %b, want target blank and nofollow without smartypants", opts.Flags)
+	}
+
+	if _, err := defaultMarkdownOptions.with([]string{"unknown"}, nil); err == nil {
+		t.Errorf("with() should reject unknown extensions")
+	}
+	if _, err := defaultMarkdownOptions.with(nil, []string{"-unknown"}); err == nil {
+		t.Errorf("with() should reject unknown flags")
+	}
+}
+
+// Test rendering with the configured options
+func TestRenderMarkdownOptions(t *testing.T) {
+	tests := []struct {
+		name       string
+		extensions []string
+		flags      []string
+		input      string
+		contains   string
+	}{
+		{"default links", nil, nil, "[Site](https://example.com)", `<a href="https://example.com">Site</a>`},
+		{"target blank", nil, []string{"target-blank"}, "[Site](https://example.com)", `<a href="https://example.com" target="_blank">`},
+		{"nofollow", nil, []string{"nofollow"}, "[Site](https://example.com)", `rel="nofollow"`},
+		{"smartypants", nil, nil, `"quoted" -- text`, "&ldquo;quoted&rdquo; &ndash; text"},
+		{"no smartypants", nil, []string{"-smartypants"}, `"quoted" -- text`, "&quot;quoted&quot; -- text"},
+		{"skip HTML", nil, []string{"skip-html"}, "before <b>bold</b> after", "before bold after"},
+		{"hard line breaks", []string{"hard-line-breaks"}, nil, "line one\nline two", "line one<br>\nline two"},
+		{"superscript", []string{"superscript"}, nil, "2^10^", "2<sup>10</sup>"},
+		{"definition lists", nil, nil, "Term\n: Definition", "<dt>Term</dt>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := defaultMarkdownOptions.with(tt.extensions, tt.flags)
+			if err != nil {
+				t.Fatal(err)
+			}
+			result := string(renderMarkdown([]byte(tt.input), opts, nil))
+			if !strings.Contains(result, tt.contains) {
+				t.Errorf("renderMarkdown() should contain %q, got:\n%s", tt.contains, result)
+			}
+		})
+	}
+}
+
+// Test per-page options from the front matter
+func TestServeMarkdownFrontMatterOptions(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+
+	writeTree(t, tmpDir, map[string]string{
+		"list.md":    "---\nextensions: [hard-line-breaks]\nhtml_flags: target-blank, nofollow\n---\nline one\nline two\n\n[Site](https://example.com)",
+		"default.md": "line one\nline two\n\n[Site](https://example.com)",
+		"invalid.md": "---\nextensions: [unknown]\n---\nline one\nline two",
+	})
+
+	tests := []struct {
+		url        string
+		expected   []string
+		unexpected []string
+	}{
+		{"/list", []string{"line one<br>", `target="_blank"`, `rel="nofollow"`}, nil},
+		{"/default", []string{"line one\nline two"}, []string{"<br>", `target="_blank"`}},
+		{"/invalid", []string{"line one\nline two"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.url, nil)
+			w := httptest.NewRecorder()
+			serveMarkdown(w, req)
+
+			body := w.Body.String()
+			for _, expected := range tt.expected {
+				if !strings.Contains(body, expected) {
+					t.Errorf("body should contain %q, got:\n%s", expected, body)
+				}
+			}
+			for _, unexpected := range tt.unexpected {
+				if strings.Contains(body, unexpected) {
+					t.Errorf("body should not contain %q", unexpected)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"html/template"
+	"io"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gomarkdown/markdown/ast"
+)
+
+// isMathText checks if the content of an inline $...$ span is a formula.
+// As on GitHub, the dollars must hug the formula, so that amounts like
+// "$5 and $6" stay plain text.
+func isMathText(tex []byte) bool {
+	first, _ := utf8.DecodeRune(tex)
+	last, _ := utf8.DecodeLastRune(tex)
+	return len(tex) > 0 && !unicode.IsSpace(first) && !unicode.IsSpace(last)
+}
+
+// mathTransform turns the $$...$$ formulas written inside a paragraph,
+// parsed as a $...$ formula between two dollars, into display formulas
+func mathTransform(doc ast.Node) {
+	var formulas []*ast.Math
+	ast.WalkFunc(doc, func(node ast.Node, entering bool) ast.WalkStatus {
+		if math, ok := node.(*ast.Math); ok && entering {
+			formulas = append(formulas, math)
+		}
+		return ast.GoToNext
+	})
+
+	for _, math := range formulas {
+		prev, ok := ast.GetPrevNode(math).(*ast.Text)
+		if !ok || !bytes.HasSuffix(prev.Literal, []byte("$")) {
+			continue
+		}
+		next, ok := ast.GetNextNode(math).(*ast.Text)
+		if !ok || !bytes.HasPrefix(next.Literal, []byte("$")) {
+			continue
+		}
+		prev.Literal = prev.Literal[:len(prev.Literal)-1]
+		next.Literal = next.Literal[1:]
+
+		block := &ast.MathBlock{}
+		block.Literal = math.Literal
+		parent := math.GetParent()
+		children := parent.GetChildren()
+		for i, child := range children {
+			if child == math {
+				children[i] = block
+			}
+		}
+		block.SetParent(parent)
+	}
+}
+
+// renderMath renders $...$ and $$...$$ formulas as containers typeset in
+// the browser by the embedded KaTeX bundle. The TeX source stays readable
+// when scripts are disabled.
+func renderMath(w io.Writer, node ast.Node, entering bool) (ast.WalkStatus, bool) {
+	switch node := node.(type) {
+	case *ast.Math:
+		tex := template.HTMLEscapeString(string(node.Literal))
+		if !isMathText(node.Literal) {
+			io.WriteString(w, "$"+tex+"$")
+			return ast.GoToNext, true
+		}
+		io.WriteString(w, "<span class=\"godown-math\">"+tex+"</span>")
+		return ast.GoToNext, true
+	case *ast.MathBlock:
+		if !entering {
+			return ast.GoToNext, true
+		}
+		tex := template.HTMLEscapeString(string(node.Literal))
+		if _, inline := node.GetParent().(*ast.Paragraph); inline {
+			io.WriteString(w, "<span class=\"godown-math godown-math-display\">"+tex+"</span>")
+			return ast.SkipChildren, true
+		}
+		io.WriteString(w, "<div class=\"godown-math godown-math-display\">"+tex+"</div>\n")
+		return ast.SkipChildren, true
+	}
+	return ast.GoToNext, false
+}
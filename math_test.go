@@ -0,0 +1,77 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// Test math formulas rendering
+func TestMdToHTMLMath(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		contains   []string
+		unexpected []string
+	}{
+		{
+			name:     "Inline formula",
+			input:    "Area $\\pi r^2 < 4$ here",
+			contains: []string{`<p>Area <span class="godown-math">\pi r^2 &lt; 4</span> here</p>`},
+		},
+		{
+			name:     "Display formula",
+			input:    "$$\n\\sum_{i=0}^n i\n$$",
+			contains: []string{"<div class=\"godown-math godown-math-display\">\n\\sum_{i=0}^n i\n</div>"},
+		},
+		{
+			name:       "Display formula in a paragraph",
+			input:      "So $$x = \\frac{1}{2}$$ holds",
+			contains:   []string{`So <span class="godown-math godown-math-display">x = \frac{1}{2}</span> holds`},
+			unexpected: []string{"$"},
+		},
+		{
+			name:       "Dollar amounts",
+			input:      "From $5 to $6",
+			contains:   []string{"From $5 to $6"},
+			unexpected: []string{"godown-math"},
+		},
+		{
+			name:       "Code span",
+			input:      "`$x$`",
+			contains:   []string{"<code>$x$</code>"},
+			unexpected: []string{"godown-math"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := string(mdToHTML([]byte(tt.input)))
+			for _, expected := range tt.contains {
+				if !strings.Contains(result, expected) {
+					t.Errorf("mdToHTML() should contain %q, got:\n%s", expected, result)
+				}
+			}
+			for _, unexpected := range tt.unexpected {
+				if strings.Contains(result, unexpected) {
+					t.Errorf("mdToHTML() should not contain %q, got:\n%s", unexpected, result)
+				}
+			}
+		})
+	}
+}
+
+// Test the inline formula detection
+func TestIsMathText(t *testing.T) {
+	tests := map[string]bool{
+		"x":      true,
+		"a + b":  true,
+		"5 and ": false,
+		" x":     false,
+		"":       false,
+	}
+	for tex, want := range tests {
+		if got := isMathText([]byte(tex)); got != want {
+			t.Errorf("isMathText(%q) = %v, want %v", tex, got, want)
+		}
+	}
+}
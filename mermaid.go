@@ -0,0 +1,29 @@
+package main
+
+import (
+	"bytes"
+	"html/template"
+	"io"
+
+	"github.com/gomarkdown/markdown/ast"
+)
+
+// isMermaid checks if the info string of a fenced code block is mermaid
+func isMermaid(info []byte) bool {
+	lang, _, _ := bytes.Cut(bytes.TrimSpace(info), []byte(" "))
+	return bytes.EqualFold(lang, []byte("mermaid"))
+}
+
+// renderMermaid renders the ```mermaid fences as diagram containers, drawn
+// in the browser by the embedded Mermaid bundle. The source stays readable
+// when scripts are disabled.
+func renderMermaid(w io.Writer, node ast.Node, entering bool) (ast.WalkStatus, bool) {
+	block, ok := node.(*ast.CodeBlock)
+	if !ok || !isMermaid(block.Info) {
+		return ast.GoToNext, false
+	}
+	io.WriteString(w, "<pre class=\"mermaid\">")
+	io.WriteString(w, template.HTMLEscapeString(string(block.Literal)))
+	io.WriteString(w, "</pre>\n")
+	return ast.GoToNext, true
+}
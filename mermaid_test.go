@@ -0,0 +1,35 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// Test Mermaid fences rendering
+func TestMdToHTMLMermaid(t *testing.T) {
+	result := string(mdToHTML([]byte("```mermaid\ngraph TD\n  A --> B<br>\n```\n\n```go\nfunc main() {}\n```")))
+
+	if !strings.Contains(result, "<pre class=\"mermaid\">graph TD\n  A --&gt; B&lt;br&gt;\n</pre>") {
+		t.Errorf("mermaid fence should be a diagram container, got:\n%s", result)
+	}
+	if !strings.Contains(result, `<code class="language-go">`) {
+		t.Errorf("other fences should stay code blocks, got:\n%s", result)
+	}
+}
+
+// Test the info string detection
+func TestIsMermaid(t *testing.T) {
+	tests := map[string]bool{
+		"mermaid":           true,
+		"Mermaid":           true,
+		" mermaid {.class}": true,
+		"mermaidjs":         false,
+		"go":                false,
+		"":                  false,
+	}
+	for info, want := range tests {
+		if got := isMermaid([]byte(info)); got != want {
+			t.Errorf("isMermaid(%q) = %v, want %v", info, got, want)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// mount is a directory served under a URL prefix
+type mount struct {
+	Prefix string // URL prefix, "" for the current directory served at the root
+	Root   string // Served directory
+	Index  string // Index file of the directory
+	Tree   *docTree
+	Repo   *gitRepo
+}
+
+// mounts are the directories given with -mount (empty when serving the
+// current directory)
+var mounts []*mount
+
+// mountList collects the repeatable -mount flag
+type mountList []string
+
+func (l *mountList) String() string {
+	return strings.Join(*l, ";")
+}
+
+func (l *mountList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// parseMount parses a mount point given as /prefix=path[,index=FILE]
+func parseMount(value, defaultIndex string) (*mount, error) {
+	prefix, root, ok := strings.Cut(value, "=")
+	if !ok || root == "" {
+		return nil, fmt.Errorf("invalid mount %q, expected /prefix=path", value)
+	}
+
+	m := &mount{Index: defaultIndex}
+	if dir, options, found := strings.Cut(root, ","); found {
+		root = dir
+		for _, option := range strings.Split(options, ",") {
+			key, val, _ := strings.Cut(option, "=")
+			if key != "index" || val == "" {
+				return nil, fmt.Errorf("invalid mount option %q in %q", option, value)
+			}
+			m.Index = val
+		}
+	}
+
+	prefix = path.Clean("/" + strings.TrimSpace(prefix))
+	if prefix == "/" || strings.HasPrefix(prefix, "/__godown") {
+		return nil, fmt.Errorf("invalid mount prefix %q", prefix)
+	}
+	m.Prefix = prefix
+
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("mount %s: %s is not a directory", prefix, root)
+	}
+	m.Root = filepath.Clean(root)
+	return m, nil
+}
+
+// parseMounts parses the mount points, rejecting duplicated prefixes
+func parseMounts(values []string, defaultIndex string) ([]*mount, error) {
+	var result []*mount
+	seen := map[string]bool{}
+	for _, value := range values {
+		m, err := parseMount(value, defaultIndex)
+		if err != nil {
+			return nil, err
+		}
+		if seen[m.Prefix] {
+			return nil, fmt.Errorf("duplicate mount prefix %s", m.Prefix)
+		}
+		seen[m.Prefix] = true
+		result = append(result, m)
+	}
+	return result, nil
+}
+
+// findMount returns the mount point serving a cleaned URL path and the
+// path relative to it. Without mount points, the current directory is
+// served at the root.
+func findMount(urlPath string) (*mount, string) {
+	if len(mounts) == 0 {
+		return servedMounts()[0], urlPath
+	}
+
+	// The longest matching prefix wins
+	var found *mount
+	for _, m := range mounts {
+		if urlPath != m.Prefix && !strings.HasPrefix(urlPath, m.Prefix+"/") {
+			continue
+		}
+		if found == nil || len(m.Prefix) > len(found.Prefix) {
+			found = m
+		}
+	}
+	if found == nil {
+		return nil, ""
+	}
+	rest := strings.TrimPrefix(urlPath, found.Prefix)
+	if rest == "" {
+		rest = "/"
+	}
+	return found, rest
+}
+
+// relPath returns the slash-separated path of a served file relative to the
+// root of the mount point
+func (m *mount) relPath(filePath string) string {
+	rel, err := filepath.Rel(m.Root, filePath)
+	if err != nil {
+		return filepath.ToSlash(filePath)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// servedMounts returns the mount points, or without mount points the
+// current directory served at the root
+func servedMounts() []*mount {
+	if len(mounts) == 0 {
+		return []*mount{{Root: ".", Index: indexFile, Tree: siteTree, Repo: siteRepo}}
+	}
+	return mounts
+}
+
+// docTrees returns the served documentation trees, of the mount points or
+// of the current directory
+func docTrees() []*docTree {
+	var trees []*docTree
+	for _, m := range servedMounts() {
+		if m.Tree != nil {
+			trees = append(trees, m.Tree)
+		}
+	}
+	return trees
+}
+
+// serveLanding lists the mount points on the root page
+func serveLanding(w http.ResponseWriter, r *http.Request) {
+	var result strings.Builder
+	result.WriteString("<h1>Documentation</h1>\n<ul class=\"godown-mounts\">\n")
+	for _, m := range mounts {
+		title := m.Prefix
+		if _, err := os.Stat(filepath.Join(m.Root, m.Index)); err == nil {
+			title = pageTitle(m.Root, filepath.ToSlash(m.Index))
+		}
+		fmt.Fprintf(&result, "<li><a href=\"%s/\">%s</a> <code>%s</code></li>\n",
+			template.HTMLEscapeString(pageURL(m.Prefix)),
+			template.HTMLEscapeString(title),
+			template.HTMLEscapeString(m.Prefix),
+		)
+	}
+	result.WriteString("</ul>\n")
+
+	renderPage(w, PageData{
+		Title:     "Documentation",
+		Content:   template.HTML(result.String()),
+		StylePath: "/__godown_style.css",
+	})
+}
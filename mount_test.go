@@ -0,0 +1,164 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Test mount point parsing
+func TestParseMount(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTree(t, tmpDir, map[string]string{"api/index.md": "# API", "file.md": "# File"})
+	api := filepath.Join(tmpDir, "api")
+
+	tests := []struct {
+		value     string
+		wantMount mount
+		wantErr   bool
+	}{
+		{"/api=" + api, mount{Prefix: "/api", Root: api, Index: "README.md"}, false},
+		{"api/=" + api + ",index=index.md", mount{Prefix: "/api", Root: api, Index: "index.md"}, false},
+		{"/docs/v2=" + api, mount{Prefix: "/docs/v2", Root: api, Index: "README.md"}, false},
+		{"/api", mount{}, true},
+		{"/=" + api, mount{}, true},
+		{"/__godown=" + api, mount{}, true},
+		{"/api=" + api + ",style=x", mount{}, true},
+		{"/api=" + filepath.Join(tmpDir, "missing"), mount{}, true},
+		{"/api=" + filepath.Join(tmpDir, "file.md"), mount{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			m, err := parseMount(tt.value, "README.md")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseMount(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if err == nil && *m != tt.wantMount {
+				t.Errorf("parseMount(%q) = %+v, want %+v", tt.value, *m, tt.wantMount)
+			}
+		})
+	}
+
+	if _, err := parseMounts([]string{"/api=" + api, "/api/=" + tmpDir}, "README.md"); err == nil {
+		t.Errorf("parseMounts() should reject duplicated prefixes")
+	}
+}
+
+// Test request routing to mount points
+func TestFindMount(t *testing.T) {
+	oldMounts := mounts
+	defer func() { mounts = oldMounts }()
+	mounts = []*mount{{Prefix: "/docs", Root: "a"}, {Prefix: "/docs/api", Root: "b"}}
+
+	tests := []struct {
+		path     string
+		wantRoot string
+		wantRel  string
+	}{
+		{"/docs", "a", "/"},
+		{"/docs/guide", "a", "/guide"},
+		{"/docs/api", "b", "/"},
+		{"/docs/api/v1", "b", "/v1"},
+		{"/docsx", "", ""},
+		{"/other", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			m, rel := findMount(tt.path)
+			root := ""
+			if m != nil {
+				root = m.Root
+			}
+			if root != tt.wantRoot || rel != tt.wantRel {
+				t.Errorf("findMount(%q) = %q, %q, want %q, %q", tt.path, root, rel, tt.wantRoot, tt.wantRel)
+			}
+		})
+	}
+}
+
+// Test serving several directories
+func TestServeMarkdownMounts(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTree(t, tmpDir, map[string]string{
+		"api/index.md":        "# API reference",
+		"api/endpoints.md":    "# Endpoints",
+		"guide/README.md":     "# User guide",
+		"guide/start/run.md":  "# Running",
+		"outside/secret.md":   "# Secret",
+		"guide/logo.png":      "fake image",
+		"guide/start/note.md": "See [running](run.md)",
+	})
+
+	oldMounts := mounts
+	defer func() { mounts = oldMounts }()
+	var err error
+	mounts, err = parseMounts([]string{
+		"/api=" + filepath.Join(tmpDir, "api") + ",index=index.md",
+		"/guide=" + filepath.Join(tmpDir, "guide"),
+	}, "README.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range mounts {
+		m.Tree = newDocTree(m.Root, m.Prefix, m.Index)
+	}
+
+	tests := []struct {
+		url      string
+		status   int
+		expected []string
+	}{
+		{"/", http.StatusOK, []string{`<a href="/api/">API reference</a> <code>/api</code>`, `<a href="/guide/">User guide</a>`}},
+		{"/api/", http.StatusOK, []string{"<h1", "API reference", `<a href="/api/endpoints">Endpoints</a>`}},
+		{"/api/endpoints", http.StatusOK, []string{"Endpoints", `<a href="/api/endpoints" class="active" aria-current="page">`}},
+		{"/guide/", http.StatusOK, []string{"User guide", `<a href="/guide/start/run">Running</a>`}},
+		{"/guide/start/run", http.StatusOK, []string{"Running"}},
+		{"/guide/logo.png", http.StatusOK, []string{"fake image"}},
+		{"/outside/secret", http.StatusNotFound, nil},
+		{"/guide/../outside/secret", http.StatusNotFound, nil},
+		{"/api/missing", http.StatusNotFound, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.url, nil)
+			w := httptest.NewRecorder()
+			serveMarkdown(w, req)
+
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d", w.Code, tt.status)
+			}
+			body := w.Body.String()
+			for _, expected := range tt.expected {
+				if !strings.Contains(body, expected) {
+					t.Errorf("body should contain %q, got:\n%s", expected, body)
+				}
+			}
+		})
+	}
+
+	// Mount prefixes redirect to their index with a trailing slash
+	req := httptest.NewRequest("GET", "/api?view=source", nil)
+	w := httptest.NewRecorder()
+	serveMarkdown(w, req)
+	if w.Code != http.StatusMovedPermanently || w.Header().Get("Location") != "/api/?view=source" {
+		t.Errorf("redirect = %d %q, want 301 /api/?view=source", w.Code, w.Header().Get("Location"))
+	}
+}
+
+// Test the default site serves the current directory without mount points
+func TestFindMountDefault(t *testing.T) {
+	oldMounts, oldIndex := mounts, indexFile
+	defer func() { mounts, indexFile = oldMounts, oldIndex }()
+	mounts = nil
+	indexFile = "index.md"
+
+	m, rel := findMount("/docs/guide")
+	if m == nil || m.Root != "." || m.Prefix != "" || m.Index != "index.md" || rel != "/docs/guide" {
+		t.Errorf("findMount() = %+v, %q, want the current directory", m, rel)
+	}
+}
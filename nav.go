@@ -0,0 +1,540 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"hash/fnv"
+	"html/template"
+	"io/fs"
+	"log"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ignoreFileName lists glob patterns of files and directories to hide
+const ignoreFileName = ".godownignore"
+
+// navNode is an entry (Markdown file or directory) of the documentation tree
+type navNode struct {
+	Name     string // File or directory name
+	File     string // Slash-separated path of the Markdown page, relative to the root ("" if none)
+	URL      string
+	Title    string
+	IsDir    bool
+	Children []*navNode
+}
+
+// docTree is an in-memory view of the served documentation tree. It is built
+// once and rebuilt by watch when the filesystem changes.
+type docTree struct {
+	root   string
+	prefix string // URL prefix of the pages
+	index  string // Index file of the root
+
+	mu    sync.RWMutex
+	nav   *navNode
+	pages []*navNode  // Pages in reading order
+	files []*pageInfo // Every Markdown page, sorted by path
+	wiki  *wikiIndex
+	tags  *tagIndex
+	stamp uint64
+
+	includes []string // Files included by the pages, watched with the tree
+}
+
+// siteTree is the documentation tree of the current directory (nil when disabled)
+var siteTree *docTree
+
+// newDocTree builds the documentation tree of the root directory, served
+// under the URL prefix with the given index file
+func newDocTree(root, prefix, index string) *docTree {
+	t := &docTree{root: root, prefix: prefix, index: index}
+	t.refresh()
+	return t
+}
+
+// refresh rebuilds the tree from the filesystem
+func (t *docTree) refresh() {
+	patterns := loadIgnorePatterns(t.root)
+	stamp := treeSignature(t.root, patterns)
+	pages := scanPages(t.root, t.prefix, t.index, patterns)
+	nav := buildNav(t.root, t.index, patterns, pages)
+	if t.prefix != "" {
+		prefixURLs(nav, t.prefix)
+	}
+	wiki := buildWikiIndex(pages)
+	tags := buildTagIndex(pages)
+
+	var includes []string
+	seen := map[string]bool{}
+	for _, page := range pages {
+		for _, file := range page.Includes {
+			if !seen[file] {
+				seen[file] = true
+				includes = append(includes, file)
+			}
+		}
+	}
+	stamp = includesSignature(stamp, includes)
+
+	t.mu.Lock()
+	t.nav = nav
+	t.pages = flattenNav(nav)
+	t.files = pages
+	t.wiki = wiki
+	t.tags = tags
+	t.stamp = stamp
+	t.includes = includes
+	t.mu.Unlock()
+}
+
+// watch polls the filesystem and refreshes the tree when something changed
+func (t *docTree) watch(interval time.Duration) {
+	for range time.Tick(interval) {
+		stamp := treeSignature(t.root, loadIgnorePatterns(t.root))
+
+		t.mu.RLock()
+		changed := includesSignature(stamp, t.includes) != t.stamp
+		t.mu.RUnlock()
+
+		if changed {
+			log.Printf("Documentation tree changed, rebuilding navigation")
+			t.refresh()
+		}
+	}
+}
+
+// loadIgnorePatterns reads the patterns of the ignore file located at the root
+func loadIgnorePatterns(root string) []string {
+	file, err := os.Open(filepath.Join(root, ignoreFileName))
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	var patterns []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, strings.Trim(line, "/"))
+	}
+	return patterns
+}
+
+// isIgnored checks if a slash-separated path relative to the root is hidden
+// (dot files) or matches one of the ignore patterns
+func isIgnored(rel string, patterns []string) bool {
+	name := path.Base(rel)
+	if strings.HasPrefix(name, ".") {
+		return true
+	}
+
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, rel); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// isMarkdownFile checks if the file has a Markdown extension
+func isMarkdownFile(name string) bool {
+	return strings.EqualFold(path.Ext(name), ".md")
+}
+
+// treeSignature computes a fingerprint of the Markdown files and directories
+// of the tree, used to detect changes without rebuilding the navigation
+func treeSignature(root string, patterns []string) uint64 {
+	h := fnv.New64a()
+	filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		rel, _ := filepath.Rel(root, p)
+		rel = filepath.ToSlash(rel)
+		if rel != "." && isIgnored(rel, patterns) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.IsDir() && !isMarkdownFile(d.Name()) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		fmt.Fprintf(h, "%s|%d|%d\n", rel, info.ModTime().UnixNano(), info.Size())
+		return nil
+	})
+	return h.Sum64()
+}
+
+// buildNav returns the navigation tree, defined by the SUMMARY.md file when
+// present, else by walking the root directory. The page titles are taken from
+// the scanned pages.
+func buildNav(root, index string, patterns []string, pages []*pageInfo) *navNode {
+	node := summaryNav(root)
+	if node == nil {
+		node = buildNavDir(root, ".", patterns)
+	}
+	if node == nil {
+		node = &navNode{Name: filepath.Base(root), IsDir: true}
+	}
+
+	// The root page is the configured index file, a root README is then
+	// listed as a regular page
+	index = filepath.ToSlash(index)
+	if node.File != "" && node.File != index {
+		node.Children = append([]*navNode{{
+			Name: node.File,
+			File: node.File,
+			URL:  filePageURL(node.File),
+		}}, node.Children...)
+	}
+
+	node.File = ""
+	node.Title = "Home"
+	if _, err := os.Stat(filepath.Join(root, filepath.FromSlash(index))); err == nil {
+		node.File = index
+		node.Title = ""
+	}
+	node.URL = "/"
+
+	children := node.Children[:0]
+	for _, child := range node.Children {
+		if child.File != index || len(child.Children) > 0 {
+			children = append(children, child)
+		}
+	}
+	node.Children = children
+
+	titles := make(map[string]string, len(pages))
+	for _, page := range pages {
+		titles[page.File] = page.Title
+	}
+
+	// The index page is served at the root URL wherever it is listed, pages
+	// not scanned, such as ignored ones listed by the summary, are read
+	var walk func(n *navNode)
+	walk = func(n *navNode) {
+		if n.File == index {
+			n.URL = "/"
+		}
+		if n.Title == "" && n.File != "" {
+			if title, ok := titles[n.File]; ok {
+				n.Title = title
+			} else {
+				n.Title = pageTitle(root, n.File)
+			}
+		}
+		for _, child := range n.Children {
+			walk(child)
+		}
+	}
+	walk(node)
+	return node
+}
+
+// prefixURLs prepends the URL prefix of a mount point to the URLs of the tree
+func prefixURLs(node *navNode, prefix string) {
+	if node.URL != "" {
+		node.URL = prefix + node.URL
+	}
+	for _, child := range node.Children {
+		prefixURLs(child, prefix)
+	}
+}
+
+// buildNavDir builds the node of a directory, returning nil when it does not
+// contain any Markdown file. The titles of its pages are left to buildNav.
+func buildNavDir(root, rel string, patterns []string) *navNode {
+	entries, err := os.ReadDir(filepath.Join(root, filepath.FromSlash(rel)))
+	if err != nil {
+		return nil
+	}
+
+	node := &navNode{Name: path.Base(rel), IsDir: true}
+	var dirs []*navNode
+	for _, entry := range entries {
+		childRel := path.Join(rel, entry.Name())
+		if isIgnored(childRel, patterns) {
+			continue
+		}
+
+		if entry.IsDir() {
+			if child := buildNavDir(root, childRel, patterns); child != nil {
+				dirs = append(dirs, child)
+			}
+			continue
+		}
+
+		if !isMarkdownFile(entry.Name()) {
+			continue
+		}
+		if entry.Name() == "README.md" {
+			// The README is the page of the directory itself
+			node.File = childRel
+			continue
+		}
+		node.Children = append(node.Children, &navNode{
+			Name: entry.Name(),
+			File: childRel,
+			URL:  filePageURL(childRel),
+		})
+	}
+	node.Children = append(node.Children, dirs...)
+
+	if node.File == "" && len(node.Children) == 0 {
+		return nil
+	}
+
+	node.URL = pageURL(rel)
+	if node.File == "" {
+		node.Title = node.Name
+	}
+	return node
+}
+
+// pageURL returns the escaped URL of a slash-separated path relative to the root
+func pageURL(rel string) string {
+	u := url.URL{Path: "/" + strings.TrimPrefix(rel, "/")}
+	return u.EscapedPath()
+}
+
+// filePageURL returns the URL serving a Markdown file given by its
+// slash-separated path relative to the root
+func filePageURL(file string) string {
+	if dir := path.Dir(file); path.Base(file) == "README.md" && dir != "." {
+		return pageURL(dir)
+	}
+	return pageURL(strings.TrimSuffix(file, path.Ext(file)))
+}
+
+// pageTitle returns the title of a Markdown page: the front matter title,
+// else its first heading, else its file name
+func pageTitle(root, rel string) string {
+	content, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(rel)))
+	if err != nil {
+		return fileTitle(rel)
+	}
+	meta, body := splitFrontMatter(content)
+	return documentTitle(rel, meta, body)
+}
+
+// documentTitle returns the title of a parsed Markdown page
+func documentTitle(rel string, meta frontMatter, body []byte) string {
+	if meta.Title != "" {
+		return meta.Title
+	}
+	if title := extractTitle(body); title != "" {
+		return title
+	}
+	return fileTitle(rel)
+}
+
+// fileTitle returns the file name of a page without its extension
+func fileTitle(rel string) string {
+	name := path.Base(rel)
+	return strings.TrimSuffix(name, path.Ext(name))
+}
+
+// pageInfo is a Markdown page of the tree with its metadata
+type pageInfo struct {
+	File  string // Slash-separated path relative to the root
+	URL   string
+	Title string
+	Meta  frontMatter
+	Links []string // Targets of the wiki links
+
+	Includes []string // Files included by the page
+}
+
+// scanPages reads every Markdown page of the root, including those not
+// listed in the navigation, sorted by path
+func scanPages(root, prefix, index string, patterns []string) []*pageInfo {
+	var pages []*pageInfo
+	filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		rel, _ := filepath.Rel(root, p)
+		rel = filepath.ToSlash(rel)
+		if rel != "." && isIgnored(rel, patterns) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || !isMarkdownFile(d.Name()) {
+			return nil
+		}
+		content, err := os.ReadFile(p)
+		if err != nil {
+			return nil
+		}
+
+		url := filePageURL(rel)
+		if rel == filepath.ToSlash(index) {
+			url = "/"
+		}
+		meta, body := splitFrontMatter(content)
+		body, includes := expandIncludes(body, p, root)
+		pages = append(pages, &pageInfo{
+			File:     rel,
+			URL:      prefix + url,
+			Title:    documentTitle(rel, meta, body),
+			Meta:     meta,
+			Links:    scanWikiLinks(body),
+			Includes: includes,
+		})
+		return nil
+	})
+	return pages
+}
+
+// sortPages sorts pages by title, ignoring case
+func sortPages(pages []*pageInfo) {
+	sort.SliceStable(pages, func(i, j int) bool {
+		return strings.ToLower(pages[i].Title) < strings.ToLower(pages[j].Title)
+	})
+}
+
+// sidebar renders the navigation tree as nested lists, highlighting the
+// current page (slash-separated path relative to the root)
+func (t *docTree) sidebar(current string) template.HTML {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if t.nav == nil {
+		return ""
+	}
+
+	var result strings.Builder
+	result.WriteString("<ul>\n")
+	result.WriteString("<li>")
+	writeNavLink(&result, t.nav, current)
+	result.WriteString("</li>\n")
+	for _, child := range t.nav.Children {
+		writeNavNode(&result, child, current)
+	}
+	result.WriteString("</ul>\n")
+	return template.HTML(result.String())
+}
+
+// contains checks if the page is one of the descendants of the node
+func (n *navNode) contains(file string) bool {
+	for _, child := range n.Children {
+		if child.File == file || child.contains(file) {
+			return true
+		}
+	}
+	return false
+}
+
+// writeNavNode renders a node and, for sections, its children
+func writeNavNode(result *strings.Builder, node *navNode, current string) {
+	if !node.IsDir && len(node.Children) == 0 {
+		result.WriteString("<li>")
+		writeNavLink(result, node, current)
+		result.WriteString("</li>\n")
+		return
+	}
+
+	// Expand the sections containing the current page
+	open := ""
+	if node.contains(current) {
+		open = " open"
+	}
+
+	result.WriteString("<li><details" + open + "><summary>")
+	writeNavLink(result, node, current)
+	result.WriteString("</summary>\n<ul>\n")
+	for _, child := range node.Children {
+		writeNavNode(result, child, current)
+	}
+	result.WriteString("</ul>\n</details></li>\n")
+}
+
+// writeNavLink renders the link of a node, or its title if it has no page
+func writeNavLink(result *strings.Builder, node *navNode, current string) {
+	title := template.HTMLEscapeString(node.Title)
+	if node.File == "" {
+		result.WriteString(title)
+		return
+	}
+
+	if node.File == current {
+		fmt.Fprintf(result, "<a href=\"%s\" class=\"active\" aria-current=\"page\">%s</a>", template.HTMLEscapeString(node.URL), title)
+		return
+	}
+	fmt.Fprintf(result, "<a href=\"%s\">%s</a>", template.HTMLEscapeString(node.URL), title)
+}
+
+// flattenNav lists the pages of the tree in reading order (depth-first)
+func flattenNav(root *navNode) []*navNode {
+	var pages []*navNode
+	seen := map[string]bool{}
+	var walk func(node *navNode)
+	walk = func(node *navNode) {
+		if node.File != "" && !seen[node.File] {
+			seen[node.File] = true
+			pages = append(pages, node)
+		}
+		for _, child := range node.Children {
+			walk(child)
+		}
+	}
+	walk(root)
+	return pages
+}
+
+// neighbours returns the pages before and after the current one in reading order
+func (t *docTree) neighbours(current string) (prev, next *navNode) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	for i, page := range t.pages {
+		if page.File != current {
+			continue
+		}
+		if i > 0 {
+			prev = t.pages[i-1]
+		}
+		if i < len(t.pages)-1 {
+			next = t.pages[i+1]
+		}
+		break
+	}
+	return prev, next
+}
+
+// pagination renders the links to the previous and next pages
+func (t *docTree) pagination(current string) template.HTML {
+	prev, next := t.neighbours(current)
+	if prev == nil && next == nil {
+		return ""
+	}
+
+	var result strings.Builder
+	if prev != nil {
+		fmt.Fprintf(&result, "<a class=\"prev\" href=\"%s\" rel=\"prev\">&larr; %s</a>\n", template.HTMLEscapeString(prev.URL), template.HTMLEscapeString(prev.Title))
+	}
+	if next != nil {
+		fmt.Fprintf(&result, "<a class=\"next\" href=\"%s\" rel=\"next\">%s &rarr;</a>\n", template.HTMLEscapeString(next.URL), template.HTMLEscapeString(next.Title))
+	}
+	return template.HTML(result.String())
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTree creates the files (slash-separated path => content) below dir
+func writeTree(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// Test ignore rules
+func TestIsIgnored(t *testing.T) {
+	patterns := []string{"drafts", "*.tmp.md", "docs/private"}
+
+	tests := []struct {
+		path     string
+		expected bool
+	}{
+		{"guide.md", false},
+		{".git", true},
+		{"docs/.hidden.md", true},
+		{"drafts", true},
+		{"docs/drafts", true},
+		{"notes.tmp.md", true},
+		{"docs/private", true},
+		{"docs/public", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			result := isIgnored(tt.path, patterns)
+			if result != tt.expected {
+				t.Errorf("isIgnored(%v) = %v, want %v", tt.path, result, tt.expected)
+			}
+		})
+	}
+}
+
+// Test navigation tree construction
+func TestBuildNav(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTree(t, tmpDir, map[string]string{
+		"README.md":         "# Home page",
+		"guide.md":          "---\ntitle: User guide\n---\n# Ignored heading",
+		"notes.md":          "no heading",
+		"image.png":         "fake",
+		"docs/README.md":    "# Documentation",
+		"docs/api.md":       "# API reference",
+		"empty/data.txt":    "no markdown here",
+		"secret/hidden.md":  "# Hidden",
+		".git/HEAD.md":      "# Hidden too",
+		".godownignore":     "# comment\nsecret/\n",
+		"other/deep/end.md": "# Deep page",
+	})
+
+	oldIndex := indexFile
+	indexFile = "README.md"
+	defer func() { indexFile = oldIndex }()
+
+	patterns := loadIgnorePatterns(tmpDir)
+	nav := buildNav(tmpDir, "README.md", patterns, scanPages(tmpDir, "", "README.md", patterns))
+
+	if nav.Title != "Home page" || nav.URL != "/" || nav.File != "README.md" {
+		t.Errorf("root node = %+v", nav)
+	}
+
+	var names []string
+	for _, child := range nav.Children {
+		names = append(names, child.Name)
+	}
+	if got := strings.Join(names, ","); got != "guide.md,notes.md,docs,other" {
+		t.Errorf("root children = %v, want guide.md,notes.md,docs,other", got)
+	}
+
+	titles := map[string]string{}
+	for _, child := range nav.Children {
+		titles[child.Name] = child.Title
+	}
+	if titles["guide.md"] != "User guide" {
+		t.Errorf("front matter title = %q, want %q", titles["guide.md"], "User guide")
+	}
+	if titles["notes.md"] != "notes" {
+		t.Errorf("file name title = %q, want %q", titles["notes.md"], "notes")
+	}
+	if titles["docs"] != "Documentation" {
+		t.Errorf("directory title = %q, want %q", titles["docs"], "Documentation")
+	}
+	if titles["other"] != "other" {
+		t.Errorf("directory without README title = %q, want %q", titles["other"], "other")
+	}
+
+	// The titles come from the scanned pages, without reading them again
+	nav = buildNav(tmpDir, "README.md", patterns, []*pageInfo{{File: "guide.md", Title: "Scanned title"}})
+	for _, child := range nav.Children {
+		if child.File == "guide.md" && child.Title != "Scanned title" {
+			t.Errorf("scanned page title = %q, want %q", child.Title, "Scanned title")
+		}
+	}
+}
+
+// Test sidebar rendering and current page highlighting
+func TestSidebar(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTree(t, tmpDir, map[string]string{
+		"README.md":       "# Home",
+		"docs/api.md":     "# API & tools",
+		"guides/setup.md": "# Setup",
+	})
+
+	oldIndex := indexFile
+	indexFile = "README.md"
+	defer func() { indexFile = oldIndex }()
+
+	tree := newDocTree(tmpDir, "", "README.md")
+	html := string(tree.sidebar("docs/api.md"))
+
+	if !strings.Contains(html, `<a href="/docs/api" class="active" aria-current="page">API &amp; tools</a>`) {
+		t.Errorf("sidebar should highlight the current page, got:\n%s", html)
+	}
+	if !strings.Contains(html, "<details open><summary>docs</summary>") {
+		t.Errorf("sidebar should expand the directory of the current page, got:\n%s", html)
+	}
+	if !strings.Contains(html, "<details><summary>guides</summary>") {
+		t.Errorf("sidebar should collapse other directories, got:\n%s", html)
+	}
+}
+
+// Test tree refresh after filesystem changes
+func TestDocTreeRefresh(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTree(t, tmpDir, map[string]string{"README.md": "# Home"})
+
+	tree := newDocTree(tmpDir, "", "README.md")
+	stamp := tree.stamp
+
+	if treeSignature(tmpDir, nil) != stamp {
+		t.Errorf("signature should be stable without changes")
+	}
+
+	writeTree(t, tmpDir, map[string]string{"new.md": "# New page"})
+	if treeSignature(tmpDir, nil) == stamp {
+		t.Errorf("signature should change when a page is added")
+	}
+
+	tree.refresh()
+	if !strings.Contains(string(tree.sidebar("")), "New page") {
+		t.Errorf("refreshed sidebar should list the new page")
+	}
+}
+
+// Test sidebar integration in Markdown pages
+func TestServeMarkdownSidebar(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTree(t, tmpDir, map[string]string{
+		"README.md":      "# Home",
+		"docs/README.md": "---\ntitle: Docs index\n---\nDocs content",
+	})
+
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+
+	oldIndex := indexFile
+	indexFile = "README.md"
+	defer func() { indexFile = oldIndex }()
+
+	oldTree := siteTree
+	siteTree = newDocTree(".", "", "README.md")
+	defer func() { siteTree = oldTree }()
+
+	req := httptest.NewRequest("GET", "/docs", nil)
+	w := httptest.NewRecorder()
+
+	serveMarkdown(w, req)
+
+	body := w.Body.String()
+	if !strings.Contains(body, `<nav class="godown-sidebar">`) {
+		t.Errorf("serveMarkdown() body should contain the sidebar")
+	}
+	if !strings.Contains(body, `class="active" aria-current="page">Docs index</a>`) {
+		t.Errorf("serveMarkdown() should highlight the directory page, got:\n%s", body)
+	}
+	if !strings.Contains(body, "<title>Docs index</title>") {
+		t.Errorf("serveMarkdown() should use the front matter title")
+	}
+	if strings.Contains(body, "title: Docs index") {
+		t.Errorf("serveMarkdown() should not render the front matter")
+	}
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// notebookMimeTypes are the types of the outputs shown, by preference
+var notebookMimeTypes = []string{
+	"image/png", "image/jpeg", "image/gif", "image/svg+xml",
+	"text/html", "text/markdown", "text/plain",
+}
+
+// ansiEscape matches the terminal color codes of tracebacks
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;]*[A-Za-z]`)
+
+// notebookText is a multiline text of a notebook, stored as a string or as
+// a list of lines
+type notebookText string
+
+func (t *notebookText) UnmarshalJSON(data []byte) error {
+	var lines []string
+	if err := json.Unmarshal(data, &lines); err == nil {
+		*t = notebookText(strings.Join(lines, ""))
+		return nil
+	}
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return err
+	}
+	*t = notebookText(text)
+	return nil
+}
+
+// notebook is a Jupyter notebook (nbformat 4)
+type notebook struct {
+	NBFormat int            `json:"nbformat"`
+	Cells    []notebookCell `json:"cells"`
+	Metadata struct {
+		Kernelspec struct {
+			Language string `json:"language"`
+		} `json:"kernelspec"`
+		LanguageInfo struct {
+			Name string `json:"name"`
+		} `json:"language_info"`
+	} `json:"metadata"`
+}
+
+// notebookCell is a Markdown, code or raw cell of a notebook
+type notebookCell struct {
+	CellType       string                             `json:"cell_type"`
+	Source         notebookText                       `json:"source"`
+	ExecutionCount *int                               `json:"execution_count"`
+	Outputs        []notebookOutput                   `json:"outputs"`
+	Attachments    map[string]map[string]notebookText `json:"attachments"`
+}
+
+// notebookOutput is an output of a code cell: stream, execute_result,
+// display_data or error
+type notebookOutput struct {
+	OutputType     string                     `json:"output_type"`
+	Name           string                     `json:"name"`
+	Text           notebookText               `json:"text"`
+	Data           map[string]json.RawMessage `json:"data"`
+	ExecutionCount *int                       `json:"execution_count"`
+	EName          string                     `json:"ename"`
+	EValue         string                     `json:"evalue"`
+	Traceback      []string                   `json:"traceback"`
+}
+
+// isNotebookFile checks if the file is a Jupyter notebook
+func isNotebookFile(filePath string) bool {
+	return strings.EqualFold(filepath.Ext(filePath), ".ipynb")
+}
+
+// language returns the programming language of the code cells
+func (nb *notebook) language() string {
+	if nb.Metadata.LanguageInfo.Name != "" {
+		return nb.Metadata.LanguageInfo.Name
+	}
+	return nb.Metadata.Kernelspec.Language
+}
+
+// parseNotebook decodes a notebook, with the position of the syntax errors
+func parseNotebook(content []byte) (*notebook, error) {
+	var nb notebook
+	if err := json.Unmarshal(content, &nb); err != nil {
+		if _, syntaxErr := parseJSON(content); syntaxErr != nil {
+			return nil, syntaxErr
+		}
+		return nil, err
+	}
+	if nb.NBFormat < 4 {
+		return nil, fmt.Errorf("nbformat %d is not supported, only version 4 is rendered", nb.NBFormat)
+	}
+	return &nb, nil
+}
+
+// executionPrompt renders the In [n]: and Out[n]: prompts of a cell
+func executionPrompt(label string, count *int) string {
+	if count == nil {
+		return fmt.Sprintf("<div class=\"godown-prompt\">%s[ ]:</div>", label)
+	}
+	return fmt.Sprintf("<div class=\"godown-prompt\">%s[%d]:</div>", label, *count)
+}
+
+// renderNotebook renders the cells of a notebook with their outputs, the
+// untrusted HTML of the Markdown cells being sanitized
+func renderNotebook(nb *notebook) string {
+	var result strings.Builder
+	result.WriteString("<div class=\"godown-notebook\">\n")
+	language := nb.language()
+	for _, cell := range nb.Cells {
+		switch cell.CellType {
+		case "markdown":
+			source := string(cell.Source)
+			for name, data := range cell.Attachments {
+				for mimeType, encoded := range data {
+					source = strings.ReplaceAll(source, "attachment:"+name, "data:"+mimeType+";base64,"+strings.Join(strings.Fields(string(encoded)), ""))
+				}
+			}
+			// Markdown can hold raw HTML, sanitized as the HTML outputs
+			fmt.Fprintf(&result, "<div class=\"godown-cell godown-cell-markdown\">\n%s</div>\n", sanitizeHTML(string(mdToHTML([]byte(source)))))
+		case "code":
+			result.WriteString("<div class=\"godown-cell godown-cell-code\">\n<div class=\"godown-cell-input\">")
+			result.WriteString(executionPrompt("In ", cell.ExecutionCount))
+			class := ""
+			if language != "" {
+				class = fmt.Sprintf(" class=\"language-%s\"", template.HTMLEscapeString(language))
+			}
+			fmt.Fprintf(&result, "<pre><code%s>%s</code></pre></div>\n", class, template.HTMLEscapeString(string(cell.Source)))
+			for _, output := range cell.Outputs {
+				result.WriteString(renderNotebookOutput(output))
+			}
+			result.WriteString("</div>\n")
+		default:
+			fmt.Fprintf(&result, "<div class=\"godown-cell godown-cell-raw\">\n<pre>%s</pre>\n</div>\n", template.HTMLEscapeString(string(cell.Source)))
+		}
+	}
+	result.WriteString("</div>\n")
+	return result.String()
+}
+
+// renderNotebookOutput renders an output of a code cell: its richest
+// representation, untrusted HTML being sanitized
+func renderNotebookOutput(output notebookOutput) string {
+	var content string
+	prompt := "<div class=\"godown-prompt\"></div>"
+	switch output.OutputType {
+	case "stream":
+		class := "godown-output-stream"
+		if output.Name == "stderr" {
+			class += " godown-output-stderr"
+		}
+		content = fmt.Sprintf("<pre class=\"%s\">%s</pre>", class, template.HTMLEscapeString(string(output.Text)))
+	case "error":
+		traceback := strings.Join(output.Traceback, "\n")
+		if traceback == "" {
+			traceback = output.EName + ": " + output.EValue
+		}
+		content = fmt.Sprintf("<pre class=\"godown-output-error\">%s</pre>", template.HTMLEscapeString(ansiEscape.ReplaceAllString(traceback, "")))
+	case "execute_result", "display_data":
+		if output.OutputType == "execute_result" {
+			prompt = executionPrompt("Out", output.ExecutionCount)
+		}
+		content = renderNotebookData(output.Data)
+	}
+	if content == "" {
+		return ""
+	}
+	return fmt.Sprintf("<div class=\"godown-cell-output\">%s<div class=\"godown-output\">%s</div></div>\n", prompt, content)
+}
+
+// renderNotebookData renders the preferred representation of a rich output
+func renderNotebookData(data map[string]json.RawMessage) string {
+	for _, mimeType := range notebookMimeTypes {
+		raw, ok := data[mimeType]
+		if !ok {
+			continue
+		}
+		var text notebookText
+		if err := json.Unmarshal(raw, &text); err != nil {
+			continue
+		}
+		switch mimeType {
+		case "image/svg+xml":
+			// Scripts of images do not run
+			return fmt.Sprintf("<img src=\"data:image/svg+xml;base64,%s\" alt=\"\">", base64.StdEncoding.EncodeToString([]byte(text)))
+		case "text/html":
+			return "<div class=\"godown-output-html\">" + sanitizeHTML(string(text)) + "</div>"
+		case "text/markdown":
+			return sanitizeHTML(string(mdToHTML([]byte(text))))
+		case "text/plain":
+			return "<pre>" + template.HTMLEscapeString(string(text)) + "</pre>"
+		}
+		// Raster images are encoded again, dropping anything but base64
+		image, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(string(text)), ""))
+		if err != nil {
+			continue
+		}
+		return fmt.Sprintf("<img src=\"data:%s;base64,%s\" alt=\"\">", mimeType, base64.StdEncoding.EncodeToString(image))
+	}
+	return ""
+}
+
+// serveNotebookFile renders a Jupyter notebook, or its parse error
+func serveNotebookFile(w http.ResponseWriter, r *http.Request, filePath string) {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	if info.Size() > maxDataSize {
+		serveTextFile(w, r, filePath)
+		return
+	}
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	var htmlContent string
+	if nb, err := parseNotebook(content); err != nil {
+		htmlContent = renderDataError(content, "notebook", err)
+	} else {
+		htmlContent = renderNotebook(nb)
+	}
+
+	renderPage(w, PageData{
+		Title:     filepath.Base(filePath),
+		Content:   template.HTML(htmlContent),
+		StylePath: "/__godown_style.css",
+		Views:     template.HTML(viewLinks(r, filePath, viewNotebook)),
+	})
+}
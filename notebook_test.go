@@ -0,0 +1,172 @@
+package main
+
+import (
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+// testNotebook is a notebook with every kind of cell and output
+const testNotebook = `{
+ "nbformat": 4,
+ "nbformat_minor": 5,
+ "metadata": {"language_info": {"name": "python"}},
+ "cells": [
+  {"cell_type": "markdown", "metadata": {}, "source": ["# Analysis\n", "![plot](attachment:plot.png)"],
+   "attachments": {"plot.png": {"image/png": "iVBORw0KGgo="}}},
+  {"cell_type": "code", "execution_count": 1, "metadata": {}, "source": "print(\"<hi>\")",
+   "outputs": [
+    {"output_type": "stream", "name": "stdout", "text": ["<hi>\n"]},
+    {"output_type": "stream", "name": "stderr", "text": "warning\n"}
+   ]},
+  {"cell_type": "code", "execution_count": 2, "metadata": {}, "source": "df",
+   "outputs": [
+    {"output_type": "execute_result", "execution_count": 2, "metadata": {},
+     "data": {"text/html": "<table onclick=\"x()\"><tr><td>1</td></tr></table><script>alert(1)</script>", "text/plain": "df"}}
+   ]},
+  {"cell_type": "code", "execution_count": 3, "metadata": {}, "source": "plot()",
+   "outputs": [
+    {"output_type": "display_data", "metadata": {}, "data": {"image/png": "iVBORw0K\nGgo=\n", "text/plain": "<Figure>"}},
+    {"output_type": "display_data", "metadata": {}, "data": {"image/svg+xml": ["<svg>", "</svg>"]}}
+   ]},
+  {"cell_type": "code", "execution_count": null, "metadata": {}, "source": "1/0",
+   "outputs": [
+    {"output_type": "error", "ename": "ZeroDivisionError", "evalue": "division by zero",
+     "traceback": ["\u001b[0;31mZeroDivisionError\u001b[0m: division by zero"]}
+   ]},
+  {"cell_type": "raw", "metadata": {}, "source": "raw <text>"}
+ ]
+}`
+
+// Test the rendering of the cells and outputs of a notebook
+func TestRenderNotebook(t *testing.T) {
+	nb, err := parseNotebook([]byte(testNotebook))
+	if err != nil {
+		t.Fatalf("parseNotebook() error = %v", err)
+	}
+	result := renderNotebook(nb)
+
+	expected := []string{
+		`<h1>Analysis</h1>`,
+		`src="data:image/png;base64,iVBORw0KGgo="`,
+		`<div class="godown-prompt">In [1]:</div><pre><code class="language-python">print(&#34;&lt;hi&gt;&#34;)</code></pre>`,
+		`<pre class="godown-output-stream">&lt;hi&gt;`,
+		`<pre class="godown-output-stream godown-output-stderr">warning`,
+		`<div class="godown-prompt">Out[2]:</div>`,
+		`<div class="godown-output-html"><table><tr><td>1</td></tr></table></div>`,
+		`<img src="data:image/png;base64,iVBORw0KGgo=" alt="">`,
+		`<img src="data:image/svg+xml;base64,PHN2Zz48L3N2Zz4=" alt="">`,
+		`<div class="godown-prompt">In [ ]:</div>`,
+		`<pre class="godown-output-error">ZeroDivisionError: division by zero</pre>`,
+		`<div class="godown-cell godown-cell-raw">` + "\n" + `<pre>raw &lt;text&gt;</pre>`,
+	}
+	for _, s := range expected {
+		if !strings.Contains(result, s) {
+			t.Errorf("renderNotebook() should contain %q, got %q", s, result)
+		}
+	}
+	for _, s := range []string{"alert", "onclick", "&lt;Figure&gt;", "\x1b"} {
+		if strings.Contains(result, s) {
+			t.Errorf("renderNotebook() should not contain %q", s)
+		}
+	}
+}
+
+// Test that the raw HTML of Markdown cells and outputs is sanitized
+func TestRenderNotebookMarkdownHTML(t *testing.T) {
+	nb, err := parseNotebook([]byte(`{
+ "nbformat": 4,
+ "metadata": {},
+ "cells": [
+  {"cell_type": "markdown", "metadata": {}, "source": "Cell <script>alert(1)</script> <img src=x onerror=alert(2)>"},
+  {"cell_type": "code", "execution_count": 1, "metadata": {}, "source": "show()",
+   "outputs": [
+    {"output_type": "display_data", "metadata": {}, "data": {"text/markdown": "**Output** <script>alert(3)</script><img src=x onerror=alert(4)>"}}
+   ]}
+ ]
+}`))
+	if err != nil {
+		t.Fatalf("parseNotebook() error = %v", err)
+	}
+	result := renderNotebook(nb)
+
+	for _, s := range []string{"Cell", `<img src="x">`, "<strong>Output</strong>"} {
+		if !strings.Contains(result, s) {
+			t.Errorf("renderNotebook() should contain %q, got %q", s, result)
+		}
+	}
+	for _, s := range []string{"<script", "alert", "onerror"} {
+		if strings.Contains(result, s) {
+			t.Errorf("renderNotebook() should not contain %q, got %q", s, result)
+		}
+	}
+}
+
+// Test the errors of invalid or unsupported notebooks
+func TestParseNotebookErrors(t *testing.T) {
+	_, err := parseNotebook([]byte("{\n \"cells\": [\n  {\"cell_type\" \"code\"}\n ]\n}"))
+	if parseError, ok := err.(*dataError); !ok || parseError.Line != 3 {
+		t.Errorf("parseNotebook() error = %v, want a dataError at line 3", err)
+	}
+
+	_, err = parseNotebook([]byte(`{"nbformat": 3, "worksheets": []}`))
+	if err == nil || !strings.Contains(err.Error(), "nbformat 3") {
+		t.Errorf("parseNotebook() error = %v, want nbformat 3 unsupported", err)
+	}
+}
+
+// Test the notebook and text views of notebooks
+func TestServeNotebookFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+
+	writeTree(t, tmpDir, map[string]string{
+		"analysis.ipynb": testNotebook,
+		"broken.ipynb":   "{\n \"cells\": [\n",
+	})
+
+	tests := []struct {
+		url        string
+		expected   []string
+		unexpected []string
+	}{
+		{
+			url: "/analysis.ipynb",
+			expected: []string{
+				`<div class="godown-notebook">`,
+				`class="active" aria-current="page">Notebook</a>`,
+				`<a href="/analysis.ipynb?view=text">Text</a>`,
+			},
+		},
+		{
+			url:      "/broken.ipynb",
+			expected: []string{`<div class="godown-data-error">Invalid notebook`, `<mark id="error">`},
+		},
+		{
+			url:        "/analysis.ipynb?view=text",
+			expected:   []string{`<pre class="godown-text">`},
+			unexpected: []string{"godown-notebook"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			serveMarkdown(w, httptest.NewRequest("GET", tt.url, nil))
+			body := w.Body.String()
+			for _, s := range tt.expected {
+				if !strings.Contains(body, s) {
+					t.Errorf("body should contain %q, got %q", s, body)
+				}
+			}
+			for _, s := range tt.unexpected {
+				if strings.Contains(body, s) {
+					t.Errorf("body should not contain %q", s)
+				}
+			}
+		})
+	}
+}
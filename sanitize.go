@@ -0,0 +1,116 @@
+package main
+
+import (
+	"html"
+	"regexp"
+	"strings"
+
+	nethtml "golang.org/x/net/html"
+)
+
+// sanitizedTags are the elements kept by sanitizeHTML, other elements are
+// replaced by their content
+var sanitizedTags = map[string]bool{
+	"a": true, "abbr": true, "b": true, "blockquote": true, "br": true, "caption": true,
+	"code": true, "col": true, "colgroup": true, "dd": true, "del": true, "details": true,
+	"div": true, "dl": true, "dt": true, "em": true, "figcaption": true, "figure": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true, "hr": true,
+	"i": true, "img": true, "ins": true, "kbd": true, "li": true, "mark": true, "ol": true,
+	"p": true, "pre": true, "q": true, "s": true, "samp": true, "small": true, "span": true,
+	"strong": true, "sub": true, "summary": true, "sup": true, "table": true, "tbody": true,
+	"td": true, "tfoot": true, "th": true, "thead": true, "tr": true, "u": true, "ul": true,
+	"var": true,
+}
+
+// droppedTags are the elements removed with their content
+var droppedTags = map[string]bool{
+	"embed": true, "form": true, "frame": true, "frameset": true, "head": true, "iframe": true,
+	"noscript": true, "object": true, "script": true, "select": true, "style": true,
+	"svg": true, "template": true, "textarea": true, "title": true, "math": true,
+}
+
+// sanitizedAttributes are the attributes kept on the elements, URLs being
+// checked
+var sanitizedAttributes = map[string]bool{
+	"align": true, "alt": true, "class": true, "colspan": true, "height": true, "href": true,
+	"open": true, "rowspan": true, "scope": true, "src": true, "start": true, "title": true,
+	"width": true,
+}
+
+// safeURL matches the URLs allowed in links and images: web, mail,
+// fragments, relative paths and embedded raster images
+var safeURL = regexp.MustCompile(`^(?i)(https?:|mailto:|#|/|\./|\.\./|data:image/(png|jpeg|gif|webp);base64,|[^:/?#]+(/|$|[?#]))`)
+
+// sanitizeHTML keeps the harmless elements and attributes of an untrusted
+// HTML fragment, closing the elements left open
+func sanitizeHTML(fragment string) string {
+	var result strings.Builder
+	tokenizer := nethtml.NewTokenizer(strings.NewReader(fragment))
+	var open []string
+	dropped, depth := "", 0
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == nethtml.ErrorToken {
+			break
+		}
+		token := tokenizer.Token()
+
+		// Content of a dropped element
+		if dropped != "" {
+			switch {
+			case tokenType == nethtml.StartTagToken && token.Data == dropped:
+				depth++
+			case tokenType == nethtml.EndTagToken && token.Data == dropped:
+				if depth--; depth == 0 {
+					dropped = ""
+				}
+			}
+			continue
+		}
+
+		switch tokenType {
+		case nethtml.TextToken:
+			result.WriteString(html.EscapeString(token.Data))
+		case nethtml.StartTagToken, nethtml.SelfClosingTagToken:
+			if droppedTags[token.Data] {
+				if tokenType == nethtml.StartTagToken {
+					dropped, depth = token.Data, 1
+				}
+				continue
+			}
+			if !sanitizedTags[token.Data] {
+				continue
+			}
+			result.WriteString("<" + token.Data)
+			for _, attribute := range token.Attr {
+				name := strings.ToLower(attribute.Key)
+				if attribute.Namespace != "" || !sanitizedAttributes[name] {
+					continue
+				}
+				if (name == "href" || name == "src") && !safeURL.MatchString(strings.TrimSpace(attribute.Val)) {
+					continue
+				}
+				result.WriteString(" " + name + "=\"" + html.EscapeString(attribute.Val) + "\"")
+			}
+			result.WriteString(">")
+			if tokenType == nethtml.StartTagToken && !voidElements[token.Data] {
+				open = append(open, token.Data)
+			}
+		case nethtml.EndTagToken:
+			// Only the open elements are closed, with the elements they contain
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i] == token.Data {
+					for j := len(open) - 1; j >= i; j-- {
+						result.WriteString("</" + open[j] + ">")
+					}
+					open = open[:i]
+					break
+				}
+			}
+		}
+	}
+	for i := len(open) - 1; i >= 0; i-- {
+		result.WriteString("</" + open[i] + ">")
+	}
+	return result.String()
+}
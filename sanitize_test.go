@@ -0,0 +1,72 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// Test the sanitization of untrusted HTML
+func TestSanitizeHTML(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		expected   []string
+		unexpected []string
+	}{
+		{
+			name:       "scripts and styles",
+			input:      "<p>a</p><script>alert(1)</script><style>p{}</style><p>b</p>",
+			expected:   []string{"<p>a</p><p>b</p>"},
+			unexpected: []string{"alert", "p{}"},
+		},
+		{
+			name:       "event handlers",
+			input:      `<div onclick="alert(1)" class="x">a</div>`,
+			expected:   []string{`<div class="x">a</div>`},
+			unexpected: []string{"onclick"},
+		},
+		{
+			name:       "javascript links",
+			input:      `<a href="javascript:alert(1)">a</a><a href="https://example.com">b</a>`,
+			expected:   []string{"<a>a</a>", `<a href="https://example.com">b</a>`},
+			unexpected: []string{"javascript"},
+		},
+		{
+			name:     "embedded images",
+			input:    `<img src="data:image/png;base64,AAAA"><img src="data:text/html;base64,AAAA">`,
+			expected: []string{`<img src="data:image/png;base64,AAAA"><img>`},
+		},
+		{
+			name:     "unclosed elements",
+			input:    "<table><tr><td>1</td></tr><div><b>x",
+			expected: []string{"<table><tr><td>1</td></tr><div><b>x</b></div></table>"},
+		},
+		{
+			name:     "stray end tags",
+			input:    "a</div></p>b",
+			expected: []string{"ab"},
+		},
+		{
+			name:       "unknown elements",
+			input:      "<custom-tag>text</custom-tag><iframe src=\"x\">inner</iframe>",
+			expected:   []string{"text"},
+			unexpected: []string{"custom-tag", "iframe", "inner"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := sanitizeHTML(tt.input)
+			for _, s := range tt.expected {
+				if !strings.Contains(result, s) {
+					t.Errorf("sanitizeHTML() = %q, should contain %q", result, s)
+				}
+			}
+			for _, s := range tt.unexpected {
+				if strings.Contains(result, s) {
+					t.Errorf("sanitizeHTML() = %q, should not contain %q", result, s)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// robotsFile is the robots.txt file served instead of the generated one,
+// set with -robots
+var robotsFile string
+
+// sitemapURLSet is a sitemap (https://www.sitemaps.org/protocol.html)
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"http://www.sitemaps.org/schemas/sitemap/0.9 urlset"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+type sitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+// sitemap lists the pages of the served trees, leaving out the files hidden
+// by the ignore rules and the drafts
+func sitemap(r *http.Request) *sitemapURLSet {
+	set := &sitemapURLSet{}
+	for _, m := range servedMounts() {
+		if m.Tree == nil {
+			continue
+		}
+		m.Tree.mu.RLock()
+		files := m.Tree.files
+		m.Tree.mu.RUnlock()
+
+		for _, page := range files {
+			if page.Meta.Draft {
+				continue
+			}
+			entry := sitemapURL{Loc: siteURL(r, page.URL)}
+			filePath := filepath.Join(m.Root, filepath.FromSlash(page.File))
+			if date, ok := lastModified(m.Repo, filePath); ok {
+				entry.LastMod = date.UTC().Format(time.RFC3339)
+			}
+			set.URLs = append(set.URLs, entry)
+		}
+	}
+	return set
+}
+
+// serveSitemap serves the generated /sitemap.xml
+func serveSitemap(w http.ResponseWriter, r *http.Request) {
+	output, err := xml.MarshalIndent(sitemap(r), "", "  ")
+	if err != nil {
+		log.Printf("Error generating sitemap: %v", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.Write([]byte(xml.Header))
+	w.Write(output)
+}
+
+// serveRobots serves the -robots file, else rules allowing every page and
+// pointing to the sitemap
+func serveRobots(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if robotsFile != "" {
+		content, err := os.ReadFile(robotsFile)
+		if err != nil {
+			log.Printf("Error reading robots file: %v", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		w.Write(content)
+		return
+	}
+	fmt.Fprintf(w, "User-agent: *\nAllow: /\n\nSitemap: %s\n", siteURL(r, "/sitemap.xml"))
+}
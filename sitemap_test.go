@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/xml"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Test the generated sitemap
+func TestServeSitemap(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTree(t, tmpDir, map[string]string{
+		"README.md":         "# Home",
+		"guide/README.md":   "# Guide",
+		"guide/install.md":  "# Install",
+		"notes.md":          "---\ndraft: true\n---\n# Notes",
+		"private/secret.md": "# Secret",
+		".godownignore":     "private\n",
+		"image.png":         "png",
+	})
+	date := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	os.Chtimes(filepath.Join(tmpDir, "guide", "install.md"), date, date)
+
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+
+	oldTree, oldRepo, oldBase := siteTree, siteRepo, baseURL
+	siteTree, siteRepo = newDocTree(".", "", "README.md"), nil
+	baseURL = "https://docs.example.com"
+	defer func() { siteTree, siteRepo, baseURL = oldTree, oldRepo, oldBase }()
+
+	req := httptest.NewRequest("GET", "/sitemap.xml", nil)
+	w := httptest.NewRecorder()
+	serveSitemap(w, req)
+
+	var set sitemapURLSet
+	if err := xml.Unmarshal(w.Body.Bytes(), &set); err != nil {
+		t.Fatalf("invalid sitemap: %v\n%s", err, w.Body.String())
+	}
+	var locs []string
+	for _, u := range set.URLs {
+		locs = append(locs, u.Loc)
+		if u.Loc == "https://docs.example.com/guide/install" && u.LastMod != "2024-05-01T12:00:00Z" {
+			t.Errorf("lastmod = %q, want the modification time", u.LastMod)
+		}
+	}
+	want := "https://docs.example.com/,https://docs.example.com/guide,https://docs.example.com/guide/install"
+	if got := strings.Join(locs, ","); got != want {
+		t.Errorf("sitemap URLs = %s, want %s", got, want)
+	}
+}
+
+// Test the generated and configured robots.txt
+func TestServeRobots(t *testing.T) {
+	oldBase, oldRobots := baseURL, robotsFile
+	defer func() { baseURL, robotsFile = oldBase, oldRobots }()
+
+	baseURL, robotsFile = "https://docs.example.com", ""
+	w := httptest.NewRecorder()
+	serveRobots(w, httptest.NewRequest("GET", "/robots.txt", nil))
+	if body := w.Body.String(); body != "User-agent: *\nAllow: /\n\nSitemap: https://docs.example.com/sitemap.xml\n" {
+		t.Errorf("generated robots.txt = %q", body)
+	}
+
+	robotsFile = filepath.Join(t.TempDir(), "robots.txt")
+	os.WriteFile(robotsFile, []byte("User-agent: *\nDisallow: /\n"), 0644)
+	w = httptest.NewRecorder()
+	serveRobots(w, httptest.NewRequest("GET", "/robots.txt", nil))
+	if body := w.Body.String(); body != "User-agent: *\nDisallow: /\n" {
+		t.Errorf("configured robots.txt = %q", body)
+	}
+}
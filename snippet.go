@@ -0,0 +1,188 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// regionStart and regionEnd match the comment lines delimiting a region of
+// a source file: "// region: name" ... "// endregion: name", in any comment
+// syntax
+var (
+	regionStart = regexp.MustCompile(`^\s*(?://|#|--|;|%|/\*|<!--)\s*region:\s*([\w.-]+)`)
+	regionEnd   = regexp.MustCompile(`^\s*(?://|#|--|;|%|/\*|<!--)\s*endregion\b(?::\s*([\w.-]+))?`)
+)
+
+// snippetLanguages maps the file extensions which are not the name of their
+// language in fenced code blocks
+var snippetLanguages = map[string]string{
+	".h":   "c",
+	".hpp": "cpp",
+	".js":  "javascript",
+	".md":  "markdown",
+	".mjs": "javascript",
+	".mk":  "makefile",
+	".py":  "python",
+	".rb":  "ruby",
+	".rs":  "rust",
+	".sh":  "bash",
+	".ts":  "typescript",
+	".tsx": "typescript",
+	".yml": "yaml",
+}
+
+// snippet returns a file, a line range (lines="10-20") or a region
+// (region="name") of a file as a fenced code block, followed by a link to
+// the text view of the file
+func (e *expansion) snippet(target string, params map[string]string, filePath string) ([]byte, error) {
+	resolved, err := e.resolve(target, filePath)
+	if err != nil {
+		return nil, err
+	}
+	if !isTextFile(resolved) {
+		return nil, fmt.Errorf("not a text file")
+	}
+	content, err := e.readFile(resolved)
+	if err != nil {
+		return nil, err
+	}
+	lines := strings.Split(strings.TrimSuffix(strings.ReplaceAll(string(content), "\r\n", "\n"), "\n"), "\n")
+
+	rel, _ := filepath.Rel(e.root, resolved)
+	label := filepath.ToSlash(rel)
+	switch lineRange, region := params["lines"], params["region"]; {
+	case lineRange != "" && region != "":
+		return nil, fmt.Errorf("lines and region cannot be combined")
+	case lineRange != "":
+		start, end, err := parseLineRange(lineRange, len(lines))
+		if err != nil {
+			return nil, err
+		}
+		lines = lines[start-1 : end]
+		label += fmt.Sprintf(", lines %d-%d", start, end)
+	case region != "":
+		if lines, err = snippetRegion(lines, region); err != nil {
+			return nil, err
+		}
+		label += ", region " + region
+	}
+
+	language := params["lang"]
+	if language == "" {
+		language = snippetLanguage(resolved)
+	}
+
+	// The link is relative to the page, even from an included file
+	link, _ := filepath.Rel(filepath.Dir(e.page), resolved)
+	link = (&url.URL{Path: filepath.ToSlash(link)}).EscapedPath() + "?view=text"
+
+	code := strings.Join(lines, "\n")
+	fence := "```"
+	for strings.Contains(code, fence) {
+		fence += "`"
+	}
+	return []byte(fmt.Sprintf("\n%s%s\n%s\n%s\n\n[%s](%s)\n", fence, language, code, fence, escapeLinkText(label), link)), nil
+}
+
+// parseLineRange parses a 1-based range of lines: "10-20", "10-" (to the
+// end) or "10"
+func parseLineRange(spec string, count int) (int, int, error) {
+	from, to, isRange := strings.Cut(spec, "-")
+	start, err := strconv.Atoi(strings.TrimSpace(from))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid line range %q", spec)
+	}
+	end := start
+	if isRange {
+		end = count
+		if to = strings.TrimSpace(to); to != "" {
+			if end, err = strconv.Atoi(to); err != nil {
+				return 0, 0, fmt.Errorf("invalid line range %q", spec)
+			}
+		}
+	}
+	if start < 1 || end < start || end > count {
+		return 0, 0, fmt.Errorf("lines %s out of range, the file has %d lines", spec, count)
+	}
+	return start, end, nil
+}
+
+// snippetRegion returns the lines of a region, without the markers of the
+// nested regions and with their common indentation removed
+func snippetRegion(lines []string, name string) ([]string, error) {
+	start := -1
+	for i, line := range lines {
+		if match := regionStart.FindStringSubmatch(line); match != nil && match[1] == name {
+			start = i + 1
+			break
+		}
+	}
+	if start < 0 {
+		return nil, fmt.Errorf("region %q not found", name)
+	}
+
+	var region []string
+	depth := 0
+	for _, line := range lines[start:] {
+		if regionStart.MatchString(line) {
+			depth++
+			continue
+		}
+		if match := regionEnd.FindStringSubmatch(line); match != nil {
+			if match[1] == name || (match[1] == "" && depth == 0) {
+				return dedent(region), nil
+			}
+			depth--
+			continue
+		}
+		region = append(region, line)
+	}
+	return nil, fmt.Errorf("region %q not closed", name)
+}
+
+// dedent removes the indentation common to the non-blank lines
+func dedent(lines []string) []string {
+	indent := ""
+	first := true
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		prefix := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		if first {
+			indent, first = prefix, false
+			continue
+		}
+		for !strings.HasPrefix(prefix, indent) {
+			indent = indent[:len(indent)-1]
+		}
+	}
+	result := make([]string, len(lines))
+	for i, line := range lines {
+		result[i] = strings.TrimPrefix(line, indent)
+	}
+	return result
+}
+
+// snippetLanguage returns the language of a source file for the fenced code
+// block, from its extension
+func snippetLanguage(filePath string) string {
+	ext := strings.ToLower(filepath.Ext(filePath))
+	if language, ok := snippetLanguages[ext]; ok {
+		return language
+	}
+	if ext == "" && strings.EqualFold(filepath.Base(filePath), "Makefile") {
+		return "makefile"
+	}
+	return strings.TrimPrefix(ext, ".")
+}
+
+// escapeLinkText escapes the Markdown characters of a link text
+func escapeLinkText(text string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `[`, `\[`, `]`, `\]`, `*`, `\*`, `_`, `\_`, "`", "\\`")
+	return replacer.Replace(text)
+}
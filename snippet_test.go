@@ -0,0 +1,161 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Test the embedding of source files, line ranges and regions
+func TestSnippetDirective(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTree(t, tmpDir, map[string]string{
+		"src/main.go": "package main\n\nimport \"fmt\"\n\n" +
+			"func main() {\n" +
+			"\t// region: greeting\n" +
+			"\tname := \"world\"\n" +
+			"\t// region: print\n" +
+			"\tfmt.Println(\"hello\", name)\n" +
+			"\t// endregion: print\n" +
+			"\t// endregion: greeting\n" +
+			"}\n",
+		"src/fence.md":    "````\ncode\n````\n",
+		"src/image.png":   "\x89PNG\x00\x00",
+		"docs/shared.md":  "{{< snippet \"../src/main.go\" lines=\"1\" >}}\n",
+		"docs/guide.md":   "",
+		"scripts/run.sh":  "#!/bin/sh\n# region: run\necho run\n# endregion\n",
+		"scripts/Open.sh": "# region: open\necho open\n",
+	})
+	page := filepath.Join(tmpDir, "docs", "guide.md")
+
+	tests := []struct {
+		name       string
+		body       string
+		contains   []string
+		unexpected []string
+	}{
+		{
+			name:     "whole file",
+			body:     `{{< snippet "../src/main.go" >}}`,
+			contains: []string{"```go\npackage main\n", "\n}\n```\n", "[src/main.go](../src/main.go?view=text)"},
+		},
+		{
+			name:       "line range",
+			body:       `{{< snippet "../src/main.go" lines="3-5" >}}`,
+			contains:   []string{"```go\nimport \"fmt\"\n\nfunc main() {\n```", "[src/main.go, lines 3-5]"},
+			unexpected: []string{"package main"},
+		},
+		{
+			name:     "open range and language",
+			body:     `{{< snippet "../src/main.go" lines="12-" lang="text" >}}`,
+			contains: []string{"```text\n}\n```"},
+		},
+		{
+			name:       "region",
+			body:       `{{< snippet "../src/main.go" region="greeting" >}}`,
+			contains:   []string{"```go\nname := \"world\"\nfmt.Println(\"hello\", name)\n```", "[src/main.go, region greeting]"},
+			unexpected: []string{"region: print", "endregion"},
+		},
+		{
+			name:     "nested region",
+			body:     `{{< snippet "../src/main.go" region="print" >}}`,
+			contains: []string{"```go\nfmt.Println(\"hello\", name)\n```"},
+		},
+		{
+			name:     "shell region",
+			body:     `{{< snippet "../scripts/run.sh" region="run" >}}`,
+			contains: []string{"```bash\necho run\n```"},
+		},
+		{
+			name:     "longer fence",
+			body:     `{{< snippet "../src/fence.md" >}}`,
+			contains: []string{"`````markdown\n````\ncode\n````\n`````"},
+		},
+		{
+			name:     "link from an included file",
+			body:     `{{< include "shared.md" >}}`,
+			contains: []string{"```go\npackage main\n```", "(../src/main.go?view=text)"},
+		},
+		{
+			name:     "missing file",
+			body:     `{{< snippet "../src/none.go" >}}`,
+			contains: []string{"Cannot embed `../src/none.go`: file not found"},
+		},
+		{
+			name:     "missing region",
+			body:     `{{< snippet "../src/main.go" region="none" >}}`,
+			contains: []string{`region "none" not found`},
+		},
+		{
+			name:     "unclosed region",
+			body:     `{{< snippet "../scripts/Open.sh" region="open" >}}`,
+			contains: []string{`region "open" not closed`},
+		},
+		{
+			name:     "out of range",
+			body:     `{{< snippet "../src/main.go" lines="10-40" >}}`,
+			contains: []string{"lines 10-40 out of range, the file has 12 lines"},
+		},
+		{
+			name:     "binary file",
+			body:     `{{< snippet "../src/image.png" >}}`,
+			contains: []string{"not a text file"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, _ := expandIncludes([]byte(tt.body), page, tmpDir)
+			for _, s := range tt.contains {
+				if !strings.Contains(string(result), s) {
+					t.Errorf("expandIncludes() = %q, should contain %q", result, s)
+				}
+			}
+			for _, s := range tt.unexpected {
+				if strings.Contains(string(result), s) {
+					t.Errorf("expandIncludes() = %q, should not contain %q", result, s)
+				}
+			}
+		})
+	}
+}
+
+// Test the parsing of line ranges
+func TestParseLineRange(t *testing.T) {
+	tests := []struct {
+		spec       string
+		start, end int
+		wantErr    bool
+	}{
+		{"3-5", 3, 5, false},
+		{"4", 4, 4, false},
+		{"7-", 7, 10, false},
+		{" 2 - 3 ", 2, 3, false},
+		{"0-2", 0, 0, true},
+		{"5-3", 0, 0, true},
+		{"9-11", 0, 0, true},
+		{"a-b", 0, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			start, end, err := parseLineRange(tt.spec, 10)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseLineRange(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			}
+			if start != tt.start || end != tt.end {
+				t.Errorf("parseLineRange(%q) = %d, %d, want %d, %d", tt.spec, start, end, tt.start, tt.end)
+			}
+		})
+	}
+}
+
+// Test the removal of the common indentation
+func TestDedent(t *testing.T) {
+	lines := []string{"\t\tif ok {", "", "\t\t\treturn", "\t\t}"}
+	expected := []string{"if ok {", "", "\treturn", "}"}
+	if result := dedent(lines); !reflect.DeepEqual(result, expected) {
+		t.Errorf("dedent() = %q, want %q", result, expected)
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/gomarkdown/markdown/parser"
+)
+
+// summaryFileName is the mdBook-style table of contents defining the reading order
+const summaryFileName = "SUMMARY.md"
+
+// summaryNav builds the navigation tree from the SUMMARY.md file of the root,
+// returning nil when there is none
+func summaryNav(root string) *navNode {
+	content, err := os.ReadFile(filepath.Join(root, summaryFileName))
+	if err != nil {
+		return nil
+	}
+	_, body := splitFrontMatter(content)
+
+	p := parser.NewWithExtensions(parser.CommonExtensions)
+	doc := p.Parse(body)
+
+	node := &navNode{Name: summaryFileName, IsDir: true}
+	var part *navNode
+	add := func(entry *navNode) {
+		if part != nil {
+			part.Children = append(part.Children, entry)
+			return
+		}
+		node.Children = append(node.Children, entry)
+	}
+
+	for i, block := range doc.GetChildren() {
+		switch block := block.(type) {
+		case *ast.Heading:
+			// A leading heading is the title of the summary, the next ones
+			// start new parts
+			if i == 0 {
+				continue
+			}
+			part = &navNode{Title: nodeText(block), IsDir: true}
+			node.Children = append(node.Children, part)
+		case *ast.HorizontalRule:
+			// A separator ends the current part
+			part = nil
+		case *ast.Paragraph:
+			for _, link := range findLinks(block) {
+				add(summaryEntry(link))
+			}
+		case *ast.List:
+			for _, entry := range summaryList(block) {
+				add(entry)
+			}
+		}
+	}
+
+	return node
+}
+
+// summaryList converts a (possibly nested) list of links to navigation nodes
+func summaryList(list *ast.List) []*navNode {
+	var entries []*navNode
+	for _, item := range list.GetChildren() {
+		var entry *navNode
+		var children []*navNode
+		for _, block := range item.GetChildren() {
+			switch block := block.(type) {
+			case *ast.List:
+				children = append(children, summaryList(block)...)
+			default:
+				if entry != nil {
+					continue
+				}
+				if links := findLinks(block); len(links) > 0 {
+					entry = summaryEntry(links[0])
+				} else if text := nodeText(block); text != "" {
+					entry = &navNode{Title: text}
+				}
+			}
+		}
+		if entry == nil {
+			entry = &navNode{}
+		}
+		entry.Children = children
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// summaryEntry converts a link of the summary to a navigation node. Links
+// without destination are draft chapters, listed without page.
+func summaryEntry(link *ast.Link) *navNode {
+	entry := &navNode{Title: nodeText(link)}
+
+	dest := string(link.Destination)
+	if i := strings.IndexAny(dest, "#?"); i >= 0 {
+		dest = dest[:i]
+	}
+	if unescaped, err := url.PathUnescape(dest); err == nil {
+		dest = unescaped
+	}
+	if dest == "" || strings.Contains(dest, "://") || !isMarkdownFile(dest) {
+		return entry
+	}
+
+	file := path.Clean(strings.TrimPrefix(dest, "/"))
+	if strings.HasPrefix(file, "../") {
+		return entry
+	}
+
+	entry.Name = path.Base(file)
+	entry.File = file
+	entry.URL = filePageURL(file)
+	return entry
+}
+
+// findLinks returns the links found below a node
+func findLinks(node ast.Node) []*ast.Link {
+	var links []*ast.Link
+	ast.WalkFunc(node, func(n ast.Node, entering bool) ast.WalkStatus {
+		if link, ok := n.(*ast.Link); ok && entering {
+			links = append(links, link)
+			return ast.SkipChildren
+		}
+		return ast.GoToNext
+	})
+	return links
+}
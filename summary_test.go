@@ -0,0 +1,146 @@
+package main
+
+import (
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+// Test SUMMARY.md parsing
+func TestSummaryNav(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTree(t, tmpDir, map[string]string{
+		"SUMMARY.md": `# Summary
+
+[Introduction](README.md)
+
+- [Getting started](start.md)
+  - [Install](start/install%20guide.md)
+  - [Draft chapter]()
+- [Reference](reference/README.md)
+
+# Advanced
+
+- [Internals](internals.md#overview)
+
+---
+
+[Appendix](appendix.md)
+`,
+		"README.md": "# Intro",
+	})
+
+	oldIndex := indexFile
+	indexFile = "README.md"
+	defer func() { indexFile = oldIndex }()
+
+	nav := summaryNav(tmpDir)
+	if nav == nil {
+		t.Fatal("summaryNav() should parse SUMMARY.md")
+	}
+
+	var titles []string
+	for _, child := range nav.Children {
+		titles = append(titles, child.Title)
+	}
+	if got := strings.Join(titles, "|"); got != "Introduction|Getting started|Reference|Advanced|Appendix" {
+		t.Errorf("summary entries = %v", got)
+	}
+
+	start := nav.Children[1]
+	if start.URL != "/start" || len(start.Children) != 2 {
+		t.Fatalf("chapter = %+v", start)
+	}
+	if start.Children[0].File != "start/install guide.md" || start.Children[0].URL != "/start/install%20guide" {
+		t.Errorf("nested chapter = %+v", start.Children[0])
+	}
+	if start.Children[1].File != "" || start.Children[1].Title != "Draft chapter" {
+		t.Errorf("draft chapter = %+v", start.Children[1])
+	}
+	if nav.Children[2].URL != "/reference" {
+		t.Errorf("README chapter URL = %v, want /reference", nav.Children[2].URL)
+	}
+
+	part := nav.Children[3]
+	if len(part.Children) != 1 || part.Children[0].File != "internals.md" {
+		t.Errorf("part = %+v", part)
+	}
+
+	if summaryNav(t.TempDir()) != nil {
+		t.Errorf("summaryNav() should return nil without SUMMARY.md")
+	}
+}
+
+// Test reading order and previous/next links
+func TestPagination(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTree(t, tmpDir, map[string]string{
+		"SUMMARY.md": "- [Intro](README.md)\n- [One](one.md)\n  - [Two](two.md)\n- [Three](three.md)\n",
+		"README.md":  "# Intro",
+	})
+
+	oldIndex := indexFile
+	indexFile = "README.md"
+	defer func() { indexFile = oldIndex }()
+
+	tree := newDocTree(tmpDir, "", "README.md")
+
+	var files []string
+	for _, page := range tree.pages {
+		files = append(files, page.File)
+	}
+	if got := strings.Join(files, ","); got != "README.md,one.md,two.md,three.md" {
+		t.Errorf("reading order = %v", got)
+	}
+
+	html := string(tree.pagination("two.md"))
+	if !strings.Contains(html, `<a class="prev" href="/one" rel="prev">&larr; One</a>`) {
+		t.Errorf("pagination should link to the previous page, got:\n%s", html)
+	}
+	if !strings.Contains(html, `<a class="next" href="/three" rel="next">Three &rarr;</a>`) {
+		t.Errorf("pagination should link to the next page, got:\n%s", html)
+	}
+
+	if html := string(tree.pagination("README.md")); strings.Contains(html, "prev") {
+		t.Errorf("first page should not have a previous link, got:\n%s", html)
+	}
+	if tree.pagination("unknown.md") != "" {
+		t.Errorf("pages outside the reading order should not have links")
+	}
+}
+
+// Test previous/next links in Markdown pages
+func TestServeMarkdownPagination(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTree(t, tmpDir, map[string]string{
+		"README.md": "# Home",
+		"a.md":      "# Page A",
+		"b.md":      "# Page B",
+	})
+
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+
+	oldIndex := indexFile
+	indexFile = "README.md"
+	defer func() { indexFile = oldIndex }()
+
+	oldTree := siteTree
+	siteTree = newDocTree(".", "", "README.md")
+	defer func() { siteTree = oldTree }()
+
+	req := httptest.NewRequest("GET", "/a", nil)
+	w := httptest.NewRecorder()
+
+	serveMarkdown(w, req)
+
+	body := w.Body.String()
+	if !strings.Contains(body, `<nav class="godown-pagination">`) {
+		t.Fatalf("serveMarkdown() body should contain pagination, got:\n%s", body)
+	}
+	if !strings.Contains(body, `href="/" rel="prev">&larr; Home</a>`) || !strings.Contains(body, `href="/b" rel="next">Page B &rarr;</a>`) {
+		t.Errorf("serveMarkdown() pagination should follow directory order, got:\n%s", body)
+	}
+}
@@ -0,0 +1,328 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// tablePageRows is the number of rows of a table page, the other pages being
+// browsed with ?page=
+const tablePageRows = 1000
+
+// tablePageSize is the number of rows shown at once in the browser
+const tablePageSize = 100
+
+// tableSampleSize is the size of the beginning of a file from which its
+// delimiter and header are guessed
+const tableSampleSize = 64 << 10
+
+// tableDelimiters are the delimiters tried when sniffing a CSV file
+var tableDelimiters = []rune{',', ';', '\t', '|'}
+
+// isTableFile checks if the file is a CSV or TSV data file
+func isTableFile(filePath string) bool {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".csv", ".tsv":
+		return true
+	}
+	return false
+}
+
+// sniffDelimiter guesses the delimiter of CSV data: the one splitting the
+// first lines into the same number of fields, as many as possible
+func sniffDelimiter(content []byte) rune {
+	lines := bytes.SplitN(content, []byte("\n"), 21)
+	if len(lines) > 20 {
+		lines = lines[:20]
+	}
+	sample := bytes.Join(lines, []byte("\n"))
+
+	best, bestScore := ',', 0
+	for _, delimiter := range tableDelimiters {
+		reader := csv.NewReader(bytes.NewReader(sample))
+		reader.Comma = delimiter
+		reader.FieldsPerRecord = -1
+		reader.LazyQuotes = true
+		records, _ := reader.ReadAll()
+		if len(records) == 0 || len(records[0]) < 2 {
+			continue
+		}
+
+		// Consistent rows count the most, then the number of fields
+		consistent := 0
+		for _, record := range records {
+			if len(record) == len(records[0]) {
+				consistent++
+			}
+		}
+		if score := consistent*1000 + len(records[0]); score > bestScore {
+			best, bestScore = delimiter, score
+		}
+	}
+	return best
+}
+
+// parseTable reads the rows of CSV or TSV data, padded to the same number of
+// columns, at most limit rows. It reports if the data was truncated.
+func parseTable(content []byte, delimiter rune, limit int) ([][]string, bool, error) {
+	return readTablePage(bytes.NewReader(content), delimiter, 0, limit)
+}
+
+// readTablePage reads at most limit rows of CSV or TSV data after skipping
+// the first ones, without keeping the skipped rows in memory. The rows are
+// padded to the same number of columns; it reports if more rows follow.
+func readTablePage(input io.Reader, delimiter rune, skip, limit int) ([][]string, bool, error) {
+	buffered := bufio.NewReader(input)
+	if bom, _ := buffered.Peek(3); string(bom) == "\uFEFF" {
+		buffered.Discard(3)
+	}
+	reader := csv.NewReader(buffered)
+	reader.Comma = delimiter
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	reader.ReuseRecord = true
+	for i := 0; i < skip; i++ {
+		if _, err := reader.Read(); err != nil {
+			if err == io.EOF {
+				return nil, false, nil
+			}
+			return nil, false, err
+		}
+	}
+	reader.ReuseRecord = false
+
+	var rows [][]string
+	columns := 0
+	for {
+		record, err := reader.Read()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, false, err
+		}
+		if len(rows) == limit {
+			return padRows(rows, columns), true, nil
+		}
+		rows = append(rows, record)
+		columns = max(columns, len(record))
+	}
+	return padRows(rows, columns), false, nil
+}
+
+// padRows pads the rows with empty cells to the number of columns
+func padRows(rows [][]string, columns int) [][]string {
+	for i, row := range rows {
+		for len(row) < columns {
+			row = append(row, "")
+		}
+		rows[i] = row
+	}
+	return rows
+}
+
+// isNumber checks if a cell holds a number
+func isNumber(cell string) bool {
+	_, err := strconv.ParseFloat(strings.TrimSpace(cell), 64)
+	return err == nil
+}
+
+// hasHeader guesses if the first row names the columns: its cells are
+// distinct labels, and the columns are numeric or have values of the same
+// length, unlike the label
+func hasHeader(rows [][]string) bool {
+	if len(rows) < 2 {
+		return false
+	}
+	seen := map[string]bool{}
+	for _, cell := range rows[0] {
+		cell = strings.TrimSpace(cell)
+		if cell == "" || isNumber(cell) || seen[cell] {
+			return false
+		}
+		seen[cell] = true
+	}
+
+	votes := 0
+	for column, label := range rows[0] {
+		numeric, length := true, -1
+		for _, row := range rows[1:] {
+			cell := strings.TrimSpace(row[column])
+			if cell == "" {
+				continue
+			}
+			numeric = numeric && isNumber(cell)
+			if length == -1 {
+				length = len(cell)
+			} else if length != len(cell) {
+				length = -2
+			}
+		}
+		switch {
+		case length == -1:
+			// Empty column
+		case numeric:
+			votes++
+		case length >= 0 && length != len(strings.TrimSpace(label)):
+			votes++
+		case length >= 0:
+			votes--
+		}
+	}
+	return votes > 0
+}
+
+// columnName returns the spreadsheet name of a column: A, B, ..., Z, AA...
+func columnName(column int) string {
+	name := ""
+	for column++; column > 0; column = (column - 1) / 26 {
+		name = string(rune('A'+(column-1)%26)) + name
+	}
+	return name
+}
+
+// tablePageURL returns the query string of a page of a table
+func tablePageURL(query url.Values, page int) string {
+	values := url.Values{}
+	for key, value := range query {
+		values[key] = value
+	}
+	values.Set("page", strconv.Itoa(page))
+	return "?" + values.Encode()
+}
+
+// renderTable renders a page of rows as a table sortable, filterable and
+// paginated in the browser, with the links to the other pages
+func renderTable(labels []string, rows [][]string, delimiter rune, query url.Values, page int, more bool) string {
+	var result strings.Builder
+	fmt.Fprintf(&result, "<div class=\"godown-table\" data-page-size=\"%d\">\n", tablePageSize)
+
+	columns := len(labels)
+	if len(rows) > 0 {
+		columns = max(columns, len(rows[0]))
+	}
+	for column := len(labels); column < columns; column++ {
+		labels = append(labels, columnName(column))
+	}
+	rows = padRows(rows, columns)
+
+	delimiterName := string(delimiter)
+	if delimiter == '\t' {
+		delimiterName = "tab"
+	}
+	rowsInfo := strconv.Itoa(len(rows))
+	if page > 1 || more {
+		first := (page-1)*tablePageRows + 1
+		rowsInfo = fmt.Sprintf("%d–%d", first, first+len(rows)-1)
+	}
+	fmt.Fprintf(&result, "<p class=\"godown-table-info\">Rows: %s, columns: %d, delimiter: %s</p>\n", rowsInfo, columns, template.HTMLEscapeString(delimiterName))
+
+	if page > 1 || more {
+		result.WriteString("<div class=\"godown-table-nav\">\n")
+		if page > 1 {
+			fmt.Fprintf(&result, "<a href=\"%s\">&laquo; First</a>\n", template.HTMLEscapeString(tablePageURL(query, 1)))
+			fmt.Fprintf(&result, "<a href=\"%s\" rel=\"prev\">&lsaquo; Previous</a>\n", template.HTMLEscapeString(tablePageURL(query, page-1)))
+		}
+		fmt.Fprintf(&result, "<span>Page %d</span>\n", page)
+		if more {
+			fmt.Fprintf(&result, "<a href=\"%s\" rel=\"next\">Next &rsaquo;</a>\n", template.HTMLEscapeString(tablePageURL(query, page+1)))
+		}
+		result.WriteString("</div>\n")
+	}
+
+	result.WriteString("<table class=\"godown-csv\">\n<thead>\n<tr>")
+	for _, label := range labels {
+		fmt.Fprintf(&result, "<th>%s</th>", template.HTMLEscapeString(label))
+	}
+	result.WriteString("</tr>\n</thead>\n<tbody>\n")
+	for _, row := range rows {
+		result.WriteString("<tr>")
+		for _, cell := range row {
+			if isNumber(cell) {
+				fmt.Fprintf(&result, "<td class=\"godown-number\">%s</td>", template.HTMLEscapeString(cell))
+			} else {
+				fmt.Fprintf(&result, "<td>%s</td>", template.HTMLEscapeString(cell))
+			}
+		}
+		result.WriteString("</tr>\n")
+	}
+	result.WriteString("</tbody>\n</table>\n</div>\n")
+	return result.String()
+}
+
+// serveTableFile renders a page of a CSV or TSV file as a table, or the
+// file as text when it cannot be parsed. The delimiter and the header are
+// guessed from the beginning of the file, and only the rows up to the end of
+// the page are read.
+func serveTableFile(w http.ResponseWriter, r *http.Request, filePath string) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer file.Close()
+
+	sample := make([]byte, tableSampleSize)
+	n, _ := io.ReadFull(file, sample)
+	sample = sample[:n]
+	if n == tableSampleSize {
+		// Whole lines only
+		if end := bytes.LastIndexByte(sample, '\n'); end >= 0 {
+			sample = sample[:end+1]
+		}
+	}
+
+	delimiter := '\t'
+	if strings.EqualFold(filepath.Ext(filePath), ".csv") {
+		delimiter = sniffDelimiter(sample)
+	}
+	head, _, err := parseTable(sample, delimiter, 20)
+	if err != nil {
+		serveTextFile(w, r, filePath)
+		return
+	}
+
+	query := r.URL.Query()
+	page := 1
+	if value, err := strconv.Atoi(query.Get("page")); err == nil && value > 1 {
+		page = value
+	}
+	skip := (page - 1) * tablePageRows
+	var labels []string
+	if hasHeader(head) {
+		labels = head[0]
+		skip++
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	rows, more, err := readTablePage(file, delimiter, skip, tablePageRows)
+	if err != nil {
+		serveTextFile(w, r, filePath)
+		return
+	}
+	if len(rows) == 0 && page > 1 {
+		http.NotFound(w, r)
+		return
+	}
+
+	renderPage(w, PageData{
+		Title:     filepath.Base(filePath),
+		Content:   template.HTML(renderTable(labels, rows, delimiter, query, page, more)),
+		StylePath: "/__godown_style.css",
+		Views:     template.HTML(viewLinks(r, filePath, viewTable)),
+	})
+}
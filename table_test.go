@@ -0,0 +1,187 @@
+package main
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Test delimiter sniffing
+func TestSniffDelimiter(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected rune
+	}{
+		{"comma", "name,age\nalice,30\nbob,25\n", ','},
+		{"semicolon", "name;price\nbook;3,50\npen;1,20\n", ';'},
+		{"tab", "name\tage\nalice\t30\n", '\t'},
+		{"pipe", "a|b|c\n1|2|3\n", '|'},
+		{"quoted commas", "\"last, first\";city\n\"doe, john\";paris\n", ';'},
+		{"single column", "name\nalice\n", ','},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := sniffDelimiter([]byte(tt.content)); result != tt.expected {
+				t.Errorf("sniffDelimiter() = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+// Test header detection
+func TestHasHeader(t *testing.T) {
+	tests := []struct {
+		name     string
+		rows     [][]string
+		expected bool
+	}{
+		{"numeric columns", [][]string{{"name", "age"}, {"alice", "30"}, {"bob", "25"}}, true},
+		{"same length values", [][]string{{"code", "country"}, {"FR", "France"}, {"DE", "Germany"}}, true},
+		{"numeric first row", [][]string{{"1", "2"}, {"3", "4"}}, false},
+		{"duplicate labels", [][]string{{"x", "x"}, {"1", "2"}}, false},
+		{"empty label", [][]string{{"name", ""}, {"alice", "30"}}, false},
+		{"data only", [][]string{{"alice", "paris"}, {"bob", "london"}, {"carol", "rome"}}, false},
+		{"single row", [][]string{{"name", "age"}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := hasHeader(tt.rows); result != tt.expected {
+				t.Errorf("hasHeader(%v) = %v, want %v", tt.rows, result, tt.expected)
+			}
+		})
+	}
+}
+
+// Test CSV parsing with ragged rows and truncation
+func TestParseTable(t *testing.T) {
+	rows, truncated, err := parseTable([]byte("\uFEFFa,b,c\n1,2\n\"x,y\",3,4\n"), ',', 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := [][]string{{"a", "b", "c"}, {"1", "2", ""}, {"x,y", "3", "4"}}
+	if !reflect.DeepEqual(rows, expected) || truncated {
+		t.Errorf("parseTable() = %q, %v, want %q, false", rows, truncated, expected)
+	}
+
+	rows, truncated, _ = parseTable([]byte("1\n2\n3\n"), ',', 2)
+	if len(rows) != 2 || !truncated {
+		t.Errorf("parseTable() = %q, %v, want 2 rows truncated", rows, truncated)
+	}
+}
+
+// Test reading a page of rows, skipping the previous ones
+func TestReadTablePage(t *testing.T) {
+	content := "\uFEFFa\n\"b\nb\"\nc,c\nd\n"
+	rows, more, err := readTablePage(strings.NewReader(content), ',', 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := [][]string{{"b\nb", ""}, {"c", "c"}}
+	if !reflect.DeepEqual(rows, expected) || !more {
+		t.Errorf("readTablePage() = %q, %v, want %q, true", rows, more, expected)
+	}
+
+	rows, more, _ = readTablePage(strings.NewReader(content), ',', 10, 2)
+	if len(rows) != 0 || more {
+		t.Errorf("readTablePage() past the end = %q, %v, want no rows", rows, more)
+	}
+}
+
+// Test spreadsheet column names
+func TestColumnName(t *testing.T) {
+	for column, expected := range map[int]string{0: "A", 25: "Z", 26: "AA", 27: "AB", 701: "ZZ", 702: "AAA"} {
+		if result := columnName(column); result != expected {
+			t.Errorf("columnName(%d) = %q, want %q", column, result, expected)
+		}
+	}
+}
+
+// Test the table and text views of data files
+func TestServeTableFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+
+	var large strings.Builder
+	large.WriteString("id,label\n")
+	for i := 1; i <= 2*tablePageRows+5; i++ {
+		fmt.Fprintf(&large, "%d,row %d\n", i, i)
+	}
+	writeTree(t, tmpDir, map[string]string{
+		"people.csv": "name;age\n<alice>;30\nbob;25\n",
+		"raw.tsv":    "x\ty\nz\tt\n",
+		"large.csv":  large.String(),
+	})
+
+	tests := []struct {
+		url        string
+		expected   []string
+		unexpected []string
+	}{
+		{
+			url: "/people.csv",
+			expected: []string{
+				`<div class="godown-table" data-page-size="100">`,
+				"Rows: 2, columns: 2, delimiter: ;",
+				"<th>name</th><th>age</th>",
+				`<td>&lt;alice&gt;</td><td class="godown-number">30</td>`,
+				`class="active" aria-current="page">Table</a>`,
+				`<a href="/people.csv?view=text">Text</a>`,
+			},
+		},
+		{
+			url:        "/people.csv?view=text",
+			expected:   []string{`<pre class="godown-text">name;age`, `class="active" aria-current="page">Text</a>`},
+			unexpected: []string{"<table"},
+		},
+		{
+			url: "/large.csv",
+			expected: []string{
+				"Rows: 1–1000, columns: 2",
+				"<th>id</th><th>label</th>",
+				"<td>row 1000</td>",
+				`<a href="?page=2" rel="next">Next &rsaquo;</a>`,
+			},
+			unexpected: []string{"row 1001", "Previous"},
+		},
+		{
+			url: "/large.csv?page=3",
+			expected: []string{
+				"Rows: 2001–2005, columns: 2",
+				"<th>id</th><th>label</th>",
+				"<td>row 2001</td>",
+				`<a href="?page=2" rel="prev">&lsaquo; Previous</a>`,
+			},
+			unexpected: []string{"row 2000<", "Next"},
+		},
+		{
+			url:      "/raw.tsv",
+			expected: []string{"delimiter: tab", "<th>A</th><th>B</th>", "<td>x</td><td>y</td>", "Rows: 2,"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			serveMarkdown(w, httptest.NewRequest("GET", tt.url, nil))
+			body := w.Body.String()
+			for _, s := range tt.expected {
+				if !strings.Contains(body, s) {
+					t.Errorf("body should contain %q, got %q", s, body)
+				}
+			}
+			for _, s := range tt.unexpected {
+				if strings.Contains(body, s) {
+					t.Errorf("body should not contain %q", s)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// tagsPath is the URL path of the tag index
+const tagsPath = "/__godown/tags"
+
+// tagIndex lists the pages of a tree by front matter tag
+type tagIndex struct {
+	names map[string]string      // Displayed name by tag key
+	pages map[string][]*pageInfo // Tagged pages by tag key
+}
+
+// buildTagIndex indexes the pages by tag
+func buildTagIndex(pages []*pageInfo) *tagIndex {
+	tags := &tagIndex{names: map[string]string{}, pages: map[string][]*pageInfo{}}
+	for _, page := range pages {
+		seen := map[string]bool{}
+		for _, tag := range page.Meta.Tags {
+			key := tagKey(tag)
+			if key == "" || seen[key] {
+				continue
+			}
+			seen[key] = true
+			if _, ok := tags.names[key]; !ok {
+				tags.names[key] = strings.TrimPrefix(strings.TrimSpace(tag), "#")
+			}
+			tags.pages[key] = append(tags.pages[key], page)
+		}
+	}
+	return tags
+}
+
+// tagKey normalizes a tag: "#Go" and "go" are the same tag
+func tagKey(tag string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+}
+
+// tagURL returns the URL of the page of a tag
+func tagURL(tag string) string {
+	return tagsPath + "/" + url.PathEscape(tagKey(tag))
+}
+
+// collectTags merges the tag indexes of the served trees
+func collectTags() *tagIndex {
+	merged := &tagIndex{names: map[string]string{}, pages: map[string][]*pageInfo{}}
+	for _, tree := range docTrees() {
+		tree.mu.RLock()
+		if tree.tags != nil {
+			for key, name := range tree.tags.names {
+				if _, ok := merged.names[key]; !ok {
+					merged.names[key] = name
+				}
+				merged.pages[key] = append(merged.pages[key], tree.tags.pages[key]...)
+			}
+		}
+		tree.mu.RUnlock()
+	}
+	for _, pages := range merged.pages {
+		sortPages(pages)
+	}
+	return merged
+}
+
+// serveTags serves the list of the tags (/__godown/tags) and the pages of a
+// tag (/__godown/tags/<tag>)
+func serveTags(w http.ResponseWriter, r *http.Request) {
+	tags := collectTags()
+	data := PageData{StylePath: "/__godown_style.css"}
+	if len(mounts) == 0 && siteTree != nil {
+		data.Sidebar = siteTree.sidebar("")
+	}
+
+	tag := strings.Trim(strings.TrimPrefix(r.URL.Path, tagsPath), "/")
+	if tag == "" {
+		data.Title = "Tags"
+		data.Content = tagList(tags)
+		renderPage(w, data)
+		return
+	}
+
+	key := tagKey(tag)
+	pages, ok := tags.pages[key]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	var result strings.Builder
+	fmt.Fprintf(&result, "<h1>Tag: %s</h1>\n<ul class=\"godown-tagged\">\n", template.HTMLEscapeString(tags.names[key]))
+	for _, page := range pages {
+		fmt.Fprintf(&result, "<li><a href=\"%s\">%s</a>", template.HTMLEscapeString(page.URL), template.HTMLEscapeString(page.Title))
+		if page.Meta.Description != "" {
+			fmt.Fprintf(&result, "<p>%s</p>", template.HTMLEscapeString(page.Meta.Description))
+		}
+		result.WriteString("</li>\n")
+	}
+	fmt.Fprintf(&result, "</ul>\n<p><a href=\"%s\">All tags</a></p>\n", tagsPath)
+
+	data.Title = "Tag: " + tags.names[key]
+	data.Content = template.HTML(result.String())
+	renderPage(w, data)
+}
+
+// tagList renders the tags with their number of pages, sorted by name
+func tagList(tags *tagIndex) template.HTML {
+	keys := make([]string, 0, len(tags.names))
+	for key := range tags.names {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var result strings.Builder
+	result.WriteString("<h1>Tags</h1>\n")
+	if len(keys) == 0 {
+		result.WriteString("<p>No page is tagged yet.</p>\n")
+		return template.HTML(result.String())
+	}
+	result.WriteString("<ul class=\"godown-tags\">\n")
+	for _, key := range keys {
+		fmt.Fprintf(&result, "<li><a class=\"godown-tag\" href=\"%s\">%s</a> <span class=\"godown-tag-count\">%d</span></li>\n",
+			template.HTMLEscapeString(tagURL(key)),
+			template.HTMLEscapeString(tags.names[key]),
+			len(tags.pages[key]),
+		)
+	}
+	result.WriteString("</ul>\n")
+	return template.HTML(result.String())
+}
+
+// pageTags renders the links to the pages of the tags of a page
+func pageTags(tags []string) template.HTML {
+	var result strings.Builder
+	seen := map[string]bool{}
+	for _, tag := range tags {
+		key := tagKey(tag)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		fmt.Fprintf(&result, "<a class=\"godown-tag\" href=\"%s\">%s</a>\n",
+			template.HTMLEscapeString(tagURL(key)),
+			template.HTMLEscapeString(strings.TrimPrefix(strings.TrimSpace(tag), "#")),
+		)
+	}
+	return template.HTML(result.String())
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+// Test the tag index of a tree
+func TestBuildTagIndex(t *testing.T) {
+	pages := []*pageInfo{
+		{File: "a.md", Title: "A", Meta: frontMatter{Tags: nameList{"Go", "#web", "go"}}},
+		{File: "b.md", Title: "B", Meta: frontMatter{Tags: nameList{"go", " "}}},
+		{File: "c.md", Title: "C"},
+	}
+	tags := buildTagIndex(pages)
+
+	if len(tags.names) != 2 || tags.names["go"] != "Go" || tags.names["web"] != "web" {
+		t.Errorf("names = %v, want Go and web", tags.names)
+	}
+	if len(tags.pages["go"]) != 2 || len(tags.pages["web"]) != 1 {
+		t.Errorf("pages = %v, want 2 pages tagged go and 1 tagged web", tags.pages)
+	}
+}
+
+// Test the tag pages
+func TestServeTags(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTree(t, tmpDir, map[string]string{
+		"README.md":       "# Home",
+		"guide.md":        "---\ntitle: Guide\ndescription: Getting <started>\ntags: [Go, tutorial]\n---\nContent",
+		"api.md":          "---\ntags: go, reference\n---\n# API",
+		"drafts/draft.md": "---\ntags: [go]\n---\n# Draft",
+		".godownignore":   "drafts\n",
+	})
+
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+
+	oldTree := siteTree
+	siteTree = newDocTree(".", "", "README.md")
+	defer func() { siteTree = oldTree }()
+
+	tests := []struct {
+		url        string
+		status     int
+		contains   []string
+		unexpected []string
+	}{
+		{
+			url:    "/__godown/tags",
+			status: 200,
+			contains: []string{
+				`<a class="godown-tag" href="/__godown/tags/go">go</a> <span class="godown-tag-count">2</span>`,
+				`href="/__godown/tags/reference">reference</a>`,
+				`href="/__godown/tags/tutorial">tutorial</a>`,
+			},
+		},
+		{
+			url:        "/__godown/tags/GO",
+			status:     200,
+			contains:   []string{"<h1>Tag: go</h1>", `<li><a href="/api">API</a></li>`, `<li><a href="/guide">Guide</a><p>Getting &lt;started&gt;</p></li>`},
+			unexpected: []string{"Draft"},
+		},
+		{url: "/__godown/tags/unknown", status: 404},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.url, nil)
+			w := httptest.NewRecorder()
+			serveTags(w, req)
+
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d", w.Code, tt.status)
+			}
+			body := w.Body.String()
+			for _, expected := range tt.contains {
+				if !strings.Contains(body, expected) {
+					t.Errorf("body should contain %q, got:\n%s", expected, body)
+				}
+			}
+			for _, unexpected := range tt.unexpected {
+				if strings.Contains(body, unexpected) {
+					t.Errorf("body should not contain %q", unexpected)
+				}
+			}
+		})
+	}
+
+	// Pages link to their tags
+	req := httptest.NewRequest("GET", "/guide", nil)
+	w := httptest.NewRecorder()
+	serveMarkdown(w, req)
+	if !strings.Contains(w.Body.String(), `<nav class="godown-page-tags">`+"\n    "+`<a class="godown-tag" href="/__godown/tags/go">Go</a>`) {
+		t.Errorf("page should link to its tags, got:\n%s", w.Body.String())
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Presentations of a file, chosen from its type or with ?view=
+const (
+	viewMedia    = "media"
+	viewMarkdown = "markdown"
+	viewTable    = "table"
+	viewData     = "data"
+	viewNotebook = "notebook"
+	viewText     = "text"
+	viewSource   = "source"
+	viewHex      = "hex"
+)
+
+// defaultView returns the presentation of a file from its type
+func defaultView(filePath string) string {
+	switch {
+	case isMediaFile(filePath):
+		return viewMedia
+	case isMarkdownFile(filePath):
+		return viewMarkdown
+	case isTableFile(filePath) && isTextFile(filePath):
+		return viewTable
+	case isDataFile(filePath) && isTextFile(filePath):
+		return viewData
+	case isNotebookFile(filePath) && isTextFile(filePath):
+		return viewNotebook
+	case isTextFile(filePath):
+		return viewText
+	default:
+		return viewHex
+	}
+}
+
+// fileView returns the presentation requested with ?view=, falling back to
+// the default presentation of the file
+func fileView(r *http.Request, filePath string) string {
+	switch view := r.URL.Query().Get("view"); view {
+	case viewHex, viewText:
+		return view
+	case viewSource:
+		if isMarkdownFile(filePath) {
+			return viewSource
+		}
+		return viewText
+	}
+	return defaultView(filePath)
+}
+
+// viewLinks renders the links to the presentations of a file, marking the
+// current one
+func viewLinks(r *http.Request, filePath, current string) string {
+	type viewLink struct{ view, label, query string }
+	var links []viewLink
+	switch base := defaultView(filePath); base {
+	case viewMarkdown:
+		links = []viewLink{{base, "Rendered", ""}, {viewSource, "Source", "?view=source"}, {viewHex, "Hex", "?view=hex"}}
+	case viewMedia:
+		links = []viewLink{{base, "Media", ""}, {viewHex, "Hex", "?view=hex"}}
+	case viewTable:
+		links = []viewLink{{base, "Table", ""}, {viewText, "Text", "?view=text"}, {viewHex, "Hex", "?view=hex"}}
+	case viewData:
+		links = []viewLink{{base, "Tree", ""}, {viewText, "Text", "?view=text"}, {viewHex, "Hex", "?view=hex"}}
+	case viewNotebook:
+		links = []viewLink{{base, "Notebook", ""}, {viewText, "Text", "?view=text"}, {viewHex, "Hex", "?view=hex"}}
+	case viewHex:
+		links = []viewLink{{base, "Hex", ""}, {viewText, "Text", "?view=text"}}
+	default:
+		links = []viewLink{{base, "Text", ""}, {viewHex, "Hex", "?view=hex"}}
+	}
+
+	path := template.HTMLEscapeString(r.URL.EscapedPath())
+	var result strings.Builder
+	for _, link := range links {
+		if link.view == current {
+			fmt.Fprintf(&result, "<a href=\"%s%s\" class=\"active\" aria-current=\"page\">%s</a>\n", path, link.query, link.label)
+		} else {
+			fmt.Fprintf(&result, "<a href=\"%s%s\">%s</a>\n", path, link.query, link.label)
+		}
+	}
+	fmt.Fprintf(&result, "<a href=\"%s?raw\" download>Raw</a>\n", path)
+	return result.String()
+}
+
+// rawContentType returns the Content-Type of the original bytes of a file
+func rawContentType(filePath string) string {
+	if contentType := getContentType(filePath); contentType != "application/octet-stream" {
+		return contentType
+	}
+	ext := strings.ToLower(filepath.Ext(filePath))
+	if ext == ".md" {
+		return "text/markdown; charset=utf-8"
+	}
+	if contentType := mime.TypeByExtension(ext); contentType != "" {
+		return contentType
+	}
+	if isTextFile(filePath) {
+		return "text/plain; charset=utf-8"
+	}
+	return "application/octet-stream"
+}
+
+// serveRaw serves the original bytes of a file as a download
+func serveRaw(w http.ResponseWriter, r *http.Request, filePath string) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	name := filepath.Base(filePath)
+	w.Header().Set("Content-Type", rawContentType(filePath))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	http.ServeContent(w, r, name, info.ModTime(), file)
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+// Test view selection from the query string and the file type
+func TestFileView(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+
+	writeTree(t, tmpDir, map[string]string{
+		"guide.md":  "# Guide",
+		"NOTES.MD":  "# Notes",
+		"notes.txt": "plain text",
+		"data.csv":  "a,b\n1,2\n",
+		"app.yaml":  "a: 1\n",
+		"nb.ipynb":  `{"nbformat": 4, "cells": []}`,
+		"data.bin":  "\x00\x01\x02",
+		"logo.png":  "\x89PNG",
+	})
+
+	tests := []struct {
+		url  string
+		file string
+		want string
+	}{
+		{"/guide", "guide.md", viewMarkdown},
+		{"/guide?view=source", "guide.md", viewSource},
+		{"/guide?view=hex", "guide.md", viewHex},
+		{"/NOTES.MD", "NOTES.MD", viewMarkdown},
+		{"/NOTES.MD?view=source", "NOTES.MD", viewSource},
+		{"/notes.txt", "notes.txt", viewText},
+		{"/notes.txt?view=source", "notes.txt", viewText},
+		{"/notes.txt?view=hex", "notes.txt", viewHex},
+		{"/data.csv", "data.csv", viewTable},
+		{"/data.csv?view=text", "data.csv", viewText},
+		{"/app.yaml", "app.yaml", viewData},
+		{"/app.yaml?view=text", "app.yaml", viewText},
+		{"/nb.ipynb", "nb.ipynb", viewNotebook},
+		{"/nb.ipynb?view=text", "nb.ipynb", viewText},
+		{"/data.bin", "data.bin", viewHex},
+		{"/data.bin?view=text", "data.bin", viewText},
+		{"/data.bin?view=unknown", "data.bin", viewHex},
+		{"/logo.png", "logo.png", viewMedia},
+		{"/logo.png?view=hex", "logo.png", viewHex},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.url, nil)
+			if got := fileView(req, tt.file); got != tt.want {
+				t.Errorf("fileView(%q) = %q, want %q", tt.url, got, tt.want)
+			}
+		})
+	}
+}
+
+// Test view modes served by serveMarkdown
+func TestServeMarkdownViews(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+
+	writeTree(t, tmpDir, map[string]string{
+		"guide.md": "# Guide <b>\n\nSome **bold** text",
+		"data.bin": "\x00\x01hello\xff",
+	})
+
+	tests := []struct {
+		url         string
+		contentType string
+		expected    []string
+		unexpected  []string
+	}{
+		{
+			url:         "/guide",
+			contentType: "text/html; charset=utf-8",
+			expected:    []string{"<strong>bold</strong>", `<a href="/guide" class="active" aria-current="page">Rendered</a>`, `<a href="/guide?view=source">Source</a>`, `<a href="/guide?raw" download>Raw</a>`},
+		},
+		{
+			url:         "/guide?view=source",
+			contentType: "text/html; charset=utf-8",
+			expected:    []string{`<pre class="godown-text">`, "Some **bold** text", "# Guide &lt;b&gt;", `class="active" aria-current="page">Source</a>`},
+			unexpected:  []string{"<strong>bold</strong>"},
+		},
+		{
+			url:         "/guide?view=hex",
+			contentType: "text/html; charset=utf-8",
+			expected:    []string{"23 20 47 75 69 64 65", `class="active" aria-current="page">Hex</a>`},
+		},
+		{
+			url:         "/data.bin?view=text",
+			contentType: "text/html; charset=utf-8",
+			expected:    []string{"hello�", `<a href="/data.bin?view=text" class="active" aria-current="page">Text</a>`},
+		},
+		{
+			url:         "/guide?raw",
+			contentType: "text/markdown; charset=utf-8",
+			expected:    []string{"# Guide <b>\n\nSome **bold** text"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.url, nil)
+			w := httptest.NewRecorder()
+			serveMarkdown(w, req)
+
+			if got := w.Result().Header.Get("Content-Type"); got != tt.contentType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.contentType)
+			}
+			body := w.Body.String()
+			for _, expected := range tt.expected {
+				if !strings.Contains(body, expected) {
+					t.Errorf("body should contain %q, got:\n%s", expected, body)
+				}
+			}
+			for _, unexpected := range tt.unexpected {
+				if strings.Contains(body, unexpected) {
+					t.Errorf("body should not contain %q", unexpected)
+				}
+			}
+		})
+	}
+}
+
+// Test raw download headers
+func TestServeRaw(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+
+	writeTree(t, tmpDir, map[string]string{
+		"archive.bin": "\x00\x01\x02\x03",
+		"logo.png":    "\x89PNG",
+	})
+
+	tests := []struct {
+		url         string
+		contentType string
+		disposition string
+	}{
+		{"/archive.bin?raw", "application/octet-stream", `attachment; filename=archive.bin`},
+		{"/logo.png?raw", "image/png", `attachment; filename=logo.png`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.url, nil)
+			w := httptest.NewRecorder()
+			serveMarkdown(w, req)
+
+			resp := w.Result()
+			if got := resp.Header.Get("Content-Type"); got != tt.contentType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.contentType)
+			}
+			if got := resp.Header.Get("Content-Disposition"); got != tt.disposition {
+				t.Errorf("Content-Disposition = %q, want %q", got, tt.disposition)
+			}
+		})
+	}
+
+	// Range requests are supported for large downloads
+	req := httptest.NewRequest("GET", "/archive.bin?raw", nil)
+	req.Header.Set("Range", "bytes=1-2")
+	w := httptest.NewRecorder()
+	serveMarkdown(w, req)
+	if got := w.Body.String(); got != "\x01\x02" {
+		t.Errorf("ranged body = %q, want %q", got, "\x01\x02")
+	}
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"path"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/gomarkdown/markdown/ast"
+)
+
+// wikiLink matches the [[target]] and [[target|label]] links
+var wikiLink = regexp.MustCompile(`\[\[([^\[\]\n]+?)\]\]`)
+
+// codeSpan matches the inline code of a line, whose links are not followed
+var codeSpan = regexp.MustCompile("`[^`]*`")
+
+// wikiIndex resolves the wiki links of a tree and records which pages link
+// to each page
+type wikiIndex struct {
+	pages     map[string]*pageInfo   // Pages by lookup key
+	backlinks map[string][]*pageInfo // Linking pages by target file
+}
+
+// buildWikiIndex indexes the pages and the wiki links between them
+func buildWikiIndex(pages []*pageInfo) *wikiIndex {
+	// Paths take precedence over file names, then over titles
+	w := &wikiIndex{pages: map[string]*pageInfo{}, backlinks: map[string][]*pageInfo{}}
+	add := func(key string, page *pageInfo) {
+		if _, ok := w.pages[key]; !ok && key != "" {
+			w.pages[key] = page
+		}
+	}
+	for _, page := range pages {
+		add(wikiKey(page.File), page)
+	}
+	for _, page := range pages {
+		name := path.Base(page.File)
+		if name == "README.md" && path.Dir(page.File) != "." {
+			name = path.Base(path.Dir(page.File))
+		}
+		add(wikiKey(name), page)
+	}
+	for _, page := range pages {
+		add(wikiKey(page.Title), page)
+	}
+
+	for _, page := range pages {
+		seen := map[string]bool{}
+		for _, link := range page.Links {
+			target, _ := w.resolve(link)
+			if target == nil || target == page || seen[target.File] {
+				continue
+			}
+			seen[target.File] = true
+			w.backlinks[target.File] = append(w.backlinks[target.File], page)
+		}
+	}
+	for _, sources := range w.backlinks {
+		sortPages(sources)
+	}
+	return w
+}
+
+// wikiKey normalizes a page name, path or title for lookups
+func wikiKey(name string) string {
+	name = strings.Trim(strings.TrimSpace(name), "/")
+	name = strings.TrimPrefix(name, "./")
+	if isMarkdownFile(name) {
+		name = strings.TrimSuffix(name, path.Ext(name))
+	}
+	return strings.ToLower(name)
+}
+
+// scanWikiLinks lists the targets of the wiki links of a Markdown source,
+// skipping code blocks and code spans
+func scanWikiLinks(content []byte) []string {
+	var targets []string
+	fence := ""
+	for _, line := range strings.Split(string(content), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fence = trimmed[:3]
+			continue
+		}
+
+		line = codeSpan.ReplaceAllString(line, "")
+		for _, m := range wikiLink.FindAllStringSubmatch(line, -1) {
+			target, _ := splitWikiLink(m[1])
+			targets = append(targets, target)
+		}
+	}
+	return targets
+}
+
+// splitWikiLink splits the content of a wiki link into its target and its
+// label, the pipe being escaped in tables
+func splitWikiLink(link string) (target, label string) {
+	link = strings.ReplaceAll(link, `\|`, "|")
+	target, label, found := strings.Cut(link, "|")
+	target = strings.TrimSpace(target)
+	if !found || strings.TrimSpace(label) == "" {
+		label = target
+	}
+	return target, strings.TrimSpace(label)
+}
+
+// resolve returns the page and the heading anchor of a link target
+// ("Page", "folder/page.md", "Page#Heading"); the page is nil when not found
+func (w *wikiIndex) resolve(target string) (*pageInfo, string) {
+	name, heading, _ := strings.Cut(target, "#")
+	anchor := ""
+	if heading != "" {
+		anchor = "#" + headingID(heading)
+	}
+	return w.pages[wikiKey(name)], anchor
+}
+
+// headingID returns the identifier given to a heading by the parser
+// (AutoHeadingIDs): lowercase letters and digits separated by dashes
+func headingID(text string) string {
+	var id []rune
+	dash := false
+	for _, r := range text {
+		if !unicode.IsLetter(r) && !unicode.IsNumber(r) {
+			dash = true
+			continue
+		}
+		if dash && len(id) > 0 {
+			id = append(id, '-')
+		}
+		dash = false
+		id = append(id, unicode.ToLower(r))
+	}
+	if len(id) == 0 {
+		return "empty"
+	}
+	return string(id)
+}
+
+// wikiLinkURL resolves a link target to the URL of its page, "" when the
+// page does not exist. A target only made of a heading stays on the page.
+func (t *docTree) wikiLinkURL(target string) string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if t.wiki == nil {
+		return ""
+	}
+	if strings.HasPrefix(target, "#") {
+		return "#" + headingID(target[1:])
+	}
+	page, anchor := t.wiki.resolve(target)
+	if page == nil {
+		return ""
+	}
+	return page.URL + anchor
+}
+
+// backlinks renders the list of the pages linking to the current one
+func (t *docTree) backlinks(current string) template.HTML {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if t.wiki == nil || len(t.wiki.backlinks[current]) == 0 {
+		return ""
+	}
+
+	var result strings.Builder
+	result.WriteString("<h2>Linked from</h2>\n<ul>\n")
+	for _, page := range t.wiki.backlinks[current] {
+		fmt.Fprintf(&result, "<li><a href=\"%s\">%s</a></li>\n", template.HTMLEscapeString(page.URL), template.HTMLEscapeString(page.Title))
+	}
+	result.WriteString("</ul>\n")
+	return template.HTML(result.String())
+}
+
+// wikiTransform replaces the wiki links of the texts with links to the pages
+// of the tree; links to missing pages are only shown as such
+func wikiTransform(doc ast.Node, tree *docTree) {
+	if tree == nil {
+		return
+	}
+
+	var texts []*ast.Text
+	ast.WalkFunc(doc, func(node ast.Node, entering bool) ast.WalkStatus {
+		if text, ok := node.(*ast.Text); ok && entering && !insideLink(text) {
+			texts = append(texts, text)
+		}
+		return ast.GoToNext
+	})
+
+	for _, text := range texts {
+		if text.GetParent() == nil {
+			continue
+		}
+		// The parser splits the texts at the escaped pipes of tables
+		for {
+			next, ok := ast.GetNextNode(text).(*ast.Text)
+			if !ok {
+				break
+			}
+			text.Literal = append(append([]byte{}, text.Literal...), next.Literal...)
+			ast.RemoveFromTree(next)
+			next.SetParent(nil)
+		}
+		transformWikiLinks(text, tree)
+	}
+}
+
+// transformWikiLinks splits a text around its wiki links
+func transformWikiLinks(text *ast.Text, tree *docTree) {
+	matches := wikiLink.FindAllSubmatchIndex(text.Literal, -1)
+	if matches == nil {
+		return
+	}
+
+	literal := text.Literal
+	var nodes []ast.Node
+	last := 0
+	for _, m := range matches {
+		target, label := splitWikiLink(string(literal[m[2]:m[3]]))
+		nodes = append(nodes, &ast.Text{Leaf: ast.Leaf{Literal: literal[last:m[0]]}})
+		if url := tree.wikiLinkURL(target); url != "" {
+			link := &ast.Link{Destination: []byte(url), AdditionalAttributes: []string{`class="godown-wikilink"`}}
+			ast.AppendChild(link, &ast.Text{Leaf: ast.Leaf{Literal: []byte(label)}})
+			nodes = append(nodes, link)
+		} else {
+			missing := fmt.Sprintf("<span class=\"godown-wikilink-missing\" title=\"%s\">%s</span>",
+				template.HTMLEscapeString("Page not found: "+target), template.HTMLEscapeString(label))
+			nodes = append(nodes, &ast.HTMLSpan{Leaf: ast.Leaf{Literal: []byte(missing)}})
+		}
+		last = m[1]
+	}
+	nodes = append(nodes, &ast.Text{Leaf: ast.Leaf{Literal: literal[last:]}})
+
+	for _, node := range nodes {
+		insertBefore(text, node)
+	}
+	ast.RemoveFromTree(text)
+}
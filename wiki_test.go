@@ -0,0 +1,157 @@
+package main
+
+import (
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+// Test the resolution of link targets by path, file name and title
+func TestWikiLinkURL(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTree(t, tmpDir, map[string]string{
+		"README.md":          "# Home",
+		"guide/setup.md":     "# Installing godown",
+		"guide/README.md":    "# Guide",
+		"notes/setup.md":     "# Other setup",
+		"drafts/secret.md":   "# Secret",
+		"Meeting Notes.md":   "no heading",
+		".godownignore":      "drafts\n",
+		"guide/Capital.MD":   "# Capital",
+		"guide/not-page.txt": "[[README]]",
+	})
+
+	tree := newDocTree(tmpDir, "/docs", "README.md")
+
+	tests := map[string]string{
+		"guide/setup":             "/docs/guide/setup",
+		"guide/setup.md":          "/docs/guide/setup",
+		"notes/setup":             "/docs/notes/setup",
+		"setup":                   "/docs/guide/setup",
+		"Installing godown":       "/docs/guide/setup",
+		"installing GODOWN":       "/docs/guide/setup",
+		"guide":                   "/docs/guide",
+		"README":                  "/docs/",
+		"Meeting Notes":           "/docs/Meeting%20Notes",
+		"capital":                 "/docs/guide/Capital",
+		"setup#Step 2: Configure": "/docs/guide/setup#step-2-configure",
+		"#Local Heading":          "#local-heading",
+		"secret":                  "",
+		"missing":                 "",
+	}
+	for target, want := range tests {
+		if got := tree.wikiLinkURL(target); got != want {
+			t.Errorf("wikiLinkURL(%q) = %q, want %q", target, got, want)
+		}
+	}
+}
+
+// Test the wiki links rendering
+func TestWikiTransform(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTree(t, tmpDir, map[string]string{
+		"README.md": "# Home",
+		"page.md":   "# Page Name",
+	})
+	tree := newDocTree(tmpDir, "", "README.md")
+
+	tests := []struct {
+		name       string
+		input      string
+		contains   []string
+		unexpected []string
+	}{
+		{
+			name:     "Title",
+			input:    "See [[Page Name]].",
+			contains: []string{`See <a class="godown-wikilink" href="/page">Page Name</a>.`},
+		},
+		{
+			name:     "Label",
+			input:    "See [[page|the page]] and [[README|home]]",
+			contains: []string{`<a class="godown-wikilink" href="/page">the page</a>`, `<a class="godown-wikilink" href="/">home</a>`},
+		},
+		{
+			name:     "Missing page",
+			input:    "See [[Nowhere|there & back]]",
+			contains: []string{`<span class="godown-wikilink-missing" title="Page not found: Nowhere">there &amp; back</span>`},
+		},
+		{
+			name:     "Table",
+			input:    "| Link |\n|---|\n| [[page\\|label]] |",
+			contains: []string{`<td><a class="godown-wikilink" href="/page">label</a></td>`},
+		},
+		{
+			name:       "Code",
+			input:      "`[[page]]`\n\n```\n[[page]]\n```",
+			contains:   []string{"<code>[[page]]</code>"},
+			unexpected: []string{"godown-wikilink"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := string(renderMarkdown([]byte(tt.input), siteMarkdown, tree))
+			for _, expected := range tt.contains {
+				if !strings.Contains(result, expected) {
+					t.Errorf("renderMarkdown() should contain %q, got:\n%s", expected, result)
+				}
+			}
+			for _, unexpected := range tt.unexpected {
+				if strings.Contains(result, unexpected) {
+					t.Errorf("renderMarkdown() should not contain %q, got:\n%s", unexpected, result)
+				}
+			}
+		})
+	}
+
+	if result := string(mdToHTML([]byte("[[page]]"))); !strings.Contains(result, "[[page]]") {
+		t.Errorf("wiki links should stay text without a tree, got:\n%s", result)
+	}
+}
+
+// Test the link graph
+func TestScanWikiLinks(t *testing.T) {
+	content := "[[One]] and [[Two|label]] `[[Code]]`\n```\n[[Fenced]]\n```\n| [[Three\\|label]] |"
+	got := strings.Join(scanWikiLinks([]byte(content)), ",")
+	if got != "One,Two,Three" {
+		t.Errorf("scanWikiLinks() = %q, want %q", got, "One,Two,Three")
+	}
+}
+
+// Test the backlinks section of the pages
+func TestServeMarkdownBacklinks(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTree(t, tmpDir, map[string]string{
+		"README.md": "# Home\n\n[[Target]] [[target|again]]",
+		"b.md":      "# Beta\n\nSee [[target]]",
+		"a.md":      "# Alpha\n\nSee [[Target#Usage]]",
+		"target.md": "# Target\n\nSelf [[target]]",
+	})
+
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+
+	oldTree := siteTree
+	siteTree = newDocTree(".", "", "README.md")
+	defer func() { siteTree = oldTree }()
+
+	req := httptest.NewRequest("GET", "/target", nil)
+	w := httptest.NewRecorder()
+	serveMarkdown(w, req)
+
+	body := w.Body.String()
+	expected := "<h2>Linked from</h2>\n<ul>\n<li><a href=\"/a\">Alpha</a></li>\n<li><a href=\"/b\">Beta</a></li>\n<li><a href=\"/\">Home</a></li>\n</ul>"
+	if !strings.Contains(body, expected) {
+		t.Errorf("page should list its backlinks once, got:\n%s", body)
+	}
+
+	req = httptest.NewRequest("GET", "/a", nil)
+	w = httptest.NewRecorder()
+	serveMarkdown(w, req)
+	if strings.Contains(w.Body.String(), "godown-backlinks") {
+		t.Errorf("page without backlinks should not have the section")
+	}
+}